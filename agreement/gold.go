@@ -0,0 +1,40 @@
+package agreement
+
+import "Lynx/models"
+
+// GoldMRC scores the answer of an annotator to a gold question against the
+// label of the question by the match rule of the project, see Match. Labels
+// without a true answer are unanswerable questions.
+func GoldMRC(rule models.MatchRule, label models.Label, answer models.MRCAnswer) (float64, bool) {
+	truth := models.MRCAnswer{Answer: label.TrueAnswer, StartIdx: label.StartIdx, IsImpossible: label.TrueAnswer == ""}
+	return Match(rule, truth, answer)
+}
+
+// GoldSentiment scores the answer of an annotator to a gold sentiment task
+// against its label: the F1 of the (category, polarity) pairs both have. It
+// is only correct when the pairs are the same.
+func GoldSentiment(label models.Label, answer models.SentiAnswer) (float64, bool) {
+	truth := make(map[models.GoldSentiment]bool)
+	for _, s := range label.Sentiments {
+		truth[s] = true
+	}
+	categories := make(map[string]string)
+	for _, aspect := range answer.Aspect {
+		categories[aspect.AspectId] = aspect.MajorAspect
+	}
+	given := make(map[models.GoldSentiment]bool)
+	for _, sentiment := range answer.Sentiment {
+		given[models.GoldSentiment{Category: categories[sentiment.AspectId], Polarity: sentiment.Sentiment}] = true
+	}
+	if len(truth) == 0 && len(given) == 0 {
+		return 1, true
+	}
+	shared := 0
+	for s := range given {
+		if truth[s] {
+			shared++
+		}
+	}
+	score := 2 * float64(shared) / float64(len(truth)+len(given))
+	return score, shared == len(truth) && shared == len(given)
+}
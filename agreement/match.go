@@ -0,0 +1,60 @@
+package agreement
+
+import (
+	"unicode/utf8"
+
+	"Lynx/models"
+)
+
+// Match scores the answer of a validator against the original answer it
+// validates by the method of rule, see models.MatchRule, and tells whether
+// the score verifies the original. Two unanswerable answers score 1, one
+// unanswerable and one answered 0.
+func Match(rule models.MatchRule, original, validation models.MRCAnswer) (float64, bool) {
+	rule = rule.Effective()
+	score := matchScore(rule.Method, original, validation)
+	return score, score >= rule.Threshold
+}
+
+func matchScore(method string, original, validation models.MRCAnswer) float64 {
+	impossibleA := original.IsImpossible || original.Answer == ""
+	impossibleB := validation.IsImpossible || validation.Answer == ""
+	if impossibleA || impossibleB {
+		if impossibleA == impossibleB {
+			return 1
+		}
+		return 0
+	}
+	startA, endA := original.StartIdx, original.StartIdx+utf8.RuneCountInString(original.Answer)
+	startB, endB := validation.StartIdx, validation.StartIdx+utf8.RuneCountInString(validation.Answer)
+	shared := min(endA, endB) - max(startA, startB)
+	if shared < 0 {
+		shared = 0
+	}
+	switch method {
+	case models.MatchOverlap:
+		return float64(shared) / float64(min(endA-startA, endB-startB))
+	case models.MatchTokenF1:
+		return TokenF1(original.Answer, validation.Answer)
+	case models.MatchCharIoU:
+		return float64(shared) / float64(endA-startA+endB-startB-shared)
+	}
+	if original.Answer == validation.Answer && original.StartIdx == validation.StartIdx {
+		return 1
+	}
+	return 0
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package agreement
+
+import (
+	"testing"
+
+	"Lynx/models"
+)
+
+func TestMatch(t *testing.T) {
+	// in 臺灣位於東亞，首都為臺北市。 the capital 臺北市 is at rune 10
+	answer := func(text string, start int) models.MRCAnswer {
+		return models.MRCAnswer{Answer: text, StartIdx: start}
+	}
+	unanswerable := models.MRCAnswer{IsImpossible: true}
+	original := answer("臺北市", 10)
+	rule := func(method string, threshold float64) models.MatchRule {
+		return models.MatchRule{Method: method, Threshold: threshold}
+	}
+	tests := []struct {
+		name       string
+		rule       models.MatchRule
+		original   models.MRCAnswer
+		validation models.MRCAnswer
+		score      float64
+		verified   bool
+	}{
+		{"exact", rule(models.MatchExact, 1), original, answer("臺北市", 10), 1, true},
+		{"exact elsewhere", rule(models.MatchExact, 1), original, answer("臺北市", 0), 0, false},
+		{"exact partial", rule(models.MatchExact, 1), original, answer("臺北", 10), 0, false},
+		{"exact ignores its threshold", rule(models.MatchExact, 0), original, answer("臺北", 10), 0, false},
+		{"no method is exact", models.MatchRule{}, original, answer("臺北市", 10), 1, true},
+
+		{"overlap contained", rule(models.MatchOverlap, 1), original, answer("臺北", 10), 1, true},
+		{"overlap at the threshold", rule(models.MatchOverlap, 0.5), original, answer("市。", 12), 0.5, true},
+		{"overlap under the threshold", rule(models.MatchOverlap, 0.51), original, answer("市。", 12), 0.5, false},
+		{"overlap disjoint", rule(models.MatchOverlap, 0.5), original, answer("東亞", 4), 0, false},
+		{"overlap adjacent", rule(models.MatchOverlap, 0.5), original, answer("為", 9), 0, false},
+		{"threshold 0 verifies anything", rule(models.MatchOverlap, 0), original, answer("東亞", 4), 0, true},
+
+		{"tokenF1 at the threshold", rule(models.MatchTokenF1, 0.8), original, answer("臺北", 10), 0.8, true},
+		{"tokenF1 under the threshold", rule(models.MatchTokenF1, 0.81), original, answer("臺北", 10), 0.8, false},
+		{"tokenF1 ignores offsets", rule(models.MatchTokenF1, 1), original, answer("臺北市", 0), 1, true},
+		{"tokenF1 disjoint", rule(models.MatchTokenF1, 0.1), original, answer("東亞", 4), 0, false},
+
+		{"charIoU contained", rule(models.MatchCharIoU, 2.0/3), original, answer("臺北", 10), 2.0 / 3, true},
+		{"charIoU under the threshold", rule(models.MatchCharIoU, 0.7), original, answer("臺北", 10), 2.0 / 3, false},
+		{"charIoU shifted", rule(models.MatchCharIoU, 0.5), original, answer("北市。", 11), 0.5, true},
+		{"charIoU disjoint", rule(models.MatchCharIoU, 0.1), original, answer("東亞", 4), 0, false},
+
+		{"both unanswerable", rule(models.MatchExact, 1), unanswerable, unanswerable, 1, true},
+		{"both unanswerable by overlap", rule(models.MatchOverlap, 0.5), unanswerable, unanswerable, 1, true},
+		{"both empty", rule(models.MatchCharIoU, 0.5), answer("", 0), answer("", 0), 1, true},
+		{"empty and unanswerable", rule(models.MatchTokenF1, 0.5), answer("", 0), unanswerable, 1, true},
+		{"unanswerable original", rule(models.MatchOverlap, 0.5), unanswerable, answer("臺北市", 10), 0, false},
+		{"unanswerable validation", rule(models.MatchTokenF1, 0.5), original, unanswerable, 0, false},
+		{"empty validation", rule(models.MatchCharIoU, 0), original, answer("", 10), 0, true},
+	}
+	for _, tt := range tests {
+		score, verified := Match(tt.rule, tt.original, tt.validation)
+		if !near(score, tt.score) || verified != tt.verified {
+			t.Errorf("%s: Match = %v, %v, want %v, %v", tt.name, score, verified, tt.score, tt.verified)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package agreement measures how much the annotators of a project agree:
+// chance corrected agreement on sentiment polarities, and exact match and
+// token F1 on MRC answer spans. Agreement is measured over units: the labels
+// the annotators gave to one item, one label per annotator.
+package agreement
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CohenKappa is Cohen's kappa of two annotators who labelled the same
+// units, a[i] and b[i] being their labels of the i-th unit. It is undefined
+// when there are no units or chance alone explains every agreement, e.g.
+// when both only used one label.
+func CohenKappa(a, b []string) (float64, bool) {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0, false
+	}
+	n := float64(len(a))
+	agreed := 0.0
+	countsA := make(map[string]float64)
+	countsB := make(map[string]float64)
+	for i := range a {
+		if a[i] == b[i] {
+			agreed++
+		}
+		countsA[a[i]]++
+		countsB[b[i]]++
+	}
+	expected := 0.0
+	for label, c := range countsA {
+		expected += c / n * countsB[label] / n
+	}
+	return chanceCorrected(agreed/n, expected)
+}
+
+// FleissKappa is Fleiss' kappa of units labelled by any number of
+// annotators. Units may have a different number of labels; each is
+// weighted equally, and units with less than two labels are left out.
+func FleissKappa(units [][]string) (float64, bool) {
+	observed, ok := ObservedAgreement(units)
+	if !ok {
+		return 0, false
+	}
+	counts := make(map[string]float64)
+	total := 0.0
+	for _, unit := range units {
+		if len(unit) < 2 {
+			continue
+		}
+		for _, label := range unit {
+			counts[label]++
+			total++
+		}
+	}
+	expected := 0.0
+	for _, c := range counts {
+		expected += (c / total) * (c / total)
+	}
+	return chanceCorrected(observed, expected)
+}
+
+// ObservedAgreement is the share of annotator pairs that agree within a
+// unit, averaged over the units with at least two labels.
+func ObservedAgreement(units [][]string) (float64, bool) {
+	sum, rated := 0.0, 0
+	for _, unit := range units {
+		m := len(unit)
+		if m < 2 {
+			continue
+		}
+		agreeing := 0
+		for _, c := range labelCounts(unit) {
+			agreeing += c * (c - 1)
+		}
+		sum += float64(agreeing) / float64(m*(m-1))
+		rated++
+	}
+	if rated == 0 {
+		return 0, false
+	}
+	return sum / float64(rated), true
+}
+
+// KrippendorffAlpha is Krippendorff's alpha for nominal labels. Every unit
+// may be labelled by a different set of annotators; missing labels are
+// simply left out of the unit. It is undefined when all labels are equal.
+func KrippendorffAlpha(units [][]string) (float64, bool) {
+	// coincidences of the pairable labels: a unit of m labels adds
+	// 1/(m-1) for every ordered pair of its labels
+	coincidences := make(map[[2]string]float64)
+	totals := make(map[string]float64)
+	n := 0.0
+	for _, unit := range units {
+		m := len(unit)
+		if m < 2 {
+			continue
+		}
+		for i := range unit {
+			for j := range unit {
+				if i != j {
+					coincidences[[2]string{unit[i], unit[j]}] += 1 / float64(m-1)
+				}
+			}
+			totals[unit[i]]++
+			n++
+		}
+	}
+	if n < 2 {
+		return 0, false
+	}
+	disagreed := 0.0
+	for pair, o := range coincidences {
+		if pair[0] != pair[1] {
+			disagreed += o
+		}
+	}
+	expected := 0.0
+	for c, nc := range totals {
+		for k, nk := range totals {
+			if c != k {
+				expected += nc * nk
+			}
+		}
+	}
+	if expected == 0 {
+		return 0, false
+	}
+	return 1 - (n-1)*disagreed/expected, true
+}
+
+func chanceCorrected(observed, expected float64) (float64, bool) {
+	if expected >= 1 {
+		return 0, false
+	}
+	return (observed - expected) / (1 - expected), true
+}
+
+func labelCounts(unit []string) map[string]int {
+	counts := make(map[string]int)
+	for _, label := range unit {
+		counts[label]++
+	}
+	return counts
+}
+
+// ExactMatch tells whether two answers are equal once normalized. Two
+// empty answers, questions both found unanswerable, match.
+func ExactMatch(a, b string) bool {
+	return strings.Join(Tokens(a), " ") == strings.Join(Tokens(b), " ")
+}
+
+// TokenF1 is the F1 score of the tokens two answers share, as in the SQuAD
+// evaluation: 1 for two empty answers, 0 when only one is empty.
+func TokenF1(a, b string) float64 {
+	tokensA, tokensB := Tokens(a), Tokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		if len(tokensA) == len(tokensB) {
+			return 1
+		}
+		return 0
+	}
+	counts := labelCounts(tokensB)
+	shared := 0
+	for _, t := range tokensA {
+		if counts[t] > 0 {
+			counts[t]--
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	precision := float64(shared) / float64(len(tokensA))
+	recall := float64(shared) / float64(len(tokensB))
+	return 2 * precision * recall / (precision + recall)
+}
+
+// Tokens normalizes an answer the way SQuAD evaluations do, lower case and
+// without punctuation or English articles, and splits it into words. Chinese,
+// Japanese and Korean characters are a token each, since those scripts do not
+// put spaces between words.
+func Tokens(answer string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		switch w := word.String(); w {
+		case "a", "an", "the":
+		default:
+			tokens = append(tokens, w)
+		}
+		word.Reset()
+	}
+	for _, r := range strings.ToLower(answer) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case unicode.IsPunct(r):
+			// dropped, "don't" is the word "dont"
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
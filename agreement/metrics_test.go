@@ -0,0 +1,208 @@
+package agreement
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// near compares to the precision textbooks print their results with.
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 0.0005
+}
+
+// pairs turns the labels of two annotators into units.
+func pairs(a, b []string) [][]string {
+	units := make([][]string, len(a))
+	for i := range a {
+		units[i] = []string{a[i], b[i]}
+	}
+	return units
+}
+
+// fromCounts expands rows of how many annotators chose each category into
+// units of labels.
+func fromCounts(rows [][]int) [][]string {
+	units := make([][]string, len(rows))
+	for i, row := range rows {
+		for category, n := range row {
+			for ; n > 0; n-- {
+				units[i] = append(units[i], strconv.Itoa(category+1))
+			}
+		}
+	}
+	return units
+}
+
+func TestCohenKappa(t *testing.T) {
+	// 50 items: both said yes to 20, a alone to 5, b alone to 10, and both
+	// said no to 15; observed 0.7, expected 0.5
+	var a, b []string
+	for _, n := range []struct {
+		a, b  string
+		count int
+	}{{"yes", "yes", 20}, {"yes", "no", 5}, {"no", "yes", 10}, {"no", "no", 15}} {
+		for i := 0; i < n.count; i++ {
+			a, b = append(a, n.a), append(b, n.b)
+		}
+	}
+	tests := []struct {
+		name string
+		a, b []string
+		want float64
+		ok   bool
+	}{
+		{"yes/no example", a, b, 0.4, true},
+		{"perfect", []string{"x", "y", "x"}, []string{"x", "y", "x"}, 1, true},
+		{"opposite", []string{"x", "y"}, []string{"y", "x"}, -1, true},
+		{"one label only", []string{"x", "x", "x"}, []string{"x", "x", "x"}, 0, false},
+		{"no units", nil, nil, 0, false},
+		{"different lengths", []string{"x", "y"}, []string{"x"}, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := CohenKappa(tt.a, tt.b)
+		if ok != tt.ok || (ok && !near(got, tt.want)) {
+			t.Errorf("%s: CohenKappa = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFleissKappa(t *testing.T) {
+	// the worked example of the Wikipedia article: 10 subjects rated by 14
+	// raters into 5 categories, P = 0.378, Pe = 0.213 and kappa 0.210
+	wikipedia := fromCounts([][]int{
+		{0, 0, 0, 0, 14},
+		{0, 2, 6, 4, 2},
+		{0, 0, 3, 5, 6},
+		{0, 3, 9, 2, 0},
+		{2, 2, 8, 1, 1},
+		{7, 7, 0, 0, 0},
+		{3, 2, 6, 3, 0},
+		{2, 5, 3, 2, 2},
+		{6, 5, 2, 1, 0},
+		{0, 2, 2, 3, 7},
+	})
+	observed, ok := ObservedAgreement(wikipedia)
+	if !ok || !near(observed, 0.378) {
+		t.Errorf("ObservedAgreement = %v, %v, want 0.378", observed, ok)
+	}
+	tests := []struct {
+		name  string
+		units [][]string
+		want  float64
+		ok    bool
+	}{
+		{"wikipedia example", wikipedia, 0.210, true},
+		{"two annotators as Scott's pi", pairs([]string{"x", "x", "y", "y"}, []string{"x", "y", "y", "y"}), 0.467, true},
+		{"single labels are left out", append(pairs([]string{"x", "y"}, []string{"x", "y"}), []string{"z"}), 1, true},
+		{"all labels equal", [][]string{{"x", "x"}, {"x", "x", "x"}}, 0, false},
+		{"fewer than two labels per unit", [][]string{{"x"}, {"y"}, {}}, 0, false},
+		{"no units", nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := FleissKappa(tt.units)
+		if ok != tt.ok || (ok && !near(got, tt.want)) {
+			t.Errorf("%s: FleissKappa = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKrippendorffAlpha(t *testing.T) {
+	// Krippendorff (2011), "Computing Krippendorff's Alpha-Reliability":
+	// four observers, twelve units and missing values, nominal alpha 0.743;
+	// the last unit has a single value and is not pairable
+	missing := [][]string{
+		{"1", "1", "1"},
+		{"2", "2", "3", "2"},
+		{"3", "3", "3", "3"},
+		{"3", "3", "3", "3"},
+		{"2", "2", "2", "2"},
+		{"1", "2", "3", "4"},
+		{"4", "4", "4", "4"},
+		{"1", "1", "2", "1"},
+		{"2", "2", "2", "2"},
+		{"5", "5", "5"},
+		{"1", "1"},
+		{"3"},
+	}
+	// and two observers of binary data without missing values, 0.095
+	binary := pairs(
+		[]string{"0", "1", "0", "0", "0", "0", "0", "0", "1", "0"},
+		[]string{"1", "1", "1", "0", "0", "1", "0", "0", "0", "0"},
+	)
+	tests := []struct {
+		name  string
+		units [][]string
+		want  float64
+		ok    bool
+	}{
+		{"nominal, missing values", missing, 0.743, true},
+		{"binary, two observers", binary, 0.095, true},
+		{"perfect", [][]string{{"x", "x"}, {"y", "y", "y"}}, 1, true},
+		{"all labels equal", [][]string{{"x", "x"}, {"x", "x", "x"}}, 0, false},
+		{"fewer than two labels per unit", [][]string{{"x"}, {"y"}, {}}, 0, false},
+		{"no units", nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := KrippendorffAlpha(tt.units)
+		if ok != tt.ok || (ok && !near(got, tt.want)) {
+			t.Errorf("%s: KrippendorffAlpha = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   []string
+	}{
+		{"", nil},
+		{"The Eiffel Tower", []string{"eiffel", "tower"}},
+		{"an apple, a pear!", []string{"apple", "pear"}},
+		{"don't", []string{"dont"}},
+		{"  1889\tParis ", []string{"1889", "paris"}},
+		{"臺北市", []string{"臺", "北", "市"}},
+		{"首都為Taipei。", []string{"首", "都", "為", "taipei"}},
+		{"ひらがなとカタカナ", []string{"ひ", "ら", "が", "な", "と", "カ", "タ", "カ", "ナ"}},
+		{"서울", []string{"서", "울"}},
+	}
+	for _, tt := range tests {
+		got := Tokens(tt.answer)
+		if len(got) != len(tt.want) {
+			t.Errorf("Tokens(%q) = %q, want %q", tt.answer, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Tokens(%q) = %q, want %q", tt.answer, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTokenF1(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"the cat sat", "a cat sat down", 0.8},
+		{"Cat", "cat.", 1},
+		{"cat", "dog", 0},
+		// repeated tokens are only shared as often as both have them
+		{"cat cat", "cat", 2.0 / 3},
+		{"臺北市", "臺北", 0.8},
+		{"", "", 1},
+		{"the", "", 1},
+		{"cat", "", 0},
+		{"", "cat", 0},
+	}
+	for _, tt := range tests {
+		if got := TokenF1(tt.a, tt.b); !near(got, tt.want) {
+			t.Errorf("TokenF1(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := TokenF1(tt.b, tt.a); !near(got, tt.want) {
+			t.Errorf("TokenF1(%q, %q) = %v, want %v, it is symmetric", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
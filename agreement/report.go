@@ -0,0 +1,331 @@
+package agreement
+
+import (
+	"fmt"
+	"sort"
+
+	"Lynx/importer"
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// conflict is the polarity of an aspect category an annotator gave
+// several sentiments to, as in SemEval.
+const conflict = "conflict"
+
+// Scores is the agreement over a set of units. Metrics that do not apply to
+// the project type, or are undefined for the units, are left out.
+type Scores struct {
+	// Units counts the units labelled by at least two annotators.
+	Units      int `json:"units"`
+	Annotators int `json:"annotators"`
+	// ObservedAgreement is the share of agreeing annotator pairs per unit.
+	ObservedAgreement *float64 `json:"observedAgreement,omitempty"`
+	CohenKappa        *float64 `json:"cohenKappa,omitempty"`
+	FleissKappa       *float64 `json:"fleissKappa,omitempty"`
+	KrippendorffAlpha *float64 `json:"krippendorffAlpha,omitempty"`
+	// ExactMatch and F1 average over every pair of answers to a question.
+	ExactMatch *float64 `json:"exactMatch,omitempty"`
+	F1         *float64 `json:"f1,omitempty"`
+}
+
+// ArticleScores is the agreement over the units of one article.
+type ArticleScores struct {
+	ArticleId string `json:"articleId"`
+	Scores
+}
+
+// PairScores is the agreement of two annotators over the units both labelled.
+type PairScores struct {
+	Annotators [2]string `json:"annotators"`
+	Scores
+}
+
+// Report is the agreement of the annotators of a project, over the whole
+// project, per article and per pair of annotators.
+type Report struct {
+	ProjectId   string          `json:"projectId"`
+	ProjectType string          `json:"projectType"`
+	Project     Scores          `json:"project"`
+	Articles    []ArticleScores `json:"articles"`
+	Pairs       []PairScores    `json:"pairs"`
+}
+
+// unit is one item with the label every annotator gave it.
+type unit struct {
+	articleId string
+	labels    map[string]string
+}
+
+// ProjectReport measures the agreement of a project. In sentiment projects a
+// unit is an aspect category of a task, labelled with the polarity each
+// annotator gave it; "conflict" when an annotator gave it several. Categories
+// an annotator did not find are missing from the unit. In MRC projects a unit
+// is a question of a task, labelled with the latest answer of each annotator
+// and validator, the empty answer when they found it unanswerable. Gold
+// tasks and questions are left out, see models.Label.
+func ProjectReport(store service.Store, project models.Project) (*Report, error) {
+	var units []unit
+	var err error
+	switch project.ProjectType {
+	case importer.TypeSentiment:
+		units, err = sentimentUnits(store, project)
+	case importer.TypeMRC:
+		units, err = mrcUnits(store, project)
+	default:
+		return nil, fmt.Errorf("cannot measure the agreement of %q projects", project.ProjectType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	score := scoreSentiment
+	if project.ProjectType == importer.TypeMRC {
+		score = scoreMRC
+	}
+
+	report := &Report{
+		ProjectId:   project.ProjectId.Hex(),
+		ProjectType: project.ProjectType,
+		Project:     score(units),
+		Articles:    []ArticleScores{},
+		Pairs:       []PairScores{},
+	}
+	var articleIds []string
+	byArticle := make(map[string][]unit)
+	for _, u := range units {
+		if _, ok := byArticle[u.articleId]; !ok {
+			articleIds = append(articleIds, u.articleId)
+		}
+		byArticle[u.articleId] = append(byArticle[u.articleId], u)
+	}
+	for _, articleId := range articleIds {
+		scores := score(byArticle[articleId])
+		if scores.Units != 0 {
+			report.Articles = append(report.Articles, ArticleScores{ArticleId: articleId, Scores: scores})
+		}
+	}
+	annotators := annotatorsOf(units)
+	for i, a := range annotators {
+		for _, b := range annotators[i+1:] {
+			shared := pairUnits(units, a, b)
+			if len(shared) == 0 {
+				continue
+			}
+			scores := score(shared)
+			if project.ProjectType == importer.TypeSentiment {
+				scores.FleissKappa = nil
+				labelsA := make([]string, len(shared))
+				labelsB := make([]string, len(shared))
+				for k, u := range shared {
+					labelsA[k], labelsB[k] = u.labels[a], u.labels[b]
+				}
+				scores.CohenKappa = value(CohenKappa(labelsA, labelsB))
+			}
+			report.Pairs = append(report.Pairs, PairScores{Annotators: [2]string{a, b}, Scores: scores})
+		}
+	}
+	return report, nil
+}
+
+func sentimentUnits(store service.Store, project models.Project) ([]unit, error) {
+	tasks, err := store.GetSentiTasksByProjectId(project.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	taskIds := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		taskIds[i] = task.TaskId
+	}
+	aspects, err := store.GetAspectsByTaskIds(taskIds)
+	if err != nil {
+		return nil, err
+	}
+	sentiments, err := store.GetSentimentsByTaskIds(taskIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// aspect ids are only unique within the answer of one annotator
+	type aspectKey struct {
+		taskId   primitive.ObjectID
+		userId   string
+		aspectId string
+	}
+	categories := make(map[aspectKey]string)
+	for _, aspect := range aspects {
+		categories[aspectKey{aspect.TaskId, aspect.UserId, aspect.AspectId}] = aspect.MajorAspect
+	}
+	type unitKey struct {
+		taskId   primitive.ObjectID
+		category string
+	}
+	labels := make(map[unitKey]map[string]string)
+	for _, sentiment := range sentiments {
+		category, ok := categories[aspectKey{sentiment.TaskId, sentiment.UserId, sentiment.AspectId}]
+		if !ok {
+			continue
+		}
+		key := unitKey{sentiment.TaskId, category}
+		if labels[key] == nil {
+			labels[key] = make(map[string]string)
+		}
+		given, ok := labels[key][sentiment.UserId]
+		if ok && given != sentiment.Sentiment {
+			labels[key][sentiment.UserId] = conflict
+		} else {
+			labels[key][sentiment.UserId] = sentiment.Sentiment
+		}
+	}
+
+	var units []unit
+	for _, task := range tasks {
+		if task.Gold {
+			continue
+		}
+		var taskCategories []string
+		for key := range labels {
+			if key.taskId == task.TaskId {
+				taskCategories = append(taskCategories, key.category)
+			}
+		}
+		sort.Strings(taskCategories)
+		for _, category := range taskCategories {
+			units = append(units, unit{articleId: task.ArticleId.Hex(), labels: labels[unitKey{task.TaskId, category}]})
+		}
+	}
+	return units, nil
+}
+
+func mrcUnits(store service.Store, project models.Project) ([]unit, error) {
+	articles, err := store.GetArticlesByProjectId(project.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	articleIds := make([]string, len(articles))
+	for i, article := range articles {
+		articleIds[i] = article.ArticleId.Hex()
+	}
+	answers, err := store.GetAnswersByArticleIds(articleIds)
+	if err != nil {
+		return nil, err
+	}
+	validations, err := store.GetValidationAnswersByArticleIds(articleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	type unitKey struct {
+		articleId string
+		taskId    string
+		question  string
+	}
+	var keys []unitKey
+	labels := make(map[unitKey]map[string]string)
+	for _, answer := range append(answers, validations...) {
+		if answer.Status == models.AnswerStatusGold {
+			continue
+		}
+		key := unitKey{answer.ArticleId, answer.TaskId, answer.Question}
+		if labels[key] == nil {
+			keys = append(keys, key)
+			labels[key] = make(map[string]string)
+		}
+		label := answer.Answer
+		if answer.IsImpossible {
+			label = ""
+		}
+		labels[key][answer.UserId] = label
+	}
+	units := make([]unit, len(keys))
+	for i, key := range keys {
+		units[i] = unit{articleId: key.articleId, labels: labels[key]}
+	}
+	return units, nil
+}
+
+func scoreSentiment(units []unit) Scores {
+	scores := Scores{Annotators: len(annotatorsOf(units))}
+	lists := make([][]string, 0, len(units))
+	for _, u := range units {
+		if len(u.labels) < 2 {
+			continue
+		}
+		list := make([]string, 0, len(u.labels))
+		for _, label := range u.labels {
+			list = append(list, label)
+		}
+		lists = append(lists, list)
+	}
+	scores.Units = len(lists)
+	scores.ObservedAgreement = value(ObservedAgreement(lists))
+	scores.FleissKappa = value(FleissKappa(lists))
+	scores.KrippendorffAlpha = value(KrippendorffAlpha(lists))
+	return scores
+}
+
+func scoreMRC(units []unit) Scores {
+	scores := Scores{Annotators: len(annotatorsOf(units))}
+	exact, f1, pairs := 0.0, 0.0, 0
+	for _, u := range units {
+		if len(u.labels) < 2 {
+			continue
+		}
+		scores.Units++
+		annotators := annotatorsOf([]unit{u})
+		for i, a := range annotators {
+			for _, b := range annotators[i+1:] {
+				if ExactMatch(u.labels[a], u.labels[b]) {
+					exact++
+				}
+				f1 += TokenF1(u.labels[a], u.labels[b])
+				pairs++
+			}
+		}
+	}
+	if pairs != 0 {
+		exact, f1 = exact/float64(pairs), f1/float64(pairs)
+		scores.ExactMatch, scores.F1 = &exact, &f1
+	}
+	return scores
+}
+
+// annotatorsOf lists the annotators of units with at least two labels, sorted.
+func annotatorsOf(units []unit) []string {
+	seen := make(map[string]bool)
+	var annotators []string
+	for _, u := range units {
+		if len(u.labels) < 2 {
+			continue
+		}
+		for annotator := range u.labels {
+			if !seen[annotator] {
+				seen[annotator] = true
+				annotators = append(annotators, annotator)
+			}
+		}
+	}
+	sort.Strings(annotators)
+	return annotators
+}
+
+// pairUnits keeps the units both a and b labelled, with only their labels.
+func pairUnits(units []unit, a, b string) []unit {
+	var shared []unit
+	for _, u := range units {
+		labelA, okA := u.labels[a]
+		labelB, okB := u.labels[b]
+		if okA && okB {
+			shared = append(shared, unit{articleId: u.articleId, labels: map[string]string{a: labelA, b: labelB}})
+		}
+	}
+	return shared
+}
+
+func value(v float64, ok bool) *float64 {
+	if !ok {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"Lynx/logging"
+)
+
+type contextKey int
+
+const userIdKey contextKey = iota
+
+// WithUserId returns a copy of ctx that carries the authenticated user.
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey, userId)
+}
+
+// UserId returns the user resolved from the session token, if any.
+func UserId(ctx context.Context) (string, bool) {
+	userId, ok := ctx.Value(userIdKey).(string)
+	return userId, ok && userId != ""
+}
+
+// Middleware resolves the user from an "Authorization: Bearer <token>"
+// header. Requests without a valid token pass through without a user;
+// routes that need one reject them.
+func (t *TokenIssuer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if strings.HasPrefix(header, "Bearer ") {
+			claims, err := t.Verify(strings.TrimPrefix(header, "Bearer "))
+			if err != nil {
+				logging.Debugf("Reject session token for %s: %v", r.URL.Path, err)
+			} else {
+				r = r.WithContext(WithUserId(r.Context(), claims.Subject))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
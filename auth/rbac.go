@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permission is what a route needs on the project of its request.
+type Permission int
+
+const (
+	// PermLogin only needs a session, no project.
+	PermLogin Permission = iota
+	PermView
+	PermAnnotate
+	PermValidate
+	PermAdjudicate
+	PermManage
+	PermOwn
+)
+
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner:       {PermView, PermManage, PermOwn},
+	models.RoleManager:     {PermView, PermManage},
+	models.RoleAnnotator:   {PermView, PermAnnotate},
+	models.RoleValidator:   {PermView, PermValidate},
+	models.RoleAdjudicator: {PermView, PermAdjudicate},
+}
+
+var (
+	ErrNoProject = errors.New("projectId is required")
+	ErrForbidden = errors.New("your roles in this project do not allow this")
+	// ErrProjectMismatch refuses a request whose query and body name two
+	// projects, which handlers reading the body would otherwise mix up.
+	ErrProjectMismatch = errors.New("projectId of the query and of the body differ")
+
+	errBodyTooLarge = errors.New("request body too large")
+)
+
+// RoleCan reports whether role grants perm.
+func RoleCan(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether any of userId's roles in projectId grants perm.
+// Paused roles only grant PermView.
+func Can(auths service.AuthStore, userId string, projectId primitive.ObjectID, perm Permission) (bool, error) {
+	userAuths, err := auths.GetUserProjectAuths(userId, projectId)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range userAuths {
+		if a.Paused && perm != PermView {
+			continue
+		}
+		if RoleCan(a.EffectiveRole(), perm) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+const projectIdKey contextKey = iota + 1
+
+// ProjectId returns the project the request was authorized for.
+func ProjectId(ctx context.Context) (primitive.ObjectID, bool) {
+	projectId, ok := ctx.Value(projectIdKey).(primitive.ObjectID)
+	return projectId, ok
+}
+
+// maxPeekBody bounds how much of a request body is buffered to find its projectId.
+const maxPeekBody = 64 << 20
+
+// projectIdFromRequest reads the projectId query parameter and the
+// projectId field of a JSON body, which must agree when both are set. The
+// body is put back for the handler; bodies that are not JSON, e.g. uploads,
+// only have the query parameter.
+func projectIdFromRequest(r *http.Request) (primitive.ObjectID, error) {
+	var fromQuery primitive.ObjectID
+	if hex := r.URL.Query().Get("projectId"); hex != "" {
+		projectId, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		fromQuery = projectId
+	}
+	var peek struct {
+		ProjectId primitive.ObjectID `json:"projectId"`
+	}
+	if r.Body != nil {
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPeekBody+1))
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		if len(body) > maxPeekBody {
+			return primitive.NilObjectID, errBodyTooLarge
+		}
+		if json.Unmarshal(body, &peek) != nil {
+			peek.ProjectId = primitive.NilObjectID
+		}
+	}
+	switch {
+	case fromQuery.IsZero() && peek.ProjectId.IsZero():
+		return primitive.NilObjectID, ErrNoProject
+	case fromQuery.IsZero():
+		return peek.ProjectId, nil
+	case !peek.ProjectId.IsZero() && peek.ProjectId != fromQuery:
+		return primitive.NilObjectID, ErrProjectMismatch
+	}
+	return fromQuery, nil
+}
+
+// Authorize checks that the user of the request holds a role granting perm
+// in the request's project. On success the returned request carries the
+// project, see ProjectId. On failure it also returns the HTTP status to answer.
+func Authorize(auths service.AuthStore, r *http.Request, perm Permission) (*http.Request, int, error) {
+	if perm == PermLogin {
+		return r, http.StatusOK, nil
+	}
+	userId, _ := UserId(r.Context())
+	projectId, err := projectIdFromRequest(r)
+	if err == errBodyTooLarge {
+		return r, http.StatusRequestEntityTooLarge, err
+	}
+	if err == ErrProjectMismatch {
+		return r, http.StatusBadRequest, err
+	}
+	if err != nil {
+		return r, http.StatusBadRequest, ErrNoProject
+	}
+	ok, err := Can(auths, userId, projectId, perm)
+	if err != nil {
+		return r, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return r, http.StatusForbidden, ErrForbidden
+	}
+	return r.WithContext(context.WithValue(r.Context(), projectIdKey, projectId)), http.StatusOK, nil
+}
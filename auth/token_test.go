@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// forge builds a token from a raw header and payload, signed with secret
+// the way TokenIssuer signs.
+func forge(secret []byte, header, payload string) string {
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return signingInput + "." + NewTokenIssuer(secret, time.Hour).sign(signingInput)
+}
+
+// unsigned builds a token without a signature, as alg none has it.
+func unsigned(header, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "."
+}
+
+func claimsJSON(c Claims) string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func TestVerify(t *testing.T) {
+	tokens := NewTokenIssuer(testSecret, time.Hour)
+	valid, _, err := tokens.Issue("user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	now := time.Now().Unix()
+	live := Claims{Issuer: issuer, Subject: "user-a", IssuedAt: now, ExpiresAt: now + 3600}
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+
+	expired, _, err := NewTokenIssuer(testSecret, -time.Minute).Issue("user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongIssuer := live
+	wrongIssuer.Issuer = "someone-else"
+	noSubject := live
+	noSubject.Subject = ""
+	tampered := live
+	tampered.Subject = "user-manager"
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", valid, nil},
+		{"forged the same way", forge(testSecret, hs256, claimsJSON(live)), nil},
+		{"expired", expired, ErrExpiredToken},
+		{"expiring now", forge(testSecret, hs256, claimsJSON(Claims{Issuer: issuer, Subject: "user-a", ExpiresAt: now})), ErrExpiredToken},
+		{"wrong secret", forge([]byte("another secret, just as long as it"), hs256, claimsJSON(live)), ErrInvalidToken},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(claimsJSON(tampered))) + "." + parts[2], ErrInvalidToken},
+		{"truncated signature", valid[:len(valid)-1], ErrInvalidToken},
+		{"padded signature", valid + "=", ErrInvalidToken},
+		{"wrong iss", forge(testSecret, hs256, claimsJSON(wrongIssuer)), ErrInvalidToken},
+		{"no sub", forge(testSecret, hs256, claimsJSON(noSubject)), ErrInvalidToken},
+		{"alg none", unsigned(`{"alg":"none","typ":"JWT"}`, claimsJSON(live)), ErrInvalidToken},
+		{"unsigned", unsigned(hs256, claimsJSON(live)), ErrInvalidToken},
+		{"alg none signed", forge(testSecret, `{"alg":"none","typ":"JWT"}`, claimsJSON(live)), ErrInvalidToken},
+		{"alg HS512", forge(testSecret, `{"alg":"HS512","typ":"JWT"}`, claimsJSON(live)), ErrInvalidToken},
+		{"alg RS256", forge(testSecret, `{"alg":"RS256","typ":"JWT"}`, claimsJSON(live)), ErrInvalidToken},
+		{"header spelled differently", forge(testSecret, `{"typ":"JWT","alg":"HS256"}`, claimsJSON(live)), ErrInvalidToken},
+		{"empty", "", ErrInvalidToken},
+		{"two segments", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"four segments", valid + "." + parts[2], ErrInvalidToken},
+		{"empty segments", "..", ErrInvalidToken},
+		{"payload not base64", tokenHeader + ".!!!." + tokens.sign(tokenHeader+".!!!"), ErrInvalidToken},
+		{"payload not JSON", forge(testSecret, hs256, "user-a"), ErrInvalidToken},
+		{"payload of the wrong type", forge(testSecret, hs256, `{"iss":"lynx","sub":"user-a","exp":"tomorrow"}`), ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		claims, err := tokens.Verify(tt.token)
+		if err != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err == nil && claims.Subject != "user-a" {
+			t.Errorf("%s: subject %q, want user-a", tt.name, claims.Subject)
+		}
+	}
+}
+
+func TestIssue(t *testing.T) {
+	before := time.Now()
+	token, expiresAt, err := NewTokenIssuer(testSecret, time.Hour).Issue("user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiresAt.Before(before.Add(time.Hour)) || expiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expires at %v, want an hour from now", expiresAt)
+	}
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil || string(header) != `{"alg":"HS256","typ":"JWT"}` {
+		t.Errorf("header %s, %v", header, err)
+	}
+}
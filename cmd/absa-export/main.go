@@ -0,0 +1,109 @@
+// Command absa-export writes the validated answers of a sentiment project
+// as a SemEval XML, JSONL or CSV file, like POST /exportAbsa.
+//
+//	go run ./cmd/absa-export -config config.json -project <projectId> -format jsonl -o out.jsonl
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"Lynx/config"
+	"Lynx/exporter"
+	"Lynx/logging"
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	configFile = flag.String("config", os.Getenv("LYNX_CONFIG"), "JSON config file, LYNX_* environment variables override it")
+	storeType  = flag.String("store", "", "storage backend, mongo or memory (overrides the config)")
+	seedFile   = flag.String("seed", "", "JSON file to preload the memory store with (overrides the config)")
+	projectId  = flag.String("project", "", "id of the sentiment project to export")
+	format     = flag.String("format", exporter.FormatSemEval, "semeval, jsonl or csv")
+	allItems   = flag.Bool("all", false, "export every validated task, not only those that all match")
+	output     = flag.String("o", "", "output file, standard output when empty")
+)
+
+func main() {
+	flag.Parse()
+	err := run()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run exports the project, returning instead of exiting so that the store
+// is closed on every path.
+func run() error {
+	cfg, err := config.Read(*configFile)
+	if err != nil {
+		return err
+	}
+	if *storeType != "" {
+		cfg.Store = *storeType
+	}
+	if *seedFile != "" {
+		cfg.SeedFile = *seedFile
+	}
+	err = cfg.ValidateStore()
+	if err != nil {
+		return err
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.SetLevel(level)
+	id, err := primitive.ObjectIDFromHex(*projectId)
+	if err != nil {
+		return fmt.Errorf("-project: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	store, closeStore, err := service.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: id})
+	if err != nil {
+		return err
+	}
+	export, err := exporter.ExportABSA(store, *project, exporter.AbsaOptions{Format: *format, AllItems: *allItems})
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		// for the error paths, the Close below reports write errors
+		defer out.Close()
+	}
+	w := bufio.NewWriter(out)
+	err = export.Write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && out != os.Stdout {
+		err = out.Close()
+	}
+	if err != nil {
+		return err
+	}
+	logging.Infof("Exported %d tasks of project %s", export.Items(), project.ProjectName)
+	if export.Unmapped != 0 {
+		logging.Warnf("%d aspect terms were not found in their context and were written without a term", export.Unmapped)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Lynx/logging"
+)
+
+// Duration is a time.Duration written as "10s" or "1m30s" in config files.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string such as \"10s\": %v", err)
+	}
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// Config is everything the server reads at startup.
+type Config struct {
+	// Store picks the storage backend, mongo or memory.
+	Store string `json:"store"`
+	// SeedFile preloads the memory store.
+	SeedFile string `json:"seedFile"`
+
+	// DBURI must reach a replica set, or a sharded cluster, since the
+	// store writes in transactions; a single node replica set will do.
+	DBURI  string `json:"dbUri"`
+	DBName string `json:"dbName"`
+
+	ListenAddr         string   `json:"listenAddr"`
+	CORSAllowedOrigins []string `json:"corsAllowedOrigins"`
+
+	// ConnectTimeout bounds one connection attempt to the database,
+	// QueryTimeout every single database call.
+	ConnectTimeout Duration `json:"connectTimeout"`
+	QueryTimeout   Duration `json:"queryTimeout"`
+	// ConnectAttempts caps the connection retries at startup, 0 retries
+	// until shutdown. The wait between attempts starts at ConnectBackoff
+	// and doubles each time.
+	ConnectAttempts int      `json:"connectAttempts"`
+	ConnectBackoff  Duration `json:"connectBackoff"`
+	// ReadTimeout and WriteTimeout are passed to http.Server.
+	ReadTimeout  Duration `json:"readTimeout"`
+	WriteTimeout Duration `json:"writeTimeout"`
+	// ShutdownTimeout is how long in-flight requests may take to drain.
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+
+	LogLevel string `json:"logLevel"`
+
+	// IdentityProvider verifies logins, google or local. local trusts any
+	// credential and is only meant for tests and development.
+	IdentityProvider string `json:"identityProvider"`
+	GoogleClientID   string `json:"googleClientId"`
+	// AuthSecret signs session tokens, which stay valid for TokenTTL.
+	AuthSecret string   `json:"authSecret"`
+	TokenTTL   Duration `json:"tokenTtl"`
+
+	// ClaimTTL is how long a validation or decision item stays reserved
+	// for the user it was handed to without a heartbeat.
+	ClaimTTL Duration `json:"claimTtl"`
+}
+
+// minAuthSecretLength is the shortest secret accepted for HMAC-SHA256.
+const minAuthSecretLength = 32
+
+// Default returns the values used for anything the file and environment leave out.
+func Default() Config {
+	return Config{
+		Store:              "mongo",
+		DBURI:              "mongodb://localhost:27017",
+		DBName:             "label-lab",
+		ListenAddr:         ":9090",
+		CORSAllowedOrigins: []string{"*"},
+		ConnectTimeout:     Duration{10 * time.Second},
+		QueryTimeout:       Duration{5 * time.Second},
+		ConnectAttempts:    10,
+		ConnectBackoff:     Duration{time.Second},
+		ReadTimeout:        Duration{15 * time.Second},
+		WriteTimeout:       Duration{30 * time.Second},
+		ShutdownTimeout:    Duration{20 * time.Second},
+		LogLevel:           "info",
+		IdentityProvider:   "google",
+		TokenTTL:           Duration{12 * time.Hour},
+		ClaimTTL:           Duration{10 * time.Minute},
+	}
+}
+
+// Load reads the JSON file at path (skipped when path is empty) over the
+// defaults, then applies LYNX_* environment variables, and validates the result.
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Read is Load without the validation, for tools that check only the
+// settings they use, see ValidateStore.
+func Read(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		decoder := json.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %v", path, err)
+		}
+	}
+	err := cfg.applyEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	strs := map[string]*string{
+		"LYNX_STORE":       &c.Store,
+		"LYNX_SEED_FILE":   &c.SeedFile,
+		"LYNX_DB_URI":      &c.DBURI,
+		"LYNX_DB_NAME":     &c.DBName,
+		"LYNX_LISTEN_ADDR": &c.ListenAddr,
+		"LYNX_LOG_LEVEL":   &c.LogLevel,
+
+		"LYNX_IDENTITY_PROVIDER": &c.IdentityProvider,
+		"LYNX_GOOGLE_CLIENT_ID":  &c.GoogleClientID,
+		"LYNX_AUTH_SECRET":       &c.AuthSecret,
+	}
+	for key, field := range strs {
+		if v, ok := os.LookupEnv(key); ok {
+			*field = v
+		}
+	}
+	if v, ok := os.LookupEnv("LYNX_CORS_ALLOWED_ORIGINS"); ok {
+		c.CORSAllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.CORSAllowedOrigins = append(c.CORSAllowedOrigins, origin)
+			}
+		}
+	}
+	if v, ok := os.LookupEnv("LYNX_CONNECT_ATTEMPTS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("LYNX_CONNECT_ATTEMPTS: %v", err)
+		}
+		c.ConnectAttempts = n
+	}
+	durations := map[string]*Duration{
+		"LYNX_CONNECT_TIMEOUT":  &c.ConnectTimeout,
+		"LYNX_QUERY_TIMEOUT":    &c.QueryTimeout,
+		"LYNX_CONNECT_BACKOFF":  &c.ConnectBackoff,
+		"LYNX_READ_TIMEOUT":     &c.ReadTimeout,
+		"LYNX_WRITE_TIMEOUT":    &c.WriteTimeout,
+		"LYNX_SHUTDOWN_TIMEOUT": &c.ShutdownTimeout,
+		"LYNX_TOKEN_TTL":        &c.TokenTTL,
+		"LYNX_CLAIM_TTL":        &c.ClaimTTL,
+	}
+	for key, field := range durations {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			field.Duration = d
+		}
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	problems := c.storeProblems()
+	if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		problems = append(problems, fmt.Sprintf("listenAddr %q: %v", c.ListenAddr, err))
+	}
+	if len(c.CORSAllowedOrigins) == 0 {
+		problems = append(problems, "corsAllowedOrigins must list at least one origin, use \"*\" to allow all")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			problems = append(problems, fmt.Sprintf("cors origin %q must be \"*\" or start with http:// or https://", origin))
+		}
+	}
+	durations := map[string]Duration{
+		"readTimeout":     c.ReadTimeout,
+		"writeTimeout":    c.WriteTimeout,
+		"shutdownTimeout": c.ShutdownTimeout,
+		"tokenTtl":        c.TokenTTL,
+		"claimTtl":        c.ClaimTTL,
+	}
+	for name, d := range durations {
+		if d.Duration <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+	switch c.IdentityProvider {
+	case "google":
+		if c.GoogleClientID == "" {
+			problems = append(problems, "googleClientId is required by the google identity provider")
+		}
+		if len(c.AuthSecret) < minAuthSecretLength {
+			problems = append(problems, fmt.Sprintf("authSecret must be at least %d bytes", minAuthSecretLength))
+		}
+	case "local":
+		// an empty secret is replaced by a random one at startup
+		if c.AuthSecret != "" && len(c.AuthSecret) < minAuthSecretLength {
+			problems = append(problems, fmt.Sprintf("authSecret must be at least %d bytes", minAuthSecretLength))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("identityProvider %q must be google or local", c.IdentityProvider))
+	}
+	return invalid(problems)
+}
+
+// ValidateStore reports the invalid settings of the store and logging only,
+// for tools that open the store without serving HTTP.
+func (c *Config) ValidateStore() error {
+	return invalid(c.storeProblems())
+}
+
+func invalid(problems []string) error {
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (c *Config) storeProblems() []string {
+	var problems []string
+	switch c.Store {
+	case "mongo":
+		if !strings.HasPrefix(c.DBURI, "mongodb://") && !strings.HasPrefix(c.DBURI, "mongodb+srv://") {
+			problems = append(problems, "dbUri must start with mongodb:// or mongodb+srv://")
+		}
+		if c.SeedFile != "" {
+			problems = append(problems, "seedFile only applies to the memory store")
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("store %q must be mongo or memory", c.Store))
+	}
+	if c.DBName == "" || strings.ContainsAny(c.DBName, "/\\. \"$") {
+		problems = append(problems, fmt.Sprintf("dbName %q is not a valid database name", c.DBName))
+	}
+	if c.ConnectAttempts < 0 {
+		problems = append(problems, "connectAttempts must not be negative")
+	}
+	durations := map[string]Duration{
+		"connectTimeout": c.ConnectTimeout,
+		"queryTimeout":   c.QueryTimeout,
+		"connectBackoff": c.ConnectBackoff,
+	}
+	for name, d := range durations {
+		if d.Duration <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
@@ -2,65 +2,81 @@ package respond
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"Lynx/agreement"
+	"Lynx/auth"
 	"Lynx/models"
 	"Lynx/service"
 	"Lynx/viewModels"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Login(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
-	var user models.User
-	var response = models.Success{}
-	err := json.NewDecoder(r.Body).Decode(&user)
+// Login verifies the posted credential with the identity provider, creates
+// the user on first login and returns a session token for later requests.
+func Login(store service.Store, identities auth.IdentityProvider, tokens *auth.TokenIssuer, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.LoginRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	userResult, err := service.GetUser(database, user.ToQueryBson())
-	// if no user found then insert a new one and return
-	if userResult == nil {
-		_, err := service.SaveUser(database, user)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return err
+	if requestBody.Credential == "" {
+		http.Error(w, "credential is required", http.StatusBadRequest)
+		return auth.ErrInvalidCredential
+	}
+	identity, err := identities.Verify(r.Context(), requestBody.Credential)
+	if err != nil {
+		log.Println("Verify credential Error", err)
+		http.Error(w, auth.ErrInvalidCredential.Error(), http.StatusUnauthorized)
+		return err
+	}
+	var response = viewModels.LoginViewModel{Success: true, Message: "User Login"}
+	userResult, err := store.GetUser(models.User{UserId: identity.UserId})
+	// if no user found then insert a new one
+	if err == service.ErrNotFound {
+		user := models.User{
+			UserId:     identity.UserId,
+			Email:      identity.Email,
+			Name:       identity.Name,
+			GivenName:  identity.GivenName,
+			FamilyName: identity.FamilyName,
+			ImageUrl:   identity.ImageUrl,
 		}
-		// check if insert user
-		_, err = service.GetUser(database, user.ToQueryBson())
+		_, err = store.SaveUser(user)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return err
 		}
-		response.Success = true
+		userResult = &user
 		response.Message = "Add New User"
-		jsondata, _ := json.Marshal(response)
-		w.Write(jsondata)
-		return nil
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	response.User = *userResult
+	response.Token, response.ExpiresAt, err = tokens.Issue(identity.UserId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
 	}
-	// if already has users
-	response.Success = true
-	response.Message = "User Login"
 	jsondata, _ := json.Marshal(response)
 	w.Write(jsondata)
 	return nil
 }
 
-func GetUsersByProject(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
-	var queryInfo map[string]string
-	err := json.NewDecoder(r.Body).Decode(&queryInfo)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return err
-	}
-	projectId, _ := strconv.Atoi(queryInfo["projectId"])
-	var queryAuth = models.Auth{ProjectId: projectId}
-	auths, err := service.GetAuthByProjectId(database, queryAuth)
+func GetUsersByProject(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var queryAuth = models.Auth{ProjectId: currentProjectId(r)}
+	auths, err := store.GetAuthByProjectId(queryAuth)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -70,31 +86,32 @@ func GetUsersByProject(database *mongo.Database, w http.ResponseWriter, r *http.
 	for _, auth := range auths {
 		userIds = append(userIds, auth.UserId)
 	}
-	users, err := service.GetUsersByIds(database, userIds)
+	users, err := store.GetUsersByIds(userIds)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 
+	// one row per role, a user holding two roles is listed twice
 	var result = []viewModels.ProjectManageViewModel{}
-	for _, user := range users {
-		var userAuth = models.Auth{}
-		for _, auth := range auths {
-			if auth.UserId == user.UserId {
-				userAuth = auth
-				break
+	for _, userAuth := range auths {
+		for _, user := range users {
+			if user.UserId != userAuth.UserId {
+				continue
 			}
+			var userViewModel = viewModels.ProjectManageViewModel{
+				ProjectId:  userAuth.ProjectId,
+				UserId:     userAuth.UserId,
+				Role:       userAuth.EffectiveRole(),
+				CodeType:   userAuth.CodeType,
+				StatusCode: userAuth.StatusCode,
+				Name:       user.Name,
+				Email:      user.Email,
+				ImageUrl:   user.ImageUrl,
+			}
+			result = append(result, userViewModel)
+			break
 		}
-		var userViewModel = viewModels.ProjectManageViewModel{
-			ProjectId:  userAuth.ProjectId,
-			UserId:     userAuth.UserId,
-			CodeType:   userAuth.CodeType,
-			StatusCode: userAuth.StatusCode,
-			Name:       user.Name,
-			Email:      user.Email,
-			ImageUrl:   user.ImageUrl,
-		}
-		result = append(result, userViewModel)
 	}
 
 	jsondata, _ := json.Marshal(result)
@@ -102,139 +119,91 @@ func GetUsersByProject(database *mongo.Database, w http.ResponseWriter, r *http.
 	return nil
 }
 
-func SaveAuth(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func SaveAuth(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.Auth
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	if !models.IsRole(requestBody.Role) {
+		err = fmt.Errorf("role %q must be one of %s", requestBody.Role, strings.Join(models.Roles, ", "))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	requestBody.ProjectId = currentProjectId(r)
+	// managers add members, only owners hand out ownership
+	if requestBody.Role == models.RoleOwner {
+		isOwner, err := auth.Can(store, currentUserId(r), requestBody.ProjectId, auth.PermOwn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+		if !isOwner {
+			http.Error(w, auth.ErrForbidden.Error(), http.StatusForbidden)
+			return auth.ErrForbidden
+		}
+	}
 	requestBody.CodeType = "1"
-	res, err := service.SaveAuth(database, requestBody)
+	res, err := store.SaveAuth(requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 	var response = models.Success{
 		Success: true,
-		Message: res.InsertedID.(primitive.ObjectID).Hex(),
+		Message: res.Hex(),
 	}
 	jsondata, _ := json.Marshal(response)
 	_, _ = w.Write(jsondata)
 	return nil
 }
 
-// func SaveProject(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
-// 	var addProjModel = viewModels.AddProjectViewModel{}
-// 	err := json.NewDecoder(r.Body).Decode(&addProjModel)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-
-// 	// assign new projectId to each member's authentication
-// 	currentId, err := service.GetProjectCount(database)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-
-// 	// check if csv format decode is correct first
-// 	articles, tasks, err := convertToArticlesAndTasks(addProjModel.CsvFile, int(currentId))
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-
-// 	// then adding objects into db
-// 	addProjModel.Project.ProjectId = int(currentId)
-// 	projectResult, err := service.SaveProject(database, addProjModel.Project)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-// 	for i := range addProjModel.Members {
-// 		addProjModel.Members[i].ProjectId = int(currentId)
-// 	}
-// 	log.Println("addProj members", addProjModel.Members)
-// 	_, err = service.SaveAuths(database, addProjModel.Members)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-// 	_, err = service.SaveArticles(database, articles)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-// 	_, err = service.SaveTasks(database, tasks)
-// 	if err != nil {
-// 		http.Error(w, err.Error(), http.StatusBadRequest)
-// 		return err
-// 	}
-// 	var response = models.Success{
-// 		Success: true,
-// 		Message: projectResult.InsertedID.(primitive.ObjectID).Hex(),
-// 	}
-// 	jsondata, _ := json.Marshal(response)
-// 	_, _ = w.Write(jsondata)
-// 	return nil
-// }
-
-// private func for decode articles and Tasks.
-// func convertToArticlesAndTasks(csvFile [][]string, currentId int) ([]models.Article, []models.MRCTask, error) {
-// 	var articles []models.Article
-// 	var tasks []models.MRCTask
-// 	articleSets := make(map[string]int)
-// 	var ai int = -1 //point index of articles
-// 	for _, pair := range csvFile {
-// 		var contexts = pair[0]
-// 		var indices = strings.Split(pair[1], "-")
-// 		var articleId = "articleId" + indices[0]
-// 		var taskId = "taskId" + pair[1]
-// 		// basic check for the csv format
-// 		if len(pair) != 2 || len(indices) != 2 {
-// 			log.Println("錯誤的CSV檔案格式")
-// 			return nil, nil, errors.New("錯誤的CSV檔案格式")
-// 		}
-// 		// check if the articles already exist else create one
-// 		if _, ok := articleSets[articleId]; ok {
-// 			articles[ai].TotalTasks += 1
-// 		} else {
-// 			articleSets[articleId] = 1
-// 			var article = models.Article{
-// 				ArticleId:    articleId,
-// 				ProjectId:    currentId,
-// 				ArticleTitle: contexts,
-// 				TotalTasks:   1,
-// 			}
-// 			articles = append(articles, article)
-// 			ai += 1
-// 		}
-// 		var task = models.MRCTask{
-// 			TaskId:    taskId,
-// 			ArticleId: articleId,
-// 			TaskType:  "MRC",
-// 			TaskTitle: contexts,
-// 			Context:   contexts,
-// 		}
-// 		tasks = append(tasks, task)
-// 	}
-// 	return articles, tasks, nil
-// }
-
-func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
-	users, err := service.GetUsers(database)
-	if err != nil {
+// GetUsers lists the users holding a role in the project. With an email
+// it looks up that one account instead, so a manager can add someone they
+// know without listing every user of Lynx.
+func GetUsers(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var queryInfo map[string]string
+	err := json.NewDecoder(r.Body).Decode(&queryInfo)
+	if err != nil && err != io.EOF {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	users := []models.User{}
+	if email := strings.TrimSpace(queryInfo["email"]); email != "" {
+		user, err := store.GetUser(models.User{Email: email})
+		if err == nil {
+			users = append(users, *user)
+		} else if err != service.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	} else {
+		auths, err := store.GetAuthByProjectId(models.Auth{ProjectId: currentProjectId(r)})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		var userIds []string
+		for _, auth := range auths {
+			if !containsString(userIds, auth.UserId) {
+				userIds = append(userIds, auth.UserId)
+			}
+		}
+		if len(userIds) != 0 {
+			users, err = store.GetUsersByIds(userIds)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
 	jsondata, _ := json.Marshal(users)
 	w.Write(jsondata)
 	return nil
 }
 
-// func GetProjects(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+// func GetProjects(store service.Store, w http.ResponseWriter, r *http.Request) error {
 // 	var queryInfo map[string]string
 // 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
 // 	var userId = queryInfo["userId"]
@@ -246,7 +215,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 		return err
 // 	}
 
-// 	projects, err := service.GetProjectsByAuth(database, queryAuth)
+// 	projects, err := store.GetProjectsByAuth(queryAuth)
 // 	if err != nil {
 // 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 		return err
@@ -256,7 +225,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	return nil
 // }
 
-// func GetArticles(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+// func GetArticles(store service.Store, w http.ResponseWriter, r *http.Request) error {
 // 	var queryInfo map[string]string
 // 	var result viewModels.ProjectViewModel
 // 	var articles []models.Article
@@ -269,12 +238,12 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	}
 // 	projectId, _ := strconv.Atoi(queryInfo["projectId"])
 // 	var queryProject = models.Project{ProjectId: projectId}
-// 	projectResult, err := service.GetProjectByProjectId(database, queryProject)
+// 	projectResult, err := store.GetProjectByProjectId(queryProject)
 // 	if err != nil {
 // 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 		return err
 // 	}
-// 	articles, err = service.GetArticlesByProjectId(database, projectId)
+// 	articles, err = store.GetArticlesByProjectId(projectId)
 // 	if err != nil {
 // 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 		return err
@@ -287,14 +256,14 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	// [PENDING] Further information for articles
 // 	// for i, article := range articles {
 // 	// 	// get how many tasks that each article has
-// 	// 	tasks, err := service.GetTasksByArticleId(database, article.ToQueryBson())
+// 	// 	tasks, err := store.GetTasksByArticleId(article.ToQueryBson())
 // 	// 	if err != nil {
 // 	// 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 	// 		return err
 // 	// 	}
 // 	// 	articles[i].TotalTasks = len(tasks)
 // 	// 	for _, task := range tasks {
-// 	// 		answers, err := service.GetAnswers(database, models.MRCAnswer{UserId: userId, TaskId: task.TaskId})
+// 	// 		answers, err := store.GetAnswers(models.MRCAnswer{UserId: userId, TaskId: task.TaskId})
 // 	// 		if err != nil {
 // 	// 			http.Error(w, err.Error(), http.StatusBadRequest)
 // 	// 			return err
@@ -308,7 +277,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	return nil
 // }
 
-// func GetTasksByArticleId(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+// func GetTasksByArticleId(store service.Store, w http.ResponseWriter, r *http.Request) error {
 // 	var queryInfo models.SentiArticle
 // 	var result viewModels.TasksViewModel
 // 	// decode request condition to queryInfo
@@ -319,7 +288,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 		return err
 // 	}
 // 	// get tasks by articles
-// 	tasks, err := service.GetTasksByArticleId(database, bson.M{"articleId": queryInfo.ArticleId})
+// 	tasks, err := store.GetTasksByArticleId(bson.M{"articleId": queryInfo.ArticleId})
 // 	if err != nil {
 // 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 		return err
@@ -328,7 +297,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	// get ArticleInfo
 // 	result.ArticleId = queryInfo.ArticleId
 // 	result.TaskType = queryInfo.TaskType
-// 	articleResult, err := service.GetArticleByArticleId(database, bson.M{"articleId": queryInfo.ArticleId})
+// 	articleResult, err := store.GetArticleByArticleId(bson.M{"articleId": queryInfo.ArticleId})
 // 	if err != nil {
 // 		http.Error(w, err.Error(), http.StatusBadRequest)
 // 		return err
@@ -342,7 +311,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 			TaskTitle: task.TaskTitle,
 // 			Context:   task.Context,
 // 		}
-// 		answers, err := service.GetAnswers(database, models.MRCAnswer{UserId: queryInfo.UserId, ArticleId: queryInfo["articleId"], TaskId: task.TaskId})
+// 		answers, err := store.GetAnswers(models.MRCAnswer{UserId: queryInfo.UserId, ArticleId: queryInfo["articleId"], TaskId: task.TaskId})
 // 		if err != nil {
 // 			http.Error(w, err.Error(), http.StatusBadRequest)
 // 			return err
@@ -360,7 +329,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // }
 
 //[TODO] update MRCTask for answered + 1
-// func SaveArticles(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+// func SaveArticles(store service.Store, w http.ResponseWriter, r *http.Request) error {
 // 	collection := database.Collection("Articles")
 // 	var articles []models.Article
 // 	err := json.NewDecoder(r.Body).Decode(&articles)
@@ -390,7 +359,7 @@ func GetUsers(database *mongo.Database, w http.ResponseWriter, r *http.Request)
 // 	return nil
 // }
 
-func GetTaskById(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetTaskById(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody map[string]string
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
@@ -398,12 +367,15 @@ func GetTaskById(database *mongo.Database, w http.ResponseWriter, r *http.Reques
 		return err
 	}
 	log.Println("GetTaskById queryInfo:", requestBody)
-	answers, err := service.GetAnswers(database, models.MRCAnswer{ArticleId: requestBody["articleId"], TaskId: requestBody["taskId"], TaskType: requestBody["taskType"]})
+	if !mrcArticleInProject(store, w, r, requestBody["articleId"]) {
+		return auth.ErrForbidden
+	}
+	answers, err := store.GetAnswers(models.MRCAnswer{ArticleId: requestBody["articleId"], TaskId: requestBody["taskId"], TaskType: requestBody["taskType"]})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	task, err := service.GetTaskById(database, models.MRCTask{ArticleId: requestBody["articleId"], TaskId: requestBody["taskId"], TaskType: requestBody["taskType"]})
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: requestBody["articleId"], TaskId: requestBody["taskId"], TaskType: requestBody["taskType"]})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -416,6 +388,9 @@ func GetTaskById(database *mongo.Database, w http.ResponseWriter, r *http.Reques
 	response.Context = task.Context
 
 	for _, answer := range answers {
+		if answer.Status == models.AnswerStatusGold {
+			continue
+		}
 		var QAPair = viewModels.QAPairModel{
 			Question: answer.Question,
 		}
@@ -427,21 +402,62 @@ func GetTaskById(database *mongo.Database, w http.ResponseWriter, r *http.Reques
 	return nil
 }
 
-func SaveAnswer(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func SaveAnswer(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.MRCAnswer
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	res, err := service.SaveAnswer(database, requestBody)
+	if !mrcArticleInProject(store, w, r, requestBody.ArticleId) {
+		return auth.ErrForbidden
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: requestBody.ArticleId, TaskId: requestBody.TaskId, TaskType: "MRC"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = answerToRunes(task.Context, &requestBody, unit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	requestBody.ProjectId = currentProjectId(r)
+	requestBody.UserId = currentUserId(r)
+	// answers to gold questions are scored, and do not count as annotations
+	gold, err := service.FindGoldLabel(store, models.Label{ProjectId: project.ProjectId, TaskType: "MRC", ArticleId: requestBody.ArticleId, TaskId: requestBody.TaskId, Question: requestBody.Question})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var result *models.GoldResult
+	if gold != nil {
+		score, correct := agreement.GoldMRC(project.MatchRule, *gold, requestBody)
+		scored := goldResult(*gold, requestBody.UserId, score, correct)
+		result = &scored
+		requestBody.Status = models.AnswerStatusGold
+	}
+	res, err := store.AnswerMRCTask(*project, *task, requestBody, result)
+	if err == service.ErrAnnotated {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 	var response = models.Success{
 		Success: true,
-		Message: res.InsertedID.(primitive.ObjectID).Hex(),
+		Message: res.Hex(),
 	}
 	jsondata, _ := json.Marshal(response)
 	_, _ = w.Write(jsondata)
@@ -461,17 +477,30 @@ func Test(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-func GetValidation(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetValidation(store service.Store, ttl time.Duration, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo map[string]string
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
-	var userId = queryInfo["userId"]
+	var userId = currentUserId(r)
 	log.Println("userId", userId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	questionPair, err := service.GetRandomValidationQuestion(database, models.MRCAnswer{UserId: queryInfo["userId"], TaskType: queryInfo["taskType"]})
-	if questionPair == nil {
+	var questionPair *models.MRCAnswer
+	query := models.MRCAnswer{ProjectId: currentProjectId(r), UserId: userId, TaskType: queryInfo["taskType"]}
+	claim, err := service.ClaimNext(store, models.ClaimMRCValidation, query.ProjectId, userId, ttl, func(exclude []primitive.ObjectID) (primitive.ObjectID, error) {
+		found, err := store.GetRandomValidationQuestion(query, exclude)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		questionPair = found
+		return found.Id, nil
+	})
+	if err != nil && err != service.ErrNotFound && err != service.ErrClaimed {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if claim == nil {
 		var response = models.Success{
 			Success: true,
 			Message: "no validation",
@@ -480,7 +509,7 @@ func GetValidation(database *mongo.Database, w http.ResponseWriter, r *http.Requ
 		w.Write(jsondata)
 		return nil
 	}
-	task, err := service.GetTaskById(database, models.MRCTask{ArticleId: questionPair.ArticleId, TaskId: questionPair.TaskId, TaskType: questionPair.TaskType})
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: questionPair.ArticleId, TaskId: questionPair.TaskId, TaskType: questionPair.TaskType})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -492,17 +521,16 @@ func GetValidation(database *mongo.Database, w http.ResponseWriter, r *http.Requ
 	response.TaskId = questionPair.TaskId
 	response.TaskTitle = task.TaskTitle
 	response.TaskContext = task.Context
+	response.ClaimExpiresAt = claim.ExpiresAt
 	jsondata, _ := json.Marshal(response)
 	w.Write(jsondata)
 	return nil
 }
 
-func SaveValidation(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func SaveValidation(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	// Decode
 	var queryInfo map[string]string
-	log.Println("originalId", queryInfo["originalId"])
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
-	log.Println("queryInfo validationAnswer", len(queryInfo["validationAnswer"]))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -514,8 +542,30 @@ func SaveValidation(database *mongo.Database, w http.ResponseWriter, r *http.Req
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	res, err := service.FindAnswerById(database, id)
-	log.Println("original answer", res)
+	res, err := store.FindAnswerById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if !inProject(w, r, res.ProjectId) {
+		return auth.ErrForbidden
+	}
+	if !notOwnWork(w, r, res.UserId) {
+		return service.ErrOwnWork
+	}
+	if !claimFree(store, w, r, models.ClaimMRCValidation, id) {
+		return service.ErrClaimed
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: res.ArticleId, TaskId: res.TaskId, TaskType: "MRC"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: res.ProjectId})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -523,60 +573,96 @@ func SaveValidation(database *mongo.Database, w http.ResponseWriter, r *http.Req
 
 	// save validation answer
 	var validationAnswer models.MRCAnswer
-	validationAnswer.UserId = queryInfo["userId"]
+	validationAnswer.ProjectId = res.ProjectId
+	validationAnswer.UserId = currentUserId(r)
 	validationAnswer.ArticleId = res.ArticleId
 	validationAnswer.TaskId = res.TaskId
 	validationAnswer.TaskType = "Validation"
 	validationAnswer.Status = "unverified"
 	validationAnswer.Question = res.Question
 	validationAnswer.Answer = queryInfo["validationAnswer"]
-	startIdx, err := strconv.Atoi(queryInfo["startIdx"])
-	validationAnswer.StartIdx = startIdx
-	result, err := service.SaveAnswer(database, validationAnswer)
-	log.Println("save result", result.InsertedID)
-	validationId := result.InsertedID.(primitive.ObjectID)
+	// an empty answer says the question cannot be answered
+	validationAnswer.IsImpossible = validationAnswer.Answer == ""
+	if !validationAnswer.IsImpossible {
+		validationAnswer.StartIdx, err = strconv.Atoi(queryInfo["startIdx"])
+		if err != nil {
+			http.Error(w, "startIdx: "+err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
+	err = answerToRunes(task.Context, &validationAnswer, unit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
 
 	// check validation
 	var validationStatus models.MRCValidation
+	validationStatus.ProjectId = res.ProjectId
 	validationStatus.LabelUserId = res.UserId
-	validationStatus.ValidationUserId = queryInfo["userId"]
+	validationStatus.ValidationUserId = validationAnswer.UserId
 	validationStatus.OriginalId = id
-	validationStatus.ValidationId = validationId
-	if res.Answer == queryInfo["validationAnswer"] && queryInfo["startIdx"] == strconv.Itoa(res.StartIdx) {
+	// the project's match rule decides what still needs an adjudicator
+	score, verified := agreement.Match(project.MatchRule, *res, validationAnswer)
+	validationStatus.Score = score
+	validationStatus.Method = project.MatchRule.Effective().Method
+	if verified {
 		validationStatus.Status = "verified"
 	} else {
 		validationStatus.Status = "pending"
 	}
-	log.Println("status info", validationStatus)
-	statusResult, err := service.SaveValidationStatus(database, validationStatus)
-	log.Println("status result", statusResult)
 
-	// update answer
-	updateResult, err := service.UpdateAnswer(database, validationStatus)
-	log.Println("update result", updateResult)
+	// the validation answer, the validation and the answer status are
+	// saved together
+	statusResult, err := store.SaveValidation(validationAnswer, validationStatus)
+	if err == service.ErrDecided {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	releaseClaim(store, r, models.ClaimMRCValidation, id)
 
 	// result and response
 	var response = models.Success{
 		Success: true,
-		Message: statusResult.InsertedID.(primitive.ObjectID).Hex(),
+		Message: statusResult.Hex(),
 	}
 	jsondata, _ := json.Marshal(response)
 	w.Write(jsondata)
 	return nil
 }
 
-func GetDecision(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetDecision(store service.Store, ttl time.Duration, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo map[string]string
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
-	var userId = queryInfo["userId"]
+	var userId = currentUserId(r)
 	log.Println("userId", userId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	decisionInfo, err := service.GetRandomDecisionInfo(database, userId)
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	var decisionInfo *models.MRCValidation
+	claim, err := service.ClaimNext(store, models.ClaimMRCDecision, currentProjectId(r), userId, ttl, func(exclude []primitive.ObjectID) (primitive.ObjectID, error) {
+		found, err := store.GetRandomDecisionInfo(currentProjectId(r), userId, exclude)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		decisionInfo = found
+		return found.Id, nil
+	})
 	log.Println("decisionInfo", decisionInfo)
-	if decisionInfo == nil {
+	if err != nil && err != service.ErrNotFound && err != service.ErrClaimed {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if claim == nil {
 		var response = models.Success{
 			Success: true,
 			Message: "no decision",
@@ -585,24 +671,20 @@ func GetDecision(database *mongo.Database, w http.ResponseWriter, r *http.Reques
 		w.Write(jsondata)
 		return nil
 	}
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return err
-	}
-	originalAnswer, err := service.FindAnswerById(database, decisionInfo.OriginalId)
+	originalAnswer, err := store.FindAnswerById(decisionInfo.OriginalId)
 	log.Println("originalAnswer", originalAnswer)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	validationAnswer, err := service.FindAnswerById(database, decisionInfo.ValidationId)
+	validationAnswer, err := store.FindAnswerById(decisionInfo.ValidationId)
 	log.Println("validationAnswer", validationAnswer)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 
-	task, err := service.GetTaskById(database, models.MRCTask{ArticleId: originalAnswer.ArticleId, TaskId: originalAnswer.TaskId, TaskType: "MRC"})
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: originalAnswer.ArticleId, TaskId: originalAnswer.TaskId, TaskType: "MRC"})
 	log.Println("task", task)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -614,57 +696,92 @@ func GetDecision(database *mongo.Database, w http.ResponseWriter, r *http.Reques
 	response.ValidationId = validationAnswer.Id
 	response.Question = originalAnswer.Question
 	response.OriginalAnswer = originalAnswer.Answer
-	response.OriginalStartIdx = originalAnswer.StartIdx
+	response.OriginalStartIdx = offsetToUnit(task.Context, originalAnswer.StartIdx, unit)
 	response.ValidationAnswer = validationAnswer.Answer
-	response.ValidationStartIdx = validationAnswer.StartIdx
+	response.ValidationStartIdx = offsetToUnit(task.Context, validationAnswer.StartIdx, unit)
 	response.OriginalTaskContext = task.Context
+	response.ClaimExpiresAt = claim.ExpiresAt
+	response.Score = decisionInfo.Score
+	response.Method = decisionInfo.Method
 	jsondata, _ := json.Marshal(response)
 	w.Write(jsondata)
 	return nil
 }
 
-func SaveDecision(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func SaveDecision(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo map[string]string
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	// update answer
-	var decisionStatus models.MRCValidation
-	originalId, err := primitive.ObjectIDFromHex(queryInfo["originalId"])
-	decisionStatus.OriginalId = originalId
-	decisionStatus.Status = queryInfo["status"]
-	updateAnswer, err := service.UpdateAnswer(database, decisionStatus)
-	log.Println("updateAnswer", updateAnswer)
+	ids := make(map[string]primitive.ObjectID)
+	for _, key := range []string{"originalId", "validationStatusId", "validationId"} {
+		id, err := primitive.ObjectIDFromHex(queryInfo[key])
+		if err != nil {
+			http.Error(w, key+": "+err.Error(), http.StatusBadRequest)
+			return err
+		}
+		ids[key] = id
+	}
+	status := queryInfo["status"]
+	if status != models.DecisionVerified && status != models.DecisionRejected {
+		err = fmt.Errorf("status must be %q or %q", models.DecisionVerified, models.DecisionRejected)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if queryInfo["decisionResult"] == "" {
+		err = errors.New("decisionResult is required")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	originalAnswer, err := store.FindAnswerById(ids["originalId"])
+	if err == service.ErrNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return err
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	// update status
-	var validationStatus models.MRCValidation
-	validationStatusId, err := primitive.ObjectIDFromHex(queryInfo["validationStatusId"])
-	validationStatus.OriginalId = validationStatusId
-	validationStatus.Status = queryInfo["status"]
-	updateErr := service.UpdateValidationStatus(database, validationStatus)
-	if updateErr != nil {
-		http.Error(w, updateErr.Error(), http.StatusBadRequest)
-		return updateErr
-	}
-
-	// save decision result
-	var decisionResult models.MRCDecision
-	decisionResult.UserId = queryInfo["userId"]
-	decisionResult.OriginalId = originalId
-	validationId, err := primitive.ObjectIDFromHex(queryInfo["validationId"])
-	decisionResult.ValidationId = validationId
-	decisionResult.ValidationStatusId = validationStatusId
-	decisionResult.DecisionResult = queryInfo["decisionResult"]
-	saveDecisionResult, err := service.SaveDecision(database, decisionResult)
+	if !inProject(w, r, originalAnswer.ProjectId) {
+		return auth.ErrForbidden
+	}
+	if !notOwnWork(w, r, originalAnswer.UserId) {
+		return service.ErrOwnWork
+	}
+	validationStatusId := ids["validationStatusId"]
+	if !claimFree(store, w, r, models.ClaimMRCDecision, validationStatusId) {
+		return service.ErrClaimed
+	}
+	decision := models.MRCDecision{
+		UserId:             currentUserId(r),
+		OriginalId:         ids["originalId"],
+		ValidationId:       ids["validationId"],
+		ValidationStatusId: validationStatusId,
+		DecisionResult:     queryInfo["decisionResult"],
+	}
+	saveDecisionResult, err := store.Decide(currentProjectId(r), decision, status)
+	switch err {
+	case nil:
+	case service.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return err
+	case service.ErrOwnWork:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return err
+	case service.ErrDecided:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	releaseClaim(store, r, models.ClaimMRCDecision, validationStatusId)
 
 	var response = models.Success{
 		Success: true,
-		Message: saveDecisionResult.InsertedID.(primitive.ObjectID).Hex(),
+		Message: saveDecisionResult.Hex(),
 	}
 	jsondata, _ := json.Marshal(response)
 	w.Write(jsondata)
@@ -672,7 +789,7 @@ func SaveDecision(database *mongo.Database, w http.ResponseWriter, r *http.Reque
 }
 
 //================================= sentiment API =================================
-func GetSentiArticles(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetSentiArticles(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo models.SentiArticle
 
 	var articles []models.Article
@@ -685,16 +802,17 @@ func GetSentiArticles(database *mongo.Database, w http.ResponseWriter, r *http.R
 		return err
 	}
 
-	projectId := queryInfo.ProjectId
+	// the body may not name another project than the authorized one
+	projectId := currentProjectId(r)
 
 	var queryProject = models.Project{ProjectId: projectId}
 	// log.Print(queryProject)
-	projectResult, err := service.GetProjectByProjectId(database, queryProject)
+	projectResult, err := store.GetProjectByProjectId(queryProject)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	articles, err = service.GetSentiArticles(database, queryProject)
+	articles, err = store.GetSentiArticles(queryProject)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -705,6 +823,7 @@ func GetSentiArticles(database *mongo.Database, w http.ResponseWriter, r *http.R
 	result.ProjectType = projectResult.ProjectType
 	result.Rule = projectResult.Rule
 	result.ManagerId = projectResult.ManagerId
+	result.Archived = projectResult.Archived
 	result.ArticleList = articles
 
 	jsondata, _ := json.Marshal(result)
@@ -712,7 +831,7 @@ func GetSentiArticles(database *mongo.Database, w http.ResponseWriter, r *http.R
 	return nil
 }
 
-func GetSentiTasksByArticleId(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetSentiTasksByArticleId(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo models.SentiArticle
 	var result viewModels.SentiTasksViewModel
 	// decode request condition to queryInfo
@@ -722,8 +841,11 @@ func GetSentiTasksByArticleId(database *mongo.Database, w http.ResponseWriter, r
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	// get tasks by articles
-	tasks, err := service.GetSentiTasksByArticleId(database, bson.M{"articleId": queryInfo.ArticleId, "isAnswered": false})
+	if !sentiArticleInProject(store, w, r, queryInfo.ArticleId) {
+		return auth.ErrForbidden
+	}
+	// get the tasks that still need annotators
+	tasks, err := store.GetSentiTasksByArticleId(queryInfo.ArticleId, false)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -733,30 +855,25 @@ func GetSentiTasksByArticleId(database *mongo.Database, w http.ResponseWriter, r
 	// get ArticleInfo
 	result.ArticleId = queryInfo.ArticleId
 	result.TaskType = queryInfo.TaskType
-	articleResult, err := service.GetSentiArticleByArticleId(database, bson.M{"_id": queryInfo.ArticleId})
+	articleResult, err := store.GetSentiArticleByArticleId(queryInfo.ArticleId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 	result.ArticleTitle = articleResult.ArticleTitle
 
-	// get tasksInfo
+	// get tasksInfo, gold tasks are only handed out by /nextTask
 	for _, task := range tasks {
+		if task.Gold {
+			continue
+		}
 		var t = viewModels.SentiTaskListModel{
 			TaskId:     task.TaskId,
 			TaskTitle:  task.TaskTitle,
 			Context:    task.Context,
 			AspectPool: task.AspectPool,
-		}
-
-		answers, err := service.GetAspectByTaskId(database, models.SentiAspect{TaskId: task.TaskId})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return err
-		}
-		// if user has answers the question
-		if len(answers) != 0 {
-			t.IsAnswered = true
+			// if user has answers the question
+			IsAnswered: task.AnsweredBy(currentUserId(r)),
 		}
 
 		result.TaskList = append(result.TaskList, t)
@@ -766,7 +883,7 @@ func GetSentiTasksByArticleId(database *mongo.Database, w http.ResponseWriter, r
 	return nil
 }
 
-func GetSentiTaskById(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetSentiTaskById(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.SentiTask
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
@@ -774,13 +891,16 @@ func GetSentiTaskById(database *mongo.Database, w http.ResponseWriter, r *http.R
 		return err
 	}
 
-	aspects, err := service.GetAspectByTaskId(database, models.SentiAspect{TaskId: requestBody.TaskId})
+	if !sentiTaskInProject(store, w, r, requestBody.TaskId) {
+		return auth.ErrForbidden
+	}
+	aspects, err := store.GetAspectByTaskId(models.SentiAspect{TaskId: requestBody.TaskId})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 	log.Println("GetSentiTaskById queryInfo:", bson.M{"_id": requestBody.TaskId, "taskType": requestBody.TaskType})
-	task, err := service.GetSentiTaskById(database, bson.M{"_id": requestBody.TaskId, "taskType": requestBody.TaskType})
+	task, err := store.GetSentiTaskById(requestBody.TaskId, requestBody.TaskType)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -792,7 +912,8 @@ func GetSentiTaskById(database *mongo.Database, w http.ResponseWriter, r *http.R
 	response.TaskTitle = task.TaskTitle
 	response.Context = task.Context
 	response.AspectPool = task.AspectPool
-	if len(aspects) != 0 {
+	// tasks answered before annotators were kept only have their aspects
+	if task.AnsweredBy(currentUserId(r)) || (task.Annotators == nil && len(aspects) != 0) {
 		response.IsAnswered = true
 	}
 
@@ -809,7 +930,7 @@ func GetSentiTaskById(database *mongo.Database, w http.ResponseWriter, r *http.R
 	return nil
 }
 
-func SaveSentiAnswer(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func SaveSentiAnswer(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.SentiAnswer
 
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
@@ -820,7 +941,70 @@ func SaveSentiAnswer(database *mongo.Database, w http.ResponseWriter, r *http.Re
 		return err
 	}
 
-	_, err = service.SaveSentiAnswer(database, requestBody)
+	var taskIds []primitive.ObjectID
+	for _, aspect := range requestBody.Aspect {
+		taskIds = append(taskIds, aspect.TaskId)
+	}
+	for _, sentiment := range requestBody.Sentiment {
+		taskIds = append(taskIds, sentiment.TaskId)
+	}
+	if !sentiTasksInProject(store, w, r, taskIds) {
+		return auth.ErrForbidden
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	err = sentiOffsetsToRunes(store, unit, requestBody.Aspect, requestBody.Sentiment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	for _, taskId := range taskIds {
+		if taskId != taskIds[0] {
+			err = errors.New("every aspect and sentiment of an answer must be on the same task")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	userId := currentUserId(r)
+	now := time.Now()
+	for i := range requestBody.Aspect {
+		requestBody.Aspect[i].UserId = userId
+		requestBody.Aspect[i].AnsweredAt = now
+	}
+	for i := range requestBody.Sentiment {
+		requestBody.Sentiment[i].UserId = userId
+	}
+	// gold tasks take every annotator and score them
+	required := project.RequiredAnnotators()
+	var gold *models.Label
+	if len(taskIds) != 0 {
+		gold, err = service.FindGoldLabel(store, models.Label{ProjectId: project.ProjectId, TaskType: "Sentiment", TaskId: taskIds[0].Hex()})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
+	var result *models.GoldResult
+	if gold != nil {
+		required = 0
+		score, correct := agreement.GoldSentiment(*gold, requestBody)
+		scored := goldResult(*gold, userId, score, correct)
+		result = &scored
+	}
+	err = store.AnswerSentiTask(*project, requestBody, required, result)
+	if err == service.ErrAnnotated || err == service.ErrTaskAnswered {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -838,7 +1022,7 @@ func SaveSentiAnswer(database *mongo.Database, w http.ResponseWriter, r *http.Re
 
 // validation 才會用到的
 
-func PostSentiValidation(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func PostSentiValidation(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.SentiAnswer
 	var finalAnswer models.SentiAnswer
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
@@ -852,47 +1036,87 @@ func PostSentiValidation(database *mongo.Database, w http.ResponseWriter, r *htt
 	log.Println("GetSentiAnsById queryInfo:", requestBody)
 
 	sentiVal := requestBody.Sentiment
-	sentiList, err := service.GetSentiAnswer(database, models.SentiSentiment{TaskId: sentiVal[0].TaskId})
-	if err != nil {
+	taskId := requestBody.Task.TaskId
+	if taskId.IsZero() {
+		err = errors.New("task._id is required")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	// log.Println("sentiList:", sentiList)
-	allMatch := 1
-	for _, sentiValItem := range sentiVal {
-		isMatch := 0
-		// log.Println("sentiVal:", sentiValItem)
-		for _, sentiListItem := range sentiList {
-
-			// log.Println("sentiList:", sentiListItem)
-			if (sentiValItem.AspectId == sentiListItem.AspectId) && (sentiValItem.Sentiment == sentiValItem.Sentiment) && (sentiValItem.Dir == sentiListItem.Dir) && (sentiValItem.Offset == sentiListItem.Offset) {
-				isMatch = 1
-			}
-		}
-		if isMatch == 0 {
-			allMatch = 0
-			break
+	for _, sentiment := range sentiVal {
+		if sentiment.TaskId != taskId {
+			err = errors.New("every sentiment of a validation must be on its task")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
 		}
 	}
-	finalAnswer.Task = requestBody.Task
+	if !sentiTasksInProject(store, w, r, []primitive.ObjectID{taskId}) {
+		return auth.ErrForbidden
+	}
+	task, err := store.FindSentiTaskById(taskId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if task.IsValidate {
+		http.Error(w, service.ErrDecided.Error(), http.StatusConflict)
+		return service.ErrDecided
+	}
+	if !task.IsAnswered || task.Gold {
+		err = errors.New("only tasks with all their annotations, and no gold tasks, can be validated")
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
+	if !notOwnWork(w, r, task.Annotators...) {
+		return service.ErrOwnWork
+	}
+	if !claimFree(store, w, r, models.ClaimSentiValidation, taskId) {
+		return service.ErrClaimed
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	err = sentiOffsetsToRunes(store, unit, requestBody.Aspect, sentiVal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	diff, err := service.MatchSentiValidation(store, taskId, sentiVal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	allMatch := 0
+	if len(diff) == 0 {
+		allMatch = 1
+	}
+	finalAnswer.Task = *task
 	finalAnswer.Aspect = requestBody.Aspect
 	finalAnswer.Sentiment = requestBody.Sentiment
-	finalAnswer.ProjectId = requestBody.ProjectId
+	finalAnswer.ProjectId = currentProjectId(r)
+	finalAnswer.UserId = currentUserId(r)
+	finalAnswer.Diff = diff
+	finalAnswer.ValidatedAt = time.Now()
 
 	if allMatch == 1 {
 		log.Println("successful validation")
-		finalAnswer.State = "All Match"
+		finalAnswer.State = models.SentiAllMatch
 
 	} else {
 		log.Println("failed validation")
-		finalAnswer.State = "Not Match"
+		finalAnswer.State = models.SentiNotMatch
 	}
 
-	_, err = service.SaveFinalAnswer(database, finalAnswer)
+	_, err = store.SaveFinalAnswer(finalAnswer)
+	if err == service.ErrDecided {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	releaseClaim(store, r, models.ClaimSentiValidation, taskId)
 
 	jsondata, _ := json.Marshal(allMatch)
 	_, _ = w.Write(jsondata)
@@ -900,7 +1124,36 @@ func PostSentiValidation(database *mongo.Database, w http.ResponseWriter, r *htt
 	return err
 }
 
-func CheckIsAnswered(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+// GetSentiFinalAnswers lists the sentiment validations of a project with
+// their diff against the annotators, the earliest first.
+func GetSentiFinalAnswers(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.SentiFinalAnswersRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	answers, err := store.GetFinalAnswersByProjectId(currentProjectId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var result = []models.SentiAnswer{}
+	for _, answer := range answers {
+		if !requestBody.TaskId.IsZero() && answer.Task.TaskId != requestBody.TaskId {
+			continue
+		}
+		if requestBody.State != "" && answer.State != requestBody.State {
+			continue
+		}
+		result = append(result, answer)
+	}
+	jsondata, _ := json.Marshal(result)
+	w.Write(jsondata)
+	return nil
+}
+
+func CheckIsAnswered(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.SentiTask
 
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
@@ -911,7 +1164,10 @@ func CheckIsAnswered(database *mongo.Database, w http.ResponseWriter, r *http.Re
 		return err
 	}
 
-	cnt, err := service.CheckIsAnswered(database, requestBody)
+	if !sentiArticleInProject(store, w, r, requestBody.ArticleId) {
+		return auth.ErrForbidden
+	}
+	cnt, err := store.CheckIsAnswered(requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -922,7 +1178,7 @@ func CheckIsAnswered(database *mongo.Database, w http.ResponseWriter, r *http.Re
 	return nil
 }
 
-func CheckIsValidated(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func CheckIsValidated(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var requestBody models.SentiTask
 
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
@@ -933,7 +1189,10 @@ func CheckIsValidated(database *mongo.Database, w http.ResponseWriter, r *http.R
 		return err
 	}
 
-	cnt, err := service.CheckIsValidated(database, requestBody)
+	if !sentiArticleInProject(store, w, r, requestBody.ArticleId) {
+		return auth.ErrForbidden
+	}
+	cnt, err := store.CheckIsValidated(requestBody)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -944,15 +1203,28 @@ func CheckIsValidated(database *mongo.Database, w http.ResponseWriter, r *http.R
 	return nil
 }
 
-func GetSentiValidation(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetSentiValidation(store service.Store, ttl time.Duration, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo models.SentiTask
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
-	task, err := service.GetRandomSentiTask(database, models.SentiTask{TaskType: queryInfo.TaskType, ProjectId: queryInfo.ProjectId})
-	if task == nil {
+	var task *models.SentiTask
+	query := models.SentiTask{TaskType: queryInfo.TaskType, ProjectId: currentProjectId(r)}
+	claim, err := service.ClaimNext(store, models.ClaimSentiValidation, query.ProjectId, currentUserId(r), ttl, func(exclude []primitive.ObjectID) (primitive.ObjectID, error) {
+		found, err := store.GetRandomSentiTask(query, currentUserId(r), exclude)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		task = found
+		return found.TaskId, nil
+	})
+	if err != nil && err != service.ErrNotFound && err != service.ErrClaimed {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if claim == nil {
 		var response = models.Success{
 			Success: true,
 			Message: "no validation",
@@ -962,7 +1234,7 @@ func GetSentiValidation(database *mongo.Database, w http.ResponseWriter, r *http
 		return nil
 	}
 
-	aspects, err := service.GetAspectByTaskId(database, models.SentiAspect{TaskId: task.TaskId})
+	aspects, err := store.GetAspectByTaskId(models.SentiAspect{TaskId: task.TaskId})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
@@ -976,6 +1248,7 @@ func GetSentiValidation(database *mongo.Database, w http.ResponseWriter, r *http
 	response.ProjectId = task.ProjectId
 	response.Context = task.Context
 	response.AspectPool = task.AspectPool
+	response.ClaimExpiresAt = &claim.ExpiresAt
 
 	if len(aspects) != 0 {
 		response.IsAnswered = true
@@ -985,19 +1258,34 @@ func GetSentiValidation(database *mongo.Database, w http.ResponseWriter, r *http
 	_, _ = w.Write(jsondata)
 	return nil
 }
-func GetSentiAspects(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
+func GetSentiAspects(store service.Store, w http.ResponseWriter, r *http.Request) error {
 	var queryInfo models.SentiTask
 	err := json.NewDecoder(r.Body).Decode(&queryInfo)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	if !sentiTaskInProject(store, w, r, queryInfo.TaskId) {
+		return auth.ErrForbidden
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
 	log.Println(models.SentiAspect{TaskId: queryInfo.TaskId})
-	aspects, err := service.GetAspectByTaskId(database, models.SentiAspect{TaskId: queryInfo.TaskId})
+	aspects, err := store.GetAspectByTaskId(models.SentiAspect{TaskId: queryInfo.TaskId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	task, err := store.FindSentiTaskById(queryInfo.TaskId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	for _, aspect := range aspects {
+		aspect.Offset = offsetToUnit(task.Context, aspect.Offset, unit)
+	}
 	log.Println(aspects)
 
 	jsondata, _ := json.Marshal(aspects)
@@ -1005,8 +1293,8 @@ func GetSentiAspects(database *mongo.Database, w http.ResponseWriter, r *http.Re
 	return nil
 }
 
-func DiscardSentiAnswer(database *mongo.Database, w http.ResponseWriter, r *http.Request) error {
-	var requestBody models.SentiTask
+func DiscardSentiAnswer(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.DiscardRequestModel
 
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	// log.Println(requestBody)
@@ -1016,11 +1304,18 @@ func DiscardSentiAnswer(database *mongo.Database, w http.ResponseWriter, r *http
 		return err
 	}
 
-	cnt, err := service.DiscardSentiAnswer(database, requestBody)
+	if !sentiTaskInProject(store, w, r, requestBody.TaskId) {
+		return auth.ErrForbidden
+	}
+	if !claimFree(store, w, r, models.ClaimSentiValidation, requestBody.TaskId) {
+		return service.ErrClaimed
+	}
+	cnt, err := store.DiscardSentiAnswer(models.SentiTask{TaskId: requestBody.TaskId}, currentUserId(r), requestBody.Reason)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	releaseClaim(store, r, models.ClaimSentiValidation, requestBody.TaskId)
 	jsondata, _ := json.Marshal(cnt)
 	_, _ = w.Write(jsondata)
 
@@ -0,0 +1,96 @@
+package respond
+
+import (
+	"net/http"
+
+	"Lynx/auth"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentUserId is the user auth.Middleware resolved from the session token.
+func currentUserId(r *http.Request) string {
+	userId, _ := auth.UserId(r.Context())
+	return userId
+}
+
+// currentProjectId is the project auth.Authorize checked the user's roles in.
+func currentProjectId(r *http.Request) primitive.ObjectID {
+	projectId, _ := auth.ProjectId(r.Context())
+	return projectId
+}
+
+// notOwnWork answers 403 when the user of the request is one of authors,
+// the annotators of what the request reviews.
+func notOwnWork(w http.ResponseWriter, r *http.Request, authors ...string) bool {
+	userId := currentUserId(r)
+	for _, author := range authors {
+		if author == userId {
+			http.Error(w, service.ErrOwnWork.Error(), http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// inProject answers 403 when a document addressed by id belongs to another
+// project than the one the request was authorized for.
+func inProject(w http.ResponseWriter, r *http.Request, projectId primitive.ObjectID) bool {
+	if projectId != currentProjectId(r) {
+		http.Error(w, "not part of this project", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// mrcArticleInProject checks an MRC article id, which tasks and answers keep
+// as a hex string, against the authorized project.
+func mrcArticleInProject(store service.Store, w http.ResponseWriter, r *http.Request, articleId string) bool {
+	id, err := primitive.ObjectIDFromHex(articleId)
+	if err != nil {
+		http.Error(w, "invalid articleId", http.StatusBadRequest)
+		return false
+	}
+	article, err := store.GetArticleByArticleId(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return inProject(w, r, article.ProjectId)
+}
+
+// sentiTaskInProject checks a sentiment task id against the authorized project.
+func sentiTaskInProject(store service.Store, w http.ResponseWriter, r *http.Request, taskId primitive.ObjectID) bool {
+	task, err := store.FindSentiTaskById(taskId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return inProject(w, r, task.ProjectId)
+}
+
+// sentiArticleInProject checks a sentiment article id against the authorized project.
+func sentiArticleInProject(store service.Store, w http.ResponseWriter, r *http.Request, articleId primitive.ObjectID) bool {
+	article, err := store.GetSentiArticleByArticleId(articleId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return inProject(w, r, article.ProjectId)
+}
+
+// sentiTasksInProject checks every distinct task id of a posted answer.
+func sentiTasksInProject(store service.Store, w http.ResponseWriter, r *http.Request, taskIds []primitive.ObjectID) bool {
+	checked := make(map[primitive.ObjectID]bool)
+	for _, taskId := range taskIds {
+		if checked[taskId] {
+			continue
+		}
+		checked[taskId] = true
+		if !sentiTaskInProject(store, w, r, taskId) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"Lynx/agreement"
+	"Lynx/models"
+	"Lynx/service"
+)
+
+// GetAgreement reports how much the annotators of a project agree, over the
+// project, per article and per pair of annotators.
+func GetAgreement(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	report, err := agreement.ProjectReport(store, *project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(report)
+	w.Write(jsondata)
+	return nil
+}
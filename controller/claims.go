@@ -0,0 +1,140 @@
+package respond
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"Lynx/auth"
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/viewModels"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// claimFree answers 409 when another user holds a live claim on the item a
+// request submits.
+func claimFree(store service.Store, w http.ResponseWriter, r *http.Request, kind string, itemId primitive.ObjectID) bool {
+	err := service.CheckClaim(store, kind, itemId, currentUserId(r))
+	if err == service.ErrClaimed {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// releaseClaim gives a submitted item back; the claim would expire anyway,
+// so failing to release it only logs.
+func releaseClaim(store service.Store, r *http.Request, kind string, itemId primitive.ObjectID) {
+	err := store.ReleaseClaim(kind, itemId, currentUserId(r))
+	if err != nil {
+		log.Println("Release claim error", err)
+	}
+}
+
+func checkClaimKind(w http.ResponseWriter, kind string) error {
+	for _, k := range models.ClaimKinds {
+		if k == kind {
+			return nil
+		}
+	}
+	err := fmt.Errorf("kind %q must be one of %s", kind, strings.Join(models.ClaimKinds, ", "))
+	http.Error(w, err.Error(), http.StatusBadRequest)
+	return err
+}
+
+// RenewClaim is the heartbeat of a user working on an item: it extends the
+// user's claim by ttl. Once the claim was taken over by someone else after
+// expiring, it answers 409 and the item must not be submitted.
+func RenewClaim(store service.Store, ttl time.Duration, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ClaimRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = checkClaimKind(w, requestBody.Kind)
+	if err != nil {
+		return err
+	}
+	userId := currentUserId(r)
+	claim, err := store.GetClaim(requestBody.Kind, requestBody.ItemId)
+	if err == nil && !inProject(w, r, claim.ProjectId) {
+		return auth.ErrForbidden
+	}
+	if err == nil {
+		claim, err = store.RenewClaim(requestBody.Kind, requestBody.ItemId, userId, time.Now().Add(ttl))
+	}
+	if err == service.ErrNotFound {
+		http.Error(w, "the claim was lost, ask for another item", http.StatusConflict)
+		return err
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(claim)
+	w.Write(jsondata)
+	return nil
+}
+
+// ReleaseClaim gives an item back to the queue without submitting it.
+func ReleaseClaim(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ClaimRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = checkClaimKind(w, requestBody.Kind)
+	if err != nil {
+		return err
+	}
+	claim, err := store.GetClaim(requestBody.Kind, requestBody.ItemId)
+	if err == nil {
+		if !inProject(w, r, claim.ProjectId) {
+			return auth.ErrForbidden
+		}
+		err = store.ReleaseClaim(requestBody.Kind, requestBody.ItemId, currentUserId(r))
+	}
+	if err != nil && err != service.ErrNotFound {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(models.Success{Success: true, Message: "released"})
+	w.Write(jsondata)
+	return nil
+}
+
+// GetClaims lists who is working on which item of the project, in the
+// queue named by kind or in all of them.
+func GetClaims(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ClaimRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if requestBody.Kind != "" {
+		err = checkClaimKind(w, requestBody.Kind)
+		if err != nil {
+			return err
+		}
+	}
+	claims, err := store.GetLiveClaims(currentProjectId(r), requestBody.Kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(claims)
+	w.Write(jsondata)
+	return nil
+}
@@ -0,0 +1,92 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"Lynx/exporter"
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/viewModels"
+)
+
+// writeDownload sends data as a JSON file download.
+func writeDownload(w http.ResponseWriter, filename string, data interface{}) error {
+	jsondata, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(jsondata)
+	return nil
+}
+
+// ExportSquad downloads the selected answers of an MRC project as a SQuAD file.
+func ExportSquad(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ExportSquadRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	opts := exporter.DefaultSquadOptions()
+	if requestBody.Version != "" {
+		opts.Version = requestBody.Version
+	}
+	if requestBody.Statuses != nil || requestBody.DecisionResults != nil {
+		opts.Statuses = requestBody.Statuses
+		opts.DecisionResults = requestBody.DecisionResults
+	}
+	dataset, err := exporter.ExportSQuAD(store, *project, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	return writeDownload(w, project.ProjectId.Hex()+"-squad.json", dataset)
+}
+
+// ExportAbsa downloads the validated answers of a sentiment project as a
+// SemEval XML, JSONL or CSV file.
+func ExportAbsa(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ExportAbsaRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if requestBody.Format == "" {
+		requestBody.Format = exporter.FormatSemEval
+	}
+	export, err := exporter.ExportABSA(store, *project, exporter.AbsaOptions{Format: requestBody.Format, AllItems: requestBody.AllItems})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if export.Unmapped != 0 {
+		log.Println("ABSA export of", project.ProjectId.Hex(), "wrote", export.Unmapped, "aspects without their term")
+	}
+	var buf bytes.Buffer
+	err = export.Write(&buf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", export.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", project.ProjectId.Hex()+"-absa."+export.Extension()))
+	w.Write(buf.Bytes())
+	return nil
+}
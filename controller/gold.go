@@ -0,0 +1,371 @@
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"Lynx/auth"
+	"Lynx/importer"
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/viewModels"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func goldResult(label models.Label, userId string, score float64, correct bool) models.GoldResult {
+	return models.GoldResult{
+		ProjectId:  label.ProjectId,
+		LabelId:    label.Id,
+		UserId:     userId,
+		Score:      score,
+		Correct:    correct,
+		AnsweredAt: time.Now(),
+	}
+}
+
+// checkGoldSentiments accepts one polarity of importer.Polarities per
+// category of the aspect pool of the task.
+func checkGoldSentiments(task models.SentiTask, sentiments []models.GoldSentiment) error {
+	if len(sentiments) == 0 {
+		return errors.New("a gold sentiment task needs sentiments")
+	}
+	seen := make(map[string]bool)
+	for _, s := range sentiments {
+		if !containsString(task.AspectPool, s.Category) {
+			return fmt.Errorf("category %q is not in the aspect pool of the task", s.Category)
+		}
+		if !containsString(importer.Polarities, s.Polarity) {
+			return fmt.Errorf("polarity %q must be one of %s", s.Polarity, strings.Join(importer.Polarities, ", "))
+		}
+		if seen[s.Category] {
+			return fmt.Errorf("category %q has several polarities", s.Category)
+		}
+		seen[s.Category] = true
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveGold makes a task of the project gold with its known answer. MRC
+// labels are a question on a task with its true answer, in the offset unit
+// of the request, or none for unanswerable questions. Sentiment labels are
+// the polarity of every category of a task, which no longer needs regular
+// annotators. Saving the label of the same question or task again
+// replaces it.
+func SaveGold(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var label models.Label
+	err := json.NewDecoder(r.Body).Decode(&label)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	label.Id = primitive.NilObjectID
+	label.ProjectId = project.ProjectId
+	label.TaskType = project.ProjectType
+	switch project.ProjectType {
+	case importer.TypeMRC:
+		if !mrcArticleInProject(store, w, r, label.ArticleId) {
+			return auth.ErrForbidden
+		}
+		task, err := store.GetTaskById(models.MRCTask{ArticleId: label.ArticleId, TaskId: label.TaskId, TaskType: "MRC"})
+		if err == nil && strings.TrimSpace(label.Question) == "" {
+			err = errors.New("a gold MRC task needs a question")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		answer := models.MRCAnswer{Answer: label.TrueAnswer, StartIdx: label.StartIdx, IsImpossible: label.TrueAnswer == ""}
+		err = answerToRunes(task.Context, &answer, unit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		label.StartIdx = answer.StartIdx
+		label.Sentiments = nil
+	case importer.TypeSentiment:
+		taskId, err := primitive.ObjectIDFromHex(label.TaskId)
+		if err != nil {
+			http.Error(w, "invalid taskId", http.StatusBadRequest)
+			return err
+		}
+		if !sentiTaskInProject(store, w, r, taskId) {
+			return auth.ErrForbidden
+		}
+		task, err := store.FindSentiTaskById(taskId)
+		if err == nil {
+			err = checkGoldSentiments(*task, label.Sentiments)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		label.ArticleId = task.ArticleId.Hex()
+		label.Question, label.TrueAnswer, label.StartIdx = "", "", 0
+	default:
+		err = fmt.Errorf("%q projects have no gold tasks", project.ProjectType)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	label.Id, err = store.SaveLabel(label)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(label)
+	w.Write(jsondata)
+	return nil
+}
+
+// DeleteGold removes a gold label. Its sentiment task goes back to the
+// regular annotators, the gold answers counting as their annotations; the
+// results of the label are kept.
+func DeleteGold(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.GoldRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = store.DeleteLabel(project.ProjectId, requestBody.Id)
+	if err == service.ErrNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return err
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(models.Success{Success: true, Message: requestBody.Id.Hex()})
+	w.Write(jsondata)
+	return nil
+}
+
+// GetGolds lists the gold labels of the project, MRC offsets in the unit
+// of the request.
+func GetGolds(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	labels, err := store.GetLabels(currentProjectId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	for i := range labels {
+		l := &labels[i]
+		if l.TaskType != importer.TypeMRC || l.TrueAnswer == "" {
+			continue
+		}
+		task, err := store.GetTaskById(models.MRCTask{ArticleId: l.ArticleId, TaskId: l.TaskId, TaskType: "MRC"})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		l.StartIdx = offsetToUnit(task.Context, l.StartIdx, unit)
+	}
+	jsondata, _ := json.Marshal(labels)
+	w.Write(jsondata)
+	return nil
+}
+
+// GetGoldStats reports how every annotator of the project does on its gold
+// tasks, and who is paused.
+func GetGoldStats(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	projectId := currentProjectId(r)
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: projectId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	auths, err := store.GetAuthByProjectId(models.Auth{ProjectId: projectId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	results, err := store.GetGoldResults(projectId, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var userIds []string
+	paused := make(map[string]bool)
+	for _, a := range auths {
+		if a.Role != models.RoleAnnotator {
+			continue
+		}
+		if !containsString(userIds, a.UserId) {
+			userIds = append(userIds, a.UserId)
+		}
+		paused[a.UserId] = paused[a.UserId] || a.Paused
+	}
+	byUser := make(map[string][]models.GoldResult)
+	for _, result := range results {
+		if !containsString(userIds, result.UserId) {
+			userIds = append(userIds, result.UserId)
+		}
+		byUser[result.UserId] = append(byUser[result.UserId], result)
+	}
+
+	window := project.Gold.EffectiveWindow()
+	stats := []viewModels.GoldStatsViewModel{}
+	for _, userId := range userIds {
+		s := viewModels.GoldStatsViewModel{UserId: userId, Answered: len(byUser[userId]), Paused: paused[userId]}
+		total := 0.0
+		for _, result := range byUser[userId] {
+			total += result.Score
+			if result.Correct {
+				s.Correct++
+			}
+		}
+		if s.Answered != 0 {
+			accuracy := float64(s.Correct) / float64(s.Answered)
+			meanScore := total / float64(s.Answered)
+			windowAccuracy, counted := service.WindowAccuracy(byUser[userId], window)
+			s.Accuracy, s.MeanScore, s.WindowAccuracy, s.Window = &accuracy, &meanScore, &windowAccuracy, counted
+		}
+		stats = append(stats, s)
+	}
+	jsondata, _ := json.Marshal(stats)
+	w.Write(jsondata)
+	return nil
+}
+
+// ResumeAnnotator lifts the pause of an annotator. Their gold accuracy is
+// unchanged, so the next wrong gold answer may pause them again.
+func ResumeAnnotator(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ResumeAnnotatorRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = store.SetAnnotatorPaused(currentProjectId(r), requestBody.UserId, false)
+	if err == service.ErrNotFound {
+		http.Error(w, "not an annotator of this project", http.StatusNotFound)
+		return err
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(models.Success{Success: true, Message: requestBody.UserId})
+	w.Write(jsondata)
+	return nil
+}
+
+// NextTask hands the annotator the next task to work on: a gold task at the
+// gold rate of the project, else the first task that still needs them.
+// Gold tasks look like any other; MRC ones come with the question to answer.
+func NextTask(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	userId := currentUserId(r)
+	label, err := service.PickGold(store, *project, userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var response *viewModels.NextTaskViewModel
+	if label != nil {
+		response, err = goldTask(store, *label)
+	} else {
+		response, err = openTask(store, *project, userId)
+	}
+	if err == service.ErrNotFound {
+		jsondata, _ := json.Marshal(models.Success{Success: true, Message: "no task"})
+		w.Write(jsondata)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(response)
+	w.Write(jsondata)
+	return nil
+}
+
+func goldTask(store service.Store, label models.Label) (*viewModels.NextTaskViewModel, error) {
+	if taskId, ok := label.SentiTaskId(); ok {
+		task, err := store.FindSentiTaskById(taskId)
+		if err != nil {
+			return nil, err
+		}
+		return sentiNextTask(*task), nil
+	}
+	task, err := store.GetTaskById(models.MRCTask{ArticleId: label.ArticleId, TaskId: label.TaskId, TaskType: "MRC"})
+	if err != nil {
+		return nil, err
+	}
+	response := mrcNextTask(*task)
+	response.Question = label.Question
+	return response, nil
+}
+
+func openTask(store service.Store, project models.Project, userId string) (*viewModels.NextTaskViewModel, error) {
+	if project.ProjectType == importer.TypeSentiment {
+		task, err := store.GetOpenSentiTask(project.ProjectId, userId)
+		if err != nil {
+			return nil, err
+		}
+		return sentiNextTask(*task), nil
+	}
+	task, err := store.GetOpenMRCTask(project.ProjectId, userId, project.RequiredAnnotators())
+	if err != nil {
+		return nil, err
+	}
+	return mrcNextTask(*task), nil
+}
+
+func sentiNextTask(task models.SentiTask) *viewModels.NextTaskViewModel {
+	return &viewModels.NextTaskViewModel{
+		TaskType:   task.TaskType,
+		ArticleId:  task.ArticleId.Hex(),
+		TaskId:     task.TaskId.Hex(),
+		TaskTitle:  task.TaskTitle,
+		Context:    task.Context,
+		AspectPool: task.AspectPool,
+	}
+}
+
+func mrcNextTask(task models.MRCTask) *viewModels.NextTaskViewModel {
+	return &viewModels.NextTaskViewModel{
+		TaskType:  task.TaskType,
+		ArticleId: task.ArticleId,
+		TaskId:    task.TaskId,
+		TaskTitle: task.TaskTitle,
+		Context:   task.Context,
+	}
+}
@@ -0,0 +1,107 @@
+package respond
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/span"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// offsetUnit reads the unit the request counts its offsets in, and answers
+// in, from span.Header. It echoes the unit on the response and answers 400
+// for unknown ones.
+func offsetUnit(w http.ResponseWriter, r *http.Request) (span.Unit, error) {
+	unit, err := span.ParseUnit(r.Header.Get(span.Header))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", err
+	}
+	w.Header().Set(span.Header, string(unit))
+	return unit, nil
+}
+
+// answerToRunes converts the StartIdx of an MRC answer from unit into runes
+// of context and checks that the answer is found there. Unanswerable
+// questions have no answer and StartIdx -1.
+func answerToRunes(context string, answer *models.MRCAnswer, unit span.Unit) error {
+	if answer.IsImpossible {
+		if answer.Answer != "" {
+			return fmt.Errorf("an unanswerable question cannot have the answer %q", answer.Answer)
+		}
+		answer.StartIdx = -1
+		return nil
+	}
+	if answer.Answer == "" {
+		return fmt.Errorf("answer is empty")
+	}
+	var err error
+	answer.StartIdx, err = span.ToRunes(context, answer.StartIdx, unit)
+	if err != nil {
+		return fmt.Errorf("startIdx: %v", err)
+	}
+	return span.Check(context, answer.StartIdx, answer.Answer)
+}
+
+// offsetToUnit converts a stored rune offset into text for a response.
+// Offsets saved before they were counted in runes may not fit the text;
+// they are sent as they are.
+func offsetToUnit(text string, offset int, unit span.Unit) int {
+	converted, err := span.FromRunes(text, offset, unit)
+	if err != nil {
+		log.Println("stored offset", offset, "does not fit its text:", err)
+		return offset
+	}
+	return converted
+}
+
+// sentiOffsetsToRunes converts the offsets of a sentiment answer from unit
+// into runes of the contexts of their tasks, and checks that every aspect
+// term is found at its offset.
+func sentiOffsetsToRunes(store service.Store, unit span.Unit, aspects []models.SentiAspect, sentiments []models.SentiSentiment) error {
+	contexts := make(map[primitive.ObjectID]string)
+	contextOf := func(taskId primitive.ObjectID) (string, error) {
+		if context, ok := contexts[taskId]; ok {
+			return context, nil
+		}
+		task, err := store.FindSentiTaskById(taskId)
+		if err != nil {
+			return "", err
+		}
+		contexts[taskId] = task.Context
+		return task.Context, nil
+	}
+	for i := range aspects {
+		a := &aspects[i]
+		context, err := contextOf(a.TaskId)
+		if err != nil {
+			return err
+		}
+		a.Offset, err = span.ToRunes(context, a.Offset, unit)
+		if err != nil {
+			return fmt.Errorf("aspect %s: %v", a.AspectId, err)
+		}
+		if a.MinorAspect != "" && a.Offset >= 0 {
+			err = span.Check(context, a.Offset, a.MinorAspect)
+			if err != nil {
+				return fmt.Errorf("aspect %s: %v", a.AspectId, err)
+			}
+		}
+	}
+	for i := range sentiments {
+		s := &sentiments[i]
+		context, err := contextOf(s.TaskId)
+		if err != nil {
+			return err
+		}
+		s.Offset, err = span.ToRunes(context, s.Offset, unit)
+		if err != nil {
+			return fmt.Errorf("sentiment of aspect %s: %v", s.AspectId, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"Lynx/models"
+	"Lynx/service"
+)
+
+// GetProgress reports where the work of a project stands: how many items
+// are in each state, over the project and per article, and how much work
+// was done day by day.
+func GetProgress(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	progress, err := store.GetProgress(*project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(progress)
+	w.Write(jsondata)
+	return nil
+}
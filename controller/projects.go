@@ -0,0 +1,520 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"Lynx/importer"
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/viewModels"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxUploadMemory is how much of a multipart upload is kept in memory,
+// the rest is buffered in temporary files.
+const maxUploadMemory = 32 << 20
+
+// isMultipart tells uploads sent as multipart/form-data from JSON bodies.
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+// readFormFile reads a whole uploaded file, nil when the form has none.
+func readFormFile(r *http.Request, field string) ([]byte, error) {
+	file, _, err := r.FormFile(field)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// writeImportError answers the RowErrors of an importer as a list, and any
+// other error as plain text.
+func writeImportError(w http.ResponseWriter, err error) {
+	if rowErrs, ok := err.(importer.RowErrors); ok {
+		var response = viewModels.ImportErrorViewModel{
+			Success: false,
+			Message: err.Error(),
+			Errors:  rowErrs,
+		}
+		jsondata, _ := json.Marshal(response)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(jsondata)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// readAbsaForm reads an ABSA upload: the file in absaFile, with the fields
+// format, aspectPool (comma separated) and withSeeds. It returns nil when
+// the form has no absaFile.
+func readAbsaForm(r *http.Request) (*viewModels.AbsaImportModel, error) {
+	data, err := readFormFile(r, "absaFile")
+	if err != nil || data == nil {
+		return nil, err
+	}
+	absa := &viewModels.AbsaImportModel{
+		Format:    r.FormValue("format"),
+		Data:      string(data),
+		WithSeeds: r.FormValue("withSeeds") == "true",
+	}
+	if pool := r.FormValue("aspectPool"); pool != "" {
+		absa.AspectPool = strings.Split(pool, ",")
+	}
+	return absa, nil
+}
+
+// parseAbsa imports an ABSA dataset into project.
+func parseAbsa(project models.Project, absa viewModels.AbsaImportModel) (*service.ProjectContent, error) {
+	return importer.ParseABSA(project, strings.NewReader(absa.Data), importer.AbsaOptions{
+		Format:     absa.Format,
+		AspectPool: absa.AspectPool,
+		WithSeeds:  absa.WithSeeds,
+	})
+}
+
+// decodeAddProject reads either a JSON AddProjectViewModel, or a multipart
+// form with the JSON fields project and members, the CSV file in csvFile,
+// the SQuAD file in squadFile with answerStatus, or the ABSA file in
+// absaFile (see readAbsaForm).
+func decodeAddProject(r *http.Request) (viewModels.AddProjectViewModel, error) {
+	var addProjModel viewModels.AddProjectViewModel
+	if !isMultipart(r) {
+		err := json.NewDecoder(r.Body).Decode(&addProjModel)
+		return addProjModel, err
+	}
+	err := r.ParseMultipartForm(maxUploadMemory)
+	if err != nil {
+		return addProjModel, err
+	}
+	err = json.Unmarshal([]byte(r.FormValue("project")), &addProjModel.Project)
+	if err != nil {
+		return addProjModel, fmt.Errorf("project: %v", err)
+	}
+	if members := r.FormValue("members"); members != "" {
+		err = json.Unmarshal([]byte(members), &addProjModel.Members)
+		if err != nil {
+			return addProjModel, fmt.Errorf("members: %v", err)
+		}
+	}
+	addProjModel.AnswerStatus = r.FormValue("answerStatus")
+	addProjModel.Squad, err = readFormFile(r, "squadFile")
+	if err != nil {
+		return addProjModel, fmt.Errorf("squadFile: %v", err)
+	}
+	if addProjModel.Squad != nil {
+		return addProjModel, nil
+	}
+	addProjModel.Absa, err = readAbsaForm(r)
+	if err != nil {
+		return addProjModel, fmt.Errorf("absaFile: %v", err)
+	}
+	if addProjModel.Absa != nil {
+		return addProjModel, nil
+	}
+	file, _, err := r.FormFile("csvFile")
+	if err != nil {
+		return addProjModel, fmt.Errorf("csvFile: %v", err)
+	}
+	defer file.Close()
+	addProjModel.CsvFile, err = importer.ReadCSV(file)
+	if err != nil {
+		return addProjModel, fmt.Errorf("csvFile: %v", err)
+	}
+	return addProjModel, nil
+}
+
+// SaveProject creates a project with its members, articles and tasks in one
+// go, from a CSV, a SQuAD or an ABSA file. The creator becomes its owner and members
+// default to annotators.
+func SaveProject(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	addProjModel, err := decodeAddProject(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project := addProjModel.Project
+	project.ProjectId = primitive.NewObjectID()
+	project.ProjectName = strings.TrimSpace(project.ProjectName)
+	project.ManagerId = currentUserId(r)
+	if project.ProjectName == "" {
+		err = errors.New("project name is required")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if project.Redundancy == 0 {
+		project.Redundancy = 1
+	}
+	err = checkRedundancy(project.Redundancy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = checkMatchRule(&project.MatchRule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = checkGold(project.Gold)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// check the whole upload before writing anything
+	var content *service.ProjectContent
+	if len(addProjModel.Squad) != 0 {
+		content, err = importer.ParseSQuAD(project, bytes.NewReader(addProjModel.Squad), addProjModel.AnswerStatus)
+	} else if addProjModel.Absa != nil {
+		content, err = parseAbsa(project, *addProjModel.Absa)
+	} else {
+		content, err = importer.ParseCSV(project, addProjModel.CsvFile)
+	}
+	if err != nil {
+		writeImportError(w, err)
+		return err
+	}
+
+	content.Auths = append(content.Auths, models.Auth{
+		ProjectId:  project.ProjectId,
+		UserId:     project.ManagerId,
+		Role:       models.RoleOwner,
+		CodeType:   "1",
+		StatusCode: "0",
+	})
+	for _, member := range addProjModel.Members {
+		if member.Role == "" {
+			member.Role = models.RoleAnnotator
+		}
+		if member.UserId == "" || !models.IsRole(member.Role) {
+			err = fmt.Errorf("member %q with role %q: roles are %s", member.UserId, member.Role, strings.Join(models.Roles, ", "))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		member.ProjectId = project.ProjectId
+		member.CodeType = "1"
+		if member.StatusCode == "" {
+			member.StatusCode = "1"
+		}
+		content.Auths = append(content.Auths, member)
+	}
+
+	err = store.CreateProject(project, *content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	log.Println("Created project", project.ProjectId.Hex(), len(content.Articles)+len(content.SentiArticles), "articles")
+	var response = models.Success{
+		Success: true,
+		Message: project.ProjectId.Hex(),
+	}
+	jsondata, _ := json.Marshal(response)
+	_, _ = w.Write(jsondata)
+	return nil
+}
+
+// GetProjects lists the projects the user holds a role in, with those roles.
+// Archived projects are left out unless includeArchived is set.
+func GetProjects(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var queryInfo viewModels.ProjectListRequestModel
+	err := json.NewDecoder(r.Body).Decode(&queryInfo)
+	if err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	auths, err := store.GetAuthsByUserId(currentUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	projects, err := store.GetProjectsByIds(auths.SelectProjectIdList())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var result = []viewModels.ProjectListViewModel{}
+	for _, project := range projects {
+		if project.Archived && !queryInfo.IncludeArchived {
+			continue
+		}
+		var item = viewModels.ProjectListViewModel{Project: project, Roles: []string{}}
+		for _, a := range auths {
+			if a.ProjectId == project.ProjectId {
+				item.Roles = append(item.Roles, a.EffectiveRole())
+			}
+		}
+		result = append(result, item)
+	}
+	jsondata, _ := json.Marshal(result)
+	w.Write(jsondata)
+	return nil
+}
+
+// checkRedundancy accepts 1 to models.MaxRedundancy annotators per task.
+func checkRedundancy(redundancy int) error {
+	if redundancy < 1 || redundancy > models.MaxRedundancy {
+		return fmt.Errorf("redundancy %d must be between 1 and %d", redundancy, models.MaxRedundancy)
+	}
+	return nil
+}
+
+// checkMatchRule accepts the methods of models.MatchMethods, an empty one
+// meaning exact, with a threshold above 0 and at most 1. Exact matches need
+// no threshold.
+func checkMatchRule(rule *models.MatchRule) error {
+	if rule.Method == "" || rule.Method == models.MatchExact {
+		*rule = rule.Effective()
+		return nil
+	}
+	known := false
+	for _, method := range models.MatchMethods {
+		known = known || method == rule.Method
+	}
+	if !known {
+		return fmt.Errorf("match method %q must be one of %s", rule.Method, strings.Join(models.MatchMethods, ", "))
+	}
+	if rule.Threshold <= 0 || rule.Threshold > 1 {
+		return fmt.Errorf("match threshold %v must be above 0 and at most 1", rule.Threshold)
+	}
+	return nil
+}
+
+// checkGold accepts rates and accuracies from 0 to 1, and windows of up to
+// models.MaxGoldWindow answers; 0 is models.DefaultGoldWindow.
+func checkGold(gold models.GoldSettings) error {
+	if gold.Rate < 0 || gold.Rate > 1 {
+		return fmt.Errorf("gold rate %v must be between 0 and 1", gold.Rate)
+	}
+	if gold.MinAccuracy < 0 || gold.MinAccuracy > 1 {
+		return fmt.Errorf("gold minAccuracy %v must be between 0 and 1", gold.MinAccuracy)
+	}
+	if gold.Window < 0 || gold.Window > models.MaxGoldWindow {
+		return fmt.Errorf("gold window %d must be between 0 and %d", gold.Window, models.MaxGoldWindow)
+	}
+	return nil
+}
+
+// UpdateProject edits name, rule, type, redundancy, match rule and gold
+// settings. The type only changes while the project has no articles, since
+// they are stored per type. A new redundancy applies to the tasks annotated
+// so far too, a new match rule only to validations saved from then on, and
+// new gold settings only pause annotators on their next gold answer.
+func UpdateProject(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.UpdateProjectRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if name := strings.TrimSpace(requestBody.ProjectName); name != "" {
+		project.ProjectName = name
+	}
+	if requestBody.Rule != nil {
+		project.Rule = *requestBody.Rule
+	}
+	if requestBody.ProjectType != "" && requestBody.ProjectType != project.ProjectType {
+		if requestBody.ProjectType != importer.TypeMRC && requestBody.ProjectType != importer.TypeSentiment {
+			err = fmt.Errorf("project type %q must be %s or %s", requestBody.ProjectType, importer.TypeMRC, importer.TypeSentiment)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		count, err := store.CountProjectArticles(project.ProjectId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		if count != 0 {
+			err = errors.New("the type of a project with articles cannot change")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return err
+		}
+		project.ProjectType = requestBody.ProjectType
+	}
+	if requestBody.Redundancy != nil {
+		err = checkRedundancy(*requestBody.Redundancy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		project.Redundancy = *requestBody.Redundancy
+	}
+	if requestBody.MatchRule != nil {
+		err = checkMatchRule(requestBody.MatchRule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		project.MatchRule = *requestBody.MatchRule
+	}
+	if requestBody.Gold != nil {
+		err = checkGold(*requestBody.Gold)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		project.Gold = *requestBody.Gold
+	}
+	err = store.UpdateProject(*project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(project)
+	w.Write(jsondata)
+	return nil
+}
+
+// ArchiveProject archives or unarchives the project of the request.
+func ArchiveProject(store service.Store, archived bool, w http.ResponseWriter, r *http.Request) error {
+	projectId := currentProjectId(r)
+	err := store.SetProjectArchived(projectId, archived)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var response = models.Success{
+		Success: true,
+		Message: projectId.Hex(),
+	}
+	jsondata, _ := json.Marshal(response)
+	w.Write(jsondata)
+	return nil
+}
+
+// DeleteProject removes the project and everything annotated in it for good.
+func DeleteProject(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	projectId := currentProjectId(r)
+	err := store.DeleteProject(projectId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	log.Println("Deleted project", projectId.Hex(), "by", currentUserId(r))
+	var response = models.Success{
+		Success: true,
+		Message: projectId.Hex(),
+	}
+	jsondata, _ := json.Marshal(response)
+	w.Write(jsondata)
+	return nil
+}
+
+// ImportSquad adds the articles, paragraphs and questions of a SQuAD file to
+// an existing MRC project. The file comes as the JSON field squad, or as the
+// file squadFile of a multipart form, with projectId in the query then.
+func ImportSquad(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ImportSquadRequestModel
+	var err error
+	if isMultipart(r) {
+		err = r.ParseMultipartForm(maxUploadMemory)
+		if err == nil {
+			requestBody.AnswerStatus = r.FormValue("answerStatus")
+			requestBody.Squad, err = readFormFile(r, "squadFile")
+		}
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&requestBody)
+	}
+	if err == nil && len(requestBody.Squad) == 0 {
+		err = errors.New("squad file is required")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	content, err := importer.ParseSQuAD(*project, bytes.NewReader(requestBody.Squad), requestBody.AnswerStatus)
+	if err != nil {
+		writeImportError(w, err)
+		return err
+	}
+	err = store.AddProjectContent(*content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var response = viewModels.ImportResultViewModel{
+		Success:  true,
+		Articles: len(content.Articles),
+		Tasks:    len(content.MRCTasks),
+		Answers:  len(content.MRCAnswers),
+	}
+	jsondata, _ := json.Marshal(response)
+	w.Write(jsondata)
+	return nil
+}
+
+// ImportAbsa adds the sentences of a SemEval or JSONL ABSA dataset to a
+// sentiment project, optionally with their aspects as answers to validate.
+// Uploads send projectId in the query string.
+func ImportAbsa(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.ImportAbsaRequestModel
+	var err error
+	if isMultipart(r) {
+		err = r.ParseMultipartForm(maxUploadMemory)
+		if err == nil {
+			var absa *viewModels.AbsaImportModel
+			absa, err = readAbsaForm(r)
+			if absa != nil {
+				requestBody.AbsaImportModel = *absa
+			}
+		}
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&requestBody)
+	}
+	if err == nil && requestBody.Data == "" {
+		err = errors.New("absa file is required")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	content, err := parseAbsa(*project, requestBody.AbsaImportModel)
+	if err != nil {
+		writeImportError(w, err)
+		return err
+	}
+	err = store.AddProjectContent(*content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var response = viewModels.ImportResultViewModel{
+		Success:  true,
+		Articles: len(content.SentiArticles),
+		Tasks:    len(content.SentiTasks),
+		Answers:  len(content.SentiAspects),
+	}
+	jsondata, _ := json.Marshal(response)
+	w.Write(jsondata)
+	return nil
+}
@@ -0,0 +1,170 @@
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Lynx/models"
+	"Lynx/service"
+	"Lynx/span"
+	"Lynx/viewModels"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// revisionOffsetsToUnit converts the stored rune offsets of revisions into
+// unit, against the contexts of their tasks.
+func revisionOffsetsToUnit(store service.Store, unit span.Unit, revisions []models.Revision) error {
+	contexts := make(map[primitive.ObjectID]string)
+	contextOf := func(revision models.Revision) (string, error) {
+		if context, ok := contexts[revision.TargetId]; ok {
+			return context, nil
+		}
+		var context string
+		if revision.SentiTask != nil {
+			task, err := store.FindSentiTaskById(revision.TargetId)
+			if err != nil {
+				return "", err
+			}
+			context = task.Context
+		} else {
+			answer := revision.MRCAnswer
+			task, err := store.GetTaskById(models.MRCTask{ArticleId: answer.ArticleId, TaskId: answer.TaskId, TaskType: "MRC"})
+			if err != nil {
+				return "", err
+			}
+			context = task.Context
+		}
+		contexts[revision.TargetId] = context
+		return context, nil
+	}
+	for i := range revisions {
+		revision := revisions[i]
+		if revision.SentiTask == nil && (revision.MRCAnswer == nil || revision.MRCAnswer.IsImpossible) {
+			continue
+		}
+		context, err := contextOf(revision)
+		if err != nil {
+			return err
+		}
+		if version := revision.SentiTask; version != nil {
+			for k := range version.Aspects {
+				version.Aspects[k].Offset = offsetToUnit(context, version.Aspects[k].Offset, unit)
+			}
+			for k := range version.Sentiments {
+				version.Sentiments[k].Offset = offsetToUnit(context, version.Sentiments[k].Offset, unit)
+			}
+		} else {
+			revision.MRCAnswer.StartIdx = offsetToUnit(context, revision.MRCAnswer.StartIdx, unit)
+		}
+	}
+	return nil
+}
+
+// GetHistory lists the revisions of the annotations of a project, or of one
+// sentiment task or MRC answer, in the order they were made. Offsets are in
+// the unit of the request.
+func GetHistory(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.HistoryRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	revisions, err := store.GetRevisions(currentProjectId(r), requestBody.TargetId)
+	if err == nil {
+		err = revisionOffsetsToUnit(store, unit, revisions)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(revisions)
+	w.Write(jsondata)
+	return nil
+}
+
+// GetRevisionDiff compares two revisions of the same annotation, field by
+// field, see service.DiffRevisions.
+func GetRevisionDiff(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.RevisionDiffRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	revisions := make([]models.Revision, 2)
+	for i, id := range []primitive.ObjectID{requestBody.From, requestBody.To} {
+		revision, err := store.GetRevision(currentProjectId(r), id)
+		if err == service.ErrNotFound {
+			http.Error(w, "revision "+id.Hex()+" not found", http.StatusNotFound)
+			return err
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		revisions[i] = *revision
+	}
+	if revisions[0].TargetId != revisions[1].TargetId {
+		err = errors.New("revisions of different annotations cannot be compared")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	err = revisionOffsetsToUnit(store, unit, revisions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	diff := models.RevisionDiff{
+		From:    revisions[0],
+		To:      revisions[1],
+		Changes: service.DiffRevisions(revisions[0], revisions[1]),
+	}
+	jsondata, _ := json.Marshal(diff)
+	w.Write(jsondata)
+	return nil
+}
+
+// RestoreRevision brings an annotation back as a revision of it left it,
+// and answers the new revision that records the restore.
+func RestoreRevision(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.RestoreRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	unit, err := offsetUnit(w, r)
+	if err != nil {
+		return err
+	}
+	revision, err := store.RestoreRevision(currentProjectId(r), requestBody.RevisionId, currentUserId(r), requestBody.Reason)
+	if err == service.ErrNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return err
+	}
+	if err == service.ErrDecided || err == service.ErrReviewed {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return err
+	}
+	if err == nil {
+		err = revisionOffsetsToUnit(store, unit, []models.Revision{*revision})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(revision)
+	w.Write(jsondata)
+	return nil
+}
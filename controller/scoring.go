@@ -0,0 +1,48 @@
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Lynx/models"
+	"Lynx/scoring"
+	"Lynx/service"
+	"Lynx/viewModels"
+)
+
+// GetLeaderboard ranks the users of a project by their acceptance rate,
+// overturn rate, throughput or gold accuracy.
+func GetLeaderboard(store service.Store, w http.ResponseWriter, r *http.Request) error {
+	var requestBody viewModels.LeaderboardRequestModel
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	var period scoring.Period
+	if requestBody.Since != nil {
+		period.Since = *requestBody.Since
+	}
+	if requestBody.Until != nil {
+		period.Until = *requestBody.Until
+	}
+	if !period.Since.IsZero() && !period.Until.IsZero() && !period.Since.Before(period.Until) {
+		err = errors.New("since must be before until")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	project, err := store.GetProjectByProjectId(models.Project{ProjectId: currentProjectId(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	leaderboard, err := scoring.ProjectLeaderboard(store, *project, period, requestBody.SortBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	jsondata, _ := json.Marshal(leaderboard)
+	w.Write(jsondata)
+	return nil
+}
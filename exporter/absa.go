@@ -0,0 +1,294 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"Lynx/importer"
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Formats ExportABSA can write. SemEval and JSONL files are read back by
+// importer.ParseABSA.
+const (
+	FormatSemEval = importer.FormatSemEval
+	FormatJSONL   = importer.FormatJSONL
+	FormatCSV     = "csv"
+)
+
+// AbsaOptions picks the format and the validated tasks to export.
+type AbsaOptions struct {
+	Format string
+	// AllItems exports every validated task, not only those whose latest
+	// validation is models.SentiAllMatch.
+	AllItems bool
+}
+
+// absaItem is one validated task with its aspects mapped onto its context.
+type absaItem struct {
+	articleId primitive.ObjectID
+	taskId    primitive.ObjectID
+	state     string
+	record    models.AbsaRecord
+}
+
+// AbsaExport is a sentiment project ready to be written by Write.
+type AbsaExport struct {
+	format string
+	items  []absaItem
+	// Unmapped counts the aspects whose term was not found in the context;
+	// they are written without a term.
+	Unmapped int
+}
+
+// ExportABSA collects the validated answers of a sentiment project, one
+// item per task from its latest validation. Every aspect becomes an
+// opinion per sentiment given to it, with the majorAspect as category, the
+// minorAspect as term and the sentiment as polarity. Terms are looked up
+// in the context: at their offset, or else at the occurrence nearest to it.
+func ExportABSA(store service.Store, project models.Project, opts AbsaOptions) (*AbsaExport, error) {
+	switch opts.Format {
+	case FormatSemEval, FormatJSONL, FormatCSV:
+	default:
+		return nil, fmt.Errorf("format %q must be %s, %s or %s", opts.Format, FormatSemEval, FormatJSONL, FormatCSV)
+	}
+	if project.ProjectType != importer.TypeSentiment {
+		return nil, fmt.Errorf("only %s projects can be exported as ABSA", importer.TypeSentiment)
+	}
+	answers, err := store.GetFinalAnswersByProjectId(project.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	// the latest validation of a task replaces the earlier ones, in place
+	latest := make(map[primitive.ObjectID]int)
+	var selected []models.SentiAnswer
+	for _, answer := range answers {
+		if i, ok := latest[answer.Task.TaskId]; ok {
+			selected[i] = answer
+			continue
+		}
+		latest[answer.Task.TaskId] = len(selected)
+		selected = append(selected, answer)
+	}
+
+	export := &AbsaExport{format: opts.Format}
+	for _, answer := range selected {
+		if !opts.AllItems && answer.State != models.SentiAllMatch {
+			continue
+		}
+		// older validations kept the task the client sent, or only its id
+		stored, err := store.FindSentiTaskById(answer.Task.TaskId)
+		if err != nil {
+			return nil, err
+		}
+		task := *stored
+		export.items = append(export.items, absaItem{
+			articleId: task.ArticleId,
+			taskId:    task.TaskId,
+			state:     answer.State,
+			record:    export.record(task, answer),
+		})
+	}
+	return export, nil
+}
+
+func (e *AbsaExport) record(task models.SentiTask, answer models.SentiAnswer) models.AbsaRecord {
+	record := models.AbsaRecord{
+		Article:    task.ArticleId.Hex(),
+		Text:       task.Context,
+		AspectPool: task.AspectPool,
+		Aspects:    []models.AbsaAspect{},
+	}
+	context := []rune(task.Context)
+	for _, aspect := range answer.Aspect {
+		mapped := models.AbsaAspect{Category: aspect.MajorAspect}
+		if aspect.MinorAspect != "" {
+			from, ok := locate(context, []rune(aspect.MinorAspect), aspect.Offset)
+			if ok {
+				mapped.Term = aspect.MinorAspect
+				mapped.From = from
+				mapped.To = from + len([]rune(aspect.MinorAspect))
+			} else {
+				e.Unmapped++
+			}
+		}
+		polarities := 0
+		for _, sentiment := range answer.Sentiment {
+			if sentiment.AspectId == aspect.AspectId {
+				mapped.Polarity = sentiment.Sentiment
+				record.Aspects = append(record.Aspects, mapped)
+				polarities++
+			}
+		}
+		if polarities == 0 {
+			record.Aspects = append(record.Aspects, mapped)
+		}
+	}
+	return record
+}
+
+// locate finds term in context at offset, or else at the occurrence nearest to it.
+func locate(context []rune, term []rune, offset int) (int, bool) {
+	matchesAt := func(i int) bool {
+		if i < 0 || i+len(term) > len(context) {
+			return false
+		}
+		return string(context[i:i+len(term)]) == string(term)
+	}
+	if matchesAt(offset) {
+		return offset, true
+	}
+	best := -1
+	for i := 0; i+len(term) <= len(context); i++ {
+		if matchesAt(i) && (best < 0 || abs(i-offset) < abs(best-offset)) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Items counts the exported tasks.
+func (e *AbsaExport) Items() int {
+	return len(e.items)
+}
+
+// Extension is the file extension of the export format.
+func (e *AbsaExport) Extension() string {
+	if e.format == FormatSemEval {
+		return "xml"
+	}
+	return e.format
+}
+
+// ContentType is the media type of the export format.
+func (e *AbsaExport) ContentType() string {
+	switch e.format {
+	case FormatSemEval:
+		return "application/xml"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Write writes the export in its format: SemEval 2016 XML with a Review
+// per article, JSONL of models.AbsaRecord, or CSV with a row per opinion.
+func (e *AbsaExport) Write(w io.Writer) error {
+	switch e.format {
+	case FormatSemEval:
+		return e.writeSemEval(w)
+	case FormatJSONL:
+		return e.writeJSONL(w)
+	}
+	return e.writeCSV(w)
+}
+
+// semEvalReviews is a SemEval 2016 file. models.SemEvalSentence also has the
+// 2014 elements, which the encoder would write out empty.
+type semEvalReviews struct {
+	XMLName xml.Name        `xml:"Reviews"`
+	Reviews []semEvalReview `xml:"Review"`
+}
+
+type semEvalReview struct {
+	Rid       string            `xml:"rid,attr"`
+	Sentences []semEvalSentence `xml:"sentences>sentence"`
+}
+
+type semEvalSentence struct {
+	Id       string                  `xml:"id,attr"`
+	Text     string                  `xml:"text"`
+	Opinions []models.SemEvalOpinion `xml:"Opinions>Opinion"`
+}
+
+func (e *AbsaExport) writeSemEval(w io.Writer) error {
+	var file semEvalReviews
+	reviews := make(map[primitive.ObjectID]int)
+	for _, item := range e.items {
+		i, ok := reviews[item.articleId]
+		if !ok {
+			i = len(file.Reviews)
+			reviews[item.articleId] = i
+			file.Reviews = append(file.Reviews, semEvalReview{Rid: item.articleId.Hex()})
+		}
+		sentence := semEvalSentence{Id: item.taskId.Hex(), Text: item.record.Text}
+		for _, aspect := range item.record.Aspects {
+			opinion := models.SemEvalOpinion{Target: "NULL", Category: aspect.Category, Polarity: aspect.Polarity}
+			if aspect.Term != "" {
+				opinion.Target, opinion.From, opinion.To = aspect.Term, aspect.From, aspect.To
+			}
+			sentence.Opinions = append(sentence.Opinions, opinion)
+		}
+		file.Reviews[i].Sentences = append(file.Reviews[i].Sentences, sentence)
+	}
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(file)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (e *AbsaExport) writeJSONL(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, item := range e.items {
+		err := encoder.Encode(item.record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// absaCSVHeader names the columns of writeCSV.
+var absaCSVHeader = []string{"articleId", "taskId", "state", "text", "category", "term", "from", "to", "polarity"}
+
+func (e *AbsaExport) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(absaCSVHeader)
+	if err != nil {
+		return err
+	}
+	for _, item := range e.items {
+		row := []string{item.articleId.Hex(), item.taskId.Hex(), item.state, item.record.Text}
+		if len(item.record.Aspects) == 0 {
+			err = writer.Write(append(row, "", "", "", "", ""))
+		}
+		for _, aspect := range item.record.Aspects {
+			from, to := "", ""
+			if aspect.Term != "" {
+				from, to = strconv.Itoa(aspect.From), strconv.Itoa(aspect.To)
+			}
+			err = writer.Write(append(row[:4:4], aspect.Category, aspect.Term, from, to, aspect.Polarity))
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
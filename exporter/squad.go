@@ -0,0 +1,135 @@
+// Package exporter writes the annotations of a project out as datasets.
+package exporter
+
+import (
+	"fmt"
+
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQuAD versions ExportSQuAD can write.
+const (
+	SquadV1 = "1.1"
+	SquadV2 = "v2.0"
+)
+
+// SquadOptions picks the answers to export. An answer is exported when its
+// status is in Statuses, or when an adjudicator decided on it with a
+// decisionResult in DecisionResults.
+type SquadOptions struct {
+	Version         string
+	Statuses        []string
+	DecisionResults []string
+}
+
+// DefaultSquadOptions exports verified answers as SQuAD v2.0.
+func DefaultSquadOptions() SquadOptions {
+	return SquadOptions{Version: SquadV2, Statuses: []string{"verified"}}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportSQuAD builds a SQuAD dataset from the MRC project: its articles
+// become data[], their tasks paragraphs and the selected answers qas, with
+// the answer id as qa id. Paragraphs and articles left without questions are
+// skipped. Unanswerable questions are only written in v2.0.
+func ExportSQuAD(store service.Store, project models.Project, opts SquadOptions) (*models.SquadDataset, error) {
+	switch opts.Version {
+	case SquadV1, "v1.1":
+		opts.Version = SquadV1
+	case SquadV2, "2.0":
+		opts.Version = SquadV2
+	default:
+		return nil, fmt.Errorf("SQuAD version %q must be %s or %s", opts.Version, SquadV1, SquadV2)
+	}
+	articles, err := store.GetArticlesByProjectId(project.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	articleIds := make([]string, len(articles))
+	for i, article := range articles {
+		articleIds[i] = article.ArticleId.Hex()
+	}
+	answers, err := store.GetAnswersByArticleIds(articleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	decided := make(map[primitive.ObjectID]bool)
+	if len(opts.DecisionResults) != 0 {
+		answerIds := make([]primitive.ObjectID, len(answers))
+		for i, answer := range answers {
+			answerIds[i] = answer.Id
+		}
+		decisions, err := store.GetDecisionsByOriginalIds(answerIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, decision := range decisions {
+			if contains(opts.DecisionResults, decision.DecisionResult) {
+				decided[decision.OriginalId] = true
+			}
+		}
+	}
+
+	// articleId, taskId -> selected answers
+	selected := make(map[string]map[string][]models.MRCAnswer)
+	for _, answer := range answers {
+		if !contains(opts.Statuses, answer.Status) && !decided[answer.Id] {
+			continue
+		}
+		if answer.IsImpossible && opts.Version == SquadV1 {
+			continue
+		}
+		if selected[answer.ArticleId] == nil {
+			selected[answer.ArticleId] = make(map[string][]models.MRCAnswer)
+		}
+		selected[answer.ArticleId][answer.TaskId] = append(selected[answer.ArticleId][answer.TaskId], answer)
+	}
+
+	dataset := &models.SquadDataset{Version: opts.Version, Data: []models.SquadArticle{}}
+	for _, article := range articles {
+		byTask := selected[article.ArticleId.Hex()]
+		if len(byTask) == 0 {
+			continue
+		}
+		tasks, err := store.GetTasksByArticleId(article.ArticleId.Hex())
+		if err != nil {
+			return nil, err
+		}
+		squadArticle := models.SquadArticle{Title: article.ArticleTitle}
+		for _, task := range tasks {
+			if len(byTask[task.TaskId]) == 0 {
+				continue
+			}
+			paragraph := models.SquadParagraph{Context: task.Context}
+			for _, answer := range byTask[task.TaskId] {
+				qa := models.SquadQA{
+					Id:           answer.Id.Hex(),
+					Question:     answer.Question,
+					Answers:      []models.SquadAnswer{},
+					IsImpossible: answer.IsImpossible,
+				}
+				if !answer.IsImpossible {
+					qa.Answers = append(qa.Answers, models.SquadAnswer{Text: answer.Answer, AnswerStart: answer.StartIdx})
+				}
+				paragraph.QAs = append(paragraph.QAs, qa)
+			}
+			squadArticle.Paragraphs = append(squadArticle.Paragraphs, paragraph)
+		}
+		if len(squadArticle.Paragraphs) != 0 {
+			dataset.Data = append(dataset.Data, squadArticle)
+		}
+	}
+	return dataset, nil
+}
@@ -1,6 +1,6 @@
 module Lynx
 
-go 1.15
+go 1.16
 
 require (
 	github.com/mitchellh/mapstructure v1.4.1
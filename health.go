@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"Lynx/version"
+)
+
+// readyzTimeout bounds the database ping behind /readyz.
+const readyzTimeout = 2 * time.Second
+
+type probeStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func writeProbe(w http.ResponseWriter, code int, status probeStatus) {
+	w.WriteHeader(code)
+	jsondata, _ := json.Marshal(status)
+	w.Write(jsondata)
+}
+
+// healthz answers as long as the process can serve HTTP at all.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, probeStatus{Status: "ok"})
+}
+
+// readyz answers 200 only once the store is connected, the server is not
+// shutting down and the database still answers a ping on the primary.
+func (p *RouteMux) readyz(w http.ResponseWriter, r *http.Request) {
+	if !p.isReady() {
+		writeProbe(w, http.StatusServiceUnavailable, probeStatus{Status: "not ready"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readyzTimeout)
+	defer cancel()
+	err := Store.Ping(ctx)
+	if err != nil {
+		writeProbe(w, http.StatusServiceUnavailable, probeStatus{Status: "not ready", Error: err.Error()})
+		return
+	}
+	writeProbe(w, http.StatusOK, probeStatus{Status: "ready"})
+}
+
+func versionInfo(w http.ResponseWriter, r *http.Request) {
+	jsondata, _ := json.Marshal(version.Info())
+	w.Write(jsondata)
+}
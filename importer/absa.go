@@ -0,0 +1,319 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"Lynx/models"
+	"Lynx/service"
+)
+
+// Formats of aspect based sentiment (ABSA) datasets.
+const (
+	FormatSemEval = "semeval"
+	FormatJSONL   = "jsonl"
+)
+
+// Polarities a preloaded sentiment can have, those of SemEval.
+var Polarities = []string{"positive", "negative", "neutral", "conflict"}
+
+// semEvalSentencesPerArticle groups the sentences of SemEval 2014 files,
+// which have no reviews, into articles of this size.
+const semEvalSentencesPerArticle = 20
+
+// maxJSONLLine bounds one record of a JSONL file.
+const maxJSONLLine = 1 << 20
+
+// AbsaOptions tells ParseABSA how to read a dataset.
+type AbsaOptions struct {
+	// Format is FormatSemEval or FormatJSONL, guessed from the first byte when empty.
+	Format string
+	// AspectPool replaces the categories found in the file as the aspect
+	// pool of every task.
+	AspectPool []string
+	// WithSeeds preloads the aspects and polarities of the file as answers
+	// by SeedUserId, so the tasks go straight to validation.
+	WithSeeds bool
+}
+
+// absaSentence is one sentence of either format, before it becomes a task.
+type absaSentence struct {
+	row     int
+	path    string
+	article string
+	title   string
+	text    string
+	pool    []string
+	aspects []models.AbsaAspect
+}
+
+// ParseABSA converts a SemEval 2014 or 2016 XML file, or a JSONL file of
+// models.AbsaRecord, into the content of a sentiment project. Every sentence
+// becomes a task:
+//
+//	SemEval 2016: every Review is an article, sentences marked OutOfScope are skipped
+//	SemEval 2014: every semEvalSentencesPerArticle sentences form an article
+//	JSONL:        records with the same "article" form one article, titled "title"
+//
+// The aspect pool of a task is opts.AspectPool, or the pool of its JSONL
+// record, or else every category of the file. With opts.WithSeeds, every
+// aspect becomes a SentiAspect with the category as majorAspect, the target
+// term as minorAspect and the term offset, and a SentiSentiment with the
+// polarity; aspects the text does not name have offset -1. SemEval 2014
+// aspect terms have no category. Seeds whose category is not in the pool,
+// whose polarity is unknown or whose term is not found at its offset are
+// reported as RowErrors, and nothing is imported then.
+func ParseABSA(project models.Project, r io.Reader, opts AbsaOptions) (*service.ProjectContent, error) {
+	if project.ProjectType != TypeSentiment {
+		return nil, fmt.Errorf("ABSA files can only be imported into %s projects", TypeSentiment)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	format := opts.Format
+	if format == "" {
+		format = guessAbsaFormat(data)
+	}
+	var sentences []absaSentence
+	switch format {
+	case FormatSemEval:
+		sentences, err = readSemEval(data)
+	case FormatJSONL:
+		sentences, err = readAbsaJSONL(data)
+	default:
+		return nil, fmt.Errorf("format %q must be %s or %s", format, FormatSemEval, FormatJSONL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sentences) == 0 {
+		return nil, RowErrors{{Row: 1, Message: "the file has no sentences"}}
+	}
+
+	filePool := cleanAspectPool(opts.AspectPool)
+	if len(filePool) == 0 {
+		filePool = categoriesOf(sentences)
+	}
+	b := newBuilder(project)
+	var rowErrs RowErrors
+	fail := func(s absaSentence, format string, args ...interface{}) {
+		rowErrs = append(rowErrs, RowError{Row: s.row, Path: s.path, Message: fmt.Sprintf(format, args...)})
+	}
+	articles := map[string]int{}
+	for _, s := range sentences {
+		if strings.TrimSpace(s.text) == "" {
+			fail(s, "text is empty")
+			continue
+		}
+		pool := filePool
+		if len(opts.AspectPool) == 0 && len(s.pool) != 0 {
+			pool = cleanAspectPool(s.pool)
+		}
+		if len(pool) == 0 {
+			fail(s, "no aspect pool: the file has no categories, send aspectPool")
+			continue
+		}
+		articleIdx, ok := articles[s.article]
+		if !ok {
+			title := s.title
+			if title == "" {
+				title = s.text
+			}
+			articleIdx = b.addArticle(title)
+			articles[s.article] = articleIdx
+		}
+		taskIdx := b.addTask(articleIdx, "", s.text, pool)
+		if !opts.WithSeeds {
+			continue
+		}
+		text := []rune(s.text)
+		for i, aspect := range s.aspects {
+			msg := checkAbsaAspect(aspect, text, pool)
+			if msg != "" {
+				fail(s, "aspect %d: %s", i+1, msg)
+				continue
+			}
+			b.addSentiSeed(taskIdx, aspect)
+		}
+		if len(rowErrs) >= maxRowErrors {
+			return nil, rowErrs
+		}
+	}
+	if len(rowErrs) != 0 {
+		return nil, rowErrs
+	}
+	b.markSentiAnswered()
+	return &b.content, nil
+}
+
+// guessAbsaFormat tells XML from JSONL by their first byte.
+func guessAbsaFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return FormatSemEval
+	}
+	return FormatJSONL
+}
+
+// semEvalFile reads both SemEval layouts: a <Reviews> root in 2016 and a
+// <sentences> root in 2014.
+type semEvalFile struct {
+	Reviews   []models.SemEvalReview   `xml:"Review"`
+	Sentences []models.SemEvalSentence `xml:"sentence"`
+}
+
+func readSemEval(data []byte) ([]absaSentence, error) {
+	var file semEvalFile
+	err := xml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("not a SemEval file: %v", err)
+	}
+	var sentences []absaSentence
+	for ri, review := range file.Reviews {
+		for si, sentence := range review.Sentences {
+			if strings.EqualFold(sentence.OutOfScope, "TRUE") {
+				continue
+			}
+			s := semEvalSentence(sentence, fmt.Sprintf("Review[%d].sentences[%d]", ri, si))
+			s.article = "review " + review.Rid
+			if review.Rid == "" {
+				s.article = fmt.Sprintf("review #%d", ri)
+			}
+			sentences = append(sentences, s)
+		}
+	}
+	for si, sentence := range file.Sentences {
+		s := semEvalSentence(sentence, fmt.Sprintf("sentences[%d]", si))
+		s.article = fmt.Sprintf("part %d", si/semEvalSentencesPerArticle)
+		sentences = append(sentences, s)
+	}
+	return sentences, nil
+}
+
+func semEvalSentence(sentence models.SemEvalSentence, path string) absaSentence {
+	s := absaSentence{path: path, text: sentence.Text}
+	for _, opinion := range sentence.Opinions {
+		aspect := models.AbsaAspect{Category: opinion.Category, Polarity: opinion.Polarity}
+		if opinion.Target != "" && opinion.Target != "NULL" {
+			aspect.Term, aspect.From, aspect.To = opinion.Target, opinion.From, opinion.To
+		}
+		s.aspects = append(s.aspects, aspect)
+	}
+	for _, term := range sentence.AspectTerms {
+		s.aspects = append(s.aspects, models.AbsaAspect{Term: term.Term, From: term.From, To: term.To, Polarity: term.Polarity})
+	}
+	for _, category := range sentence.AspectCategories {
+		s.aspects = append(s.aspects, models.AbsaAspect{Category: category.Category, Polarity: category.Polarity})
+	}
+	return s
+}
+
+func readAbsaJSONL(data []byte) ([]absaSentence, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLine)
+	var sentences []absaSentence
+	var rowErrs RowErrors
+	row := 0
+	for scanner.Scan() {
+		row++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record models.AbsaRecord
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: row, Message: err.Error()})
+			if len(rowErrs) >= maxRowErrors {
+				return nil, rowErrs
+			}
+			continue
+		}
+		// records without an article are articles of their own
+		article := "article " + record.Article
+		if record.Article == "" {
+			article = fmt.Sprintf("line %d", row)
+		}
+		sentences = append(sentences, absaSentence{
+			row:     row,
+			article: article,
+			title:   record.Title,
+			text:    record.Text,
+			pool:    record.AspectPool,
+			aspects: record.Aspects,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %v", row+1, err)
+	}
+	if len(rowErrs) != 0 {
+		return nil, rowErrs
+	}
+	return sentences, nil
+}
+
+// checkAbsaAspect returns what is wrong with a preloaded aspect of text, or "".
+func checkAbsaAspect(aspect models.AbsaAspect, text []rune, pool []string) string {
+	if aspect.Category == "" && aspect.Term == "" {
+		return "needs a category or a term"
+	}
+	if aspect.Category != "" && !containsString(pool, aspect.Category) {
+		return fmt.Sprintf("category %q is not in the aspect pool", aspect.Category)
+	}
+	if !containsString(Polarities, aspect.Polarity) {
+		return fmt.Sprintf("polarity %q must be one of %s", aspect.Polarity, strings.Join(Polarities, ", "))
+	}
+	if aspect.Term == "" {
+		return ""
+	}
+	if aspect.From < 0 || aspect.To > len(text) || aspect.From >= aspect.To || string(text[aspect.From:aspect.To]) != aspect.Term {
+		return fmt.Sprintf("%q is not found at %d-%d of the text", aspect.Term, aspect.From, aspect.To)
+	}
+	return ""
+}
+
+// categoriesOf returns the sorted categories of every sentence.
+func categoriesOf(sentences []absaSentence) []string {
+	var categories []string
+	for _, s := range sentences {
+		for _, aspect := range s.aspects {
+			if aspect.Category != "" {
+				categories = append(categories, aspect.Category)
+			}
+		}
+	}
+	return cleanAspectPool(categories)
+}
+
+// cleanAspectPool trims, deduplicates and sorts a pool.
+func cleanAspectPool(pool []string) []string {
+	seen := map[string]bool{}
+	var cleaned []string
+	for _, aspect := range pool {
+		aspect = strings.TrimSpace(aspect)
+		if aspect == "" || seen[aspect] {
+			continue
+		}
+		seen[aspect] = true
+		cleaned = append(cleaned, aspect)
+	}
+	sort.Strings(cleaned)
+	return cleaned
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
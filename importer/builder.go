@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"strconv"
+
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// builder collects the articles and tasks of one project, whatever the
+// upload format. Ids are generated here so tasks can point at their article
+// before anything is written.
+type builder struct {
+	project models.Project
+	content service.ProjectContent
+}
+
+func newBuilder(project models.Project) *builder {
+	return &builder{project: project}
+}
+
+// addArticle returns the index of the new article, used by addTask.
+func (b *builder) addArticle(title string) int {
+	if b.project.ProjectType == TypeSentiment {
+		b.content.SentiArticles = append(b.content.SentiArticles, models.SentiArticle{
+			ArticleId:    primitive.NewObjectID(),
+			ProjectId:    b.project.ProjectId,
+			TaskType:     TypeSentiment,
+			ArticleTitle: title,
+		})
+		return len(b.content.SentiArticles) - 1
+	}
+	b.content.Articles = append(b.content.Articles, models.Article{
+		ArticleId:    primitive.NewObjectID(),
+		ProjectId:    b.project.ProjectId,
+		ArticleTitle: title,
+	})
+	return len(b.content.Articles) - 1
+}
+
+// addTask appends a task to an article from addArticle and returns its
+// index, used by addAnswer. aspects only apply to sentiment projects.
+func (b *builder) addTask(article int, taskId string, context string, aspects []string) int {
+	if b.project.ProjectType == TypeSentiment {
+		a := &b.content.SentiArticles[article]
+		a.TotalTasks++
+		b.content.SentiTasks = append(b.content.SentiTasks, models.SentiTask{
+			ArticleId:  a.ArticleId,
+			TaskId:     primitive.NewObjectID(),
+			AspectPool: aspects,
+			TaskTitle:  context,
+			Context:    context,
+			TaskType:   TypeSentiment,
+			ProjectId:  b.project.ProjectId,
+		})
+		return len(b.content.SentiTasks) - 1
+	}
+	a := &b.content.Articles[article]
+	a.TotalTasks++
+	b.content.MRCTasks = append(b.content.MRCTasks, models.MRCTask{
+		ProjectId: b.project.ProjectId,
+		TaskId:    taskId,
+		ArticleId: a.ArticleId.Hex(),
+		TaskType:  TypeMRC,
+		TaskTitle: context,
+		Context:   context,
+	})
+	return len(b.content.MRCTasks) - 1
+}
+
+// addAnswer preloads an answer to an MRC task from addTask, written by
+// SeedUserId, who counts as one of its annotators.
+func (b *builder) addAnswer(task int, question string, answer string, startIdx int, impossible bool, status string) {
+	t := &b.content.MRCTasks[task]
+	if len(t.Annotators) == 0 {
+		t.Annotators = []string{SeedUserId}
+		t.Answered = 1
+		if t.Answered == b.project.RequiredAnnotators() {
+			b.articleOf(t).Answered++
+		}
+	}
+	b.content.MRCAnswers = append(b.content.MRCAnswers, models.MRCAnswer{
+		Id:           primitive.NewObjectID(),
+		ProjectId:    b.project.ProjectId,
+		UserId:       SeedUserId,
+		ArticleId:    t.ArticleId,
+		TaskId:       t.TaskId,
+		TaskType:     TypeMRC,
+		Status:       status,
+		Question:     question,
+		Answer:       answer,
+		StartIdx:     startIdx,
+		IsImpossible: impossible,
+	})
+}
+
+// addSentiSeed preloads an aspect and its polarity on a sentiment task from
+// addTask, written by SeedUserId, who counts as one of its annotators.
+// Aspects without a term get offset -1.
+func (b *builder) addSentiSeed(task int, aspect models.AbsaAspect) {
+	t := &b.content.SentiTasks[task]
+	t.Annotators = []string{SeedUserId}
+	t.IsAnswered = len(t.Annotators) >= b.project.RequiredAnnotators()
+	offset := -1
+	if aspect.Term != "" {
+		offset = aspect.From
+	}
+	aspectId := strconv.Itoa(b.seedsOf(t.TaskId) + 1)
+	b.content.SentiAspects = append(b.content.SentiAspects, models.SentiAspect{
+		TaskId:      t.TaskId,
+		AspectId:    aspectId,
+		MajorAspect: aspect.Category,
+		MinorAspect: aspect.Term,
+		Offset:      offset,
+		UserId:      SeedUserId,
+	})
+	b.content.SentiSentiments = append(b.content.SentiSentiments, models.SentiSentiment{
+		TaskId:    t.TaskId,
+		AspectId:  aspectId,
+		Offset:    offset,
+		Sentiment: aspect.Polarity,
+		UserId:    SeedUserId,
+	})
+}
+
+// articleOf finds the article of an MRC task, searching from the latest
+// article, which tasks are usually added to.
+func (b *builder) articleOf(t *models.MRCTask) *models.Article {
+	for i := len(b.content.Articles) - 1; i >= 0; i-- {
+		if b.content.Articles[i].ArticleId.Hex() == t.ArticleId {
+			return &b.content.Articles[i]
+		}
+	}
+	return nil
+}
+
+// seedsOf counts the aspects preloaded on a task so far. Seeds are added
+// task by task, so only the tail of the list needs a look.
+func (b *builder) seedsOf(taskId primitive.ObjectID) int {
+	n := 0
+	for i := len(b.content.SentiAspects) - 1; i >= 0 && b.content.SentiAspects[i].TaskId == taskId; i-- {
+		n++
+	}
+	return n
+}
+
+// markSentiAnswered marks the sentiment articles whose tasks are all answered.
+func (b *builder) markSentiAnswered() {
+	answered := map[primitive.ObjectID]int{}
+	for _, t := range b.content.SentiTasks {
+		if t.IsAnswered {
+			answered[t.ArticleId]++
+		}
+	}
+	for i := range b.content.SentiArticles {
+		a := &b.content.SentiArticles[i]
+		a.IsAnswered = a.TotalTasks > 0 && answered[a.ArticleId] == a.TotalTasks
+	}
+}
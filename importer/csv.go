@@ -0,0 +1,146 @@
+// Package importer turns uploaded datasets into the articles and tasks of a project.
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"Lynx/models"
+	"Lynx/service"
+)
+
+// Project types the importers know how to fill.
+const (
+	TypeMRC       = "MRC"
+	TypeSentiment = "Sentiment"
+)
+
+// RowError points at one bad row of an upload, counted from 1, or at the
+// JSON path of a bad entry.
+type RowError struct {
+	Row     int    `json:"row,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// RowErrors is every bad row of an upload; nothing is imported when it is returned.
+type RowErrors []RowError
+
+func (e RowErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors in the upload", len(e))
+	for i, rowErr := range e {
+		if i == 3 {
+			b.WriteString("; ...")
+			break
+		}
+		if rowErr.Path != "" {
+			fmt.Fprintf(&b, "; %s: %s", rowErr.Path, rowErr.Message)
+		} else {
+			fmt.Fprintf(&b, "; row %d: %s", rowErr.Row, rowErr.Message)
+		}
+	}
+	return b.String()
+}
+
+// aspectSeparator splits the aspect pool column of sentiment rows.
+const aspectSeparator = "|"
+
+// ReadCSV reads an uploaded CSV file. Rows may have different lengths, ParseCSV checks them.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	// spreadsheet programs like to start UTF-8 files with a byte order mark
+	if len(rows) != 0 && len(rows[0]) != 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, err
+}
+
+// ParseCSV converts CSV rows into the content of project. Every row is one task:
+//
+//	MRC:       context,<article>-<task>
+//	Sentiment: context,<article>-<task>,aspect|aspect|...
+//
+// Rows sharing the same <article> number form one article, titled with the
+// context of its first row. All bad rows are reported together as RowErrors.
+func ParseCSV(project models.Project, rows [][]string) (*service.ProjectContent, error) {
+	columns := 2
+	if project.ProjectType == TypeSentiment {
+		columns = 3
+	} else if project.ProjectType != TypeMRC {
+		return nil, fmt.Errorf("project type %q must be %s or %s", project.ProjectType, TypeMRC, TypeSentiment)
+	}
+	if len(rows) == 0 {
+		return nil, RowErrors{{Row: 1, Message: "the file has no rows"}}
+	}
+
+	b := newBuilder(project)
+	var rowErrs RowErrors
+	articleIndex := make(map[string]int) // <article> number -> builder article
+	seen := make(map[string]int)         // index column -> first row using it
+	for i, row := range rows {
+		rowNum := i + 1
+		if len(row) != columns {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Message: fmt.Sprintf("expected %d columns, got %d", columns, len(row))})
+			continue
+		}
+		context := strings.TrimSpace(row[0])
+		index := strings.TrimSpace(row[1])
+		if context == "" {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Message: "context is empty"})
+		}
+		articleNum, err := parseIndex(index)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Message: err.Error()})
+		} else if first, ok := seen[index]; ok {
+			rowErrs = append(rowErrs, RowError{Row: rowNum, Message: fmt.Sprintf("index %s is already used by row %d", index, first)})
+		} else {
+			seen[index] = rowNum
+		}
+		var aspects []string
+		if project.ProjectType == TypeSentiment {
+			for _, aspect := range strings.Split(row[2], aspectSeparator) {
+				if aspect = strings.TrimSpace(aspect); aspect != "" {
+					aspects = append(aspects, aspect)
+				}
+			}
+			if len(aspects) == 0 {
+				rowErrs = append(rowErrs, RowError{Row: rowNum, Message: "aspect pool is empty"})
+			}
+		}
+		if len(rowErrs) != 0 {
+			// keep checking the remaining rows, but build nothing
+			continue
+		}
+
+		article, ok := articleIndex[articleNum]
+		if !ok {
+			article = b.addArticle(context)
+			articleIndex[articleNum] = article
+		}
+		b.addTask(article, index, context, aspects)
+	}
+	if len(rowErrs) != 0 {
+		return nil, rowErrs
+	}
+	return &b.content, nil
+}
+
+// parseIndex checks an <article>-<task> index and returns its article part.
+func parseIndex(index string) (string, error) {
+	parts := strings.Split(index, "-")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("index %q must look like <article>-<task>, e.g. 1-2", index)
+	}
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			return "", fmt.Errorf("index %q must look like <article>-<task>, e.g. 1-2", index)
+		}
+	}
+	return parts[0], nil
+}
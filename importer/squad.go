@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"Lynx/models"
+	"Lynx/service"
+)
+
+// SeedUserId authors the answers preloaded from a dataset. It matches no
+// real user, so every validator gets to check them.
+const SeedUserId = "dataset-import"
+
+// Statuses a preloaded answer can start in: unverified answers go through
+// validation like any other, verified ones are trusted as they are.
+const (
+	StatusUnverified = "unverified"
+	StatusVerified   = "verified"
+)
+
+// maxRowErrors stops collecting errors of files that are clearly in another format.
+const maxRowErrors = 100
+
+// ParseSQuAD converts a SQuAD v1.1 or v2.0 file into the content of an MRC
+// project: every data[] entry becomes an article, every paragraph a task
+// with id <article>-<paragraph>, and every question an answer by SeedUserId
+// in answerStatus. Only the first answer of a question is kept. Answers
+// whose text is not found at answer_start in the context are reported as
+// RowErrors with their JSON path, and nothing is imported then.
+func ParseSQuAD(project models.Project, r io.Reader, answerStatus string) (*service.ProjectContent, error) {
+	if project.ProjectType != TypeMRC {
+		return nil, fmt.Errorf("SQuAD files can only be imported into %s projects", TypeMRC)
+	}
+	if answerStatus == "" {
+		answerStatus = StatusUnverified
+	}
+	if answerStatus != StatusUnverified && answerStatus != StatusVerified {
+		return nil, fmt.Errorf("answer status %q must be %s or %s", answerStatus, StatusUnverified, StatusVerified)
+	}
+	var dataset models.SquadDataset
+	err := json.NewDecoder(r).Decode(&dataset)
+	if err != nil {
+		return nil, fmt.Errorf("not a SQuAD file: %v", err)
+	}
+	if len(dataset.Data) == 0 {
+		return nil, RowErrors{{Path: "data", Message: "the file has no articles"}}
+	}
+
+	b := newBuilder(project)
+	var rowErrs RowErrors
+	fail := func(path string, format string, args ...interface{}) {
+		rowErrs = append(rowErrs, RowError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	for ai, article := range dataset.Data {
+		title := strings.TrimSpace(article.Title)
+		if title == "" {
+			title = fmt.Sprintf("Article %d", ai+1)
+		}
+		articleIdx := b.addArticle(title)
+		for pi, paragraph := range article.Paragraphs {
+			path := fmt.Sprintf("data[%d].paragraphs[%d]", ai, pi)
+			if strings.TrimSpace(paragraph.Context) == "" {
+				fail(path, "context is empty")
+				continue
+			}
+			taskIdx := b.addTask(articleIdx, fmt.Sprintf("%d-%d", ai+1, pi+1), paragraph.Context, nil)
+			context := []rune(paragraph.Context)
+			for qi, qa := range paragraph.QAs {
+				qaPath := fmt.Sprintf("%s.qas[%d]", path, qi)
+				if strings.TrimSpace(qa.Question) == "" {
+					fail(qaPath, "question is empty")
+					continue
+				}
+				if qa.IsImpossible {
+					b.addAnswer(taskIdx, qa.Question, "", -1, true, answerStatus)
+					continue
+				}
+				if len(qa.Answers) == 0 {
+					fail(qaPath, "answerable question without answers")
+					continue
+				}
+				answer := qa.Answers[0]
+				text := []rune(answer.Text)
+				end := answer.AnswerStart + len(text)
+				if len(text) == 0 || answer.AnswerStart < 0 || end > len(context) || string(context[answer.AnswerStart:end]) != answer.Text {
+					fail(qaPath+".answers[0]", "%q is not found at answer_start %d of the context", answer.Text, answer.AnswerStart)
+					continue
+				}
+				b.addAnswer(taskIdx, qa.Question, answer.Text, answer.AnswerStart, false, answerStatus)
+			}
+			if len(rowErrs) >= maxRowErrors {
+				return nil, rowErrs
+			}
+		}
+		if len(article.Paragraphs) == 0 {
+			fail(fmt.Sprintf("data[%d]", ai), "article has no paragraphs")
+		}
+	}
+	if len(rowErrs) != 0 {
+		return nil, rowErrs
+	}
+	return &b.content, nil
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level orders log messages by severity.
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel accepts one of debug, info, warn or error.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level %q, want one of %s", s, strings.Join(levelNames, ", "))
+}
+
+var current = Info
+
+// SetLevel drops every message below l.
+func SetLevel(l Level) {
+	current = l
+}
+
+func output(l Level, format string, v ...interface{}) {
+	if l < current {
+		return
+	}
+	log.Output(3, "["+strings.ToUpper(l.String())+"] "+fmt.Sprintf(format, v...))
+}
+
+func Debugf(format string, v ...interface{}) { output(Debug, format, v...) }
+func Infof(format string, v ...interface{})  { output(Info, format, v...) }
+func Warnf(format string, v ...interface{})  { output(Warn, format, v...) }
+func Errorf(format string, v ...interface{}) { output(Error, format, v...) }
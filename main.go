@@ -1,24 +1,56 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"net/http"
+	"os"
+	"time"
 
-	"Lynx/db"
-
-	"github.com/rs/cors"
-	"go.mongodb.org/mongo-driver/mongo"
+	"Lynx/auth"
+	"Lynx/config"
+	"Lynx/logging"
+	"Lynx/service"
 )
 
 var (
-	client   = db.GetDBCli()
-	Database *mongo.Database
+	configFile = flag.String("config", os.Getenv("LYNX_CONFIG"), "JSON config file, LYNX_* environment variables override it")
+	storeType  = flag.String("store", "", "storage backend, mongo or memory (overrides the config)")
+	seedFile   = flag.String("seed", "", "JSON file to preload the memory store with (overrides the config)")
+	Store      service.Store
+	Identities auth.IdentityProvider
+	Tokens     *auth.TokenIssuer
+	// ClaimTTL is the lease of the validation and decision items handed out.
+	ClaimTTL time.Duration
 )
 
+// loadConfig reads the config, applies the flags over it and validates
+// the result, so that e.g. -store memory needs no database settings.
+func loadConfig() *config.Config {
+	cfg, err := config.Read(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *storeType != "" {
+		cfg.Store = *storeType
+	}
+	if *seedFile != "" {
+		cfg.SeedFile = *seedFile
+	}
+	err = cfg.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.SetLevel(level)
+	return cfg
+}
+
 func main() {
-	Database = client.Database("label-lab")
-	mux := &RouteMux{}
-	log.Println("Server Launched on port 9090")
-	handler := cors.Default().Handler(mux)
-	http.ListenAndServe(":9090", handler)
+	flag.Parse()
+	cfg := loadConfig()
+	err := run(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Infof("Server stopped")
 }
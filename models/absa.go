@@ -0,0 +1,63 @@
+package models
+
+// SemEvalReview is a review of a SemEval 2016 ABSA file, under a <Reviews>
+// root. SemEval 2014 files have a <sentences> root instead, with
+// aspectTerms and aspectCategories.
+type SemEvalReview struct {
+	Rid       string            `xml:"rid,attr"`
+	Sentences []SemEvalSentence `xml:"sentences>sentence"`
+}
+
+type SemEvalSentence struct {
+	Id               string                  `xml:"id,attr"`
+	OutOfScope       string                  `xml:"OutOfScope,attr,omitempty"`
+	Text             string                  `xml:"text"`
+	Opinions         []SemEvalOpinion        `xml:"Opinions>Opinion,omitempty"`
+	AspectTerms      []SemEvalAspectTerm     `xml:"aspectTerms>aspectTerm,omitempty"`
+	AspectCategories []SemEvalAspectCategory `xml:"aspectCategories>aspectCategory,omitempty"`
+}
+
+// SemEvalOpinion is a 2016 opinion. Target is "NULL" when the text does
+// not name it, From and To are character offsets into the sentence.
+type SemEvalOpinion struct {
+	Target   string `xml:"target,attr"`
+	Category string `xml:"category,attr"`
+	Polarity string `xml:"polarity,attr"`
+	From     int    `xml:"from,attr"`
+	To       int    `xml:"to,attr"`
+}
+
+// SemEvalAspectTerm is a 2014 aspect term.
+type SemEvalAspectTerm struct {
+	Term     string `xml:"term,attr"`
+	Polarity string `xml:"polarity,attr"`
+	From     int    `xml:"from,attr"`
+	To       int    `xml:"to,attr"`
+}
+
+// SemEvalAspectCategory is a 2014 aspect category.
+type SemEvalAspectCategory struct {
+	Category string `xml:"category,attr"`
+	Polarity string `xml:"polarity,attr"`
+}
+
+// AbsaRecord is one line of the ABSA JSONL format, one sentiment task.
+// Records with the same Article form one article.
+type AbsaRecord struct {
+	Article    string       `json:"article"`
+	Title      string       `json:"title,omitempty"`
+	Text       string       `json:"text"`
+	AspectPool []string     `json:"aspectPool,omitempty"`
+	Aspects    []AbsaAspect `json:"aspects,omitempty"`
+}
+
+// AbsaAspect is an aspect with its polarity. Term is empty when the text
+// does not name the aspect, otherwise it is the text between the character
+// offsets From and To.
+type AbsaAspect struct {
+	Category string `json:"category"`
+	Term     string `json:"term,omitempty"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	Polarity string `json:"polarity"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -12,6 +14,10 @@ type Article struct {
 	ArticleTitle string             `bson:"articleTitle" json:"articleTitle"`
 	TotalTasks   int                `bson:"totalTasks" json:"totalTasks"`
 	Answered     int                `bson:"answered" json:"answered"`
+	// IsAnswered is derived by the store whenever a task of the article
+	// changes: every task has enough annotators. AnsweredAt is since when.
+	IsAnswered bool       `bson:"isAnswered" json:"isAnswered"`
+	AnsweredAt *time.Time `bson:"answeredAt,omitempty" json:"answeredAt,omitempty"`
 }
 
 //TableName return name of database table
@@ -40,8 +46,13 @@ type SentiArticle struct {
 	TaskType     string             `bson:"taskType" json:"taskType"`
 	ArticleTitle string             `bson:"articleTitle" json:"articleTitle"`
 	TotalTasks   int                `bson:"totalTasks" json:"totalTasks"`
-	IsAnswered   bool               `bson:"isAnswered" json:"isAnswered"`
-	IsValidated  bool               `bson:"isValidated " json:"isValidated"`
+	// IsAnswered and IsValidated are derived by the store whenever a task
+	// of the article changes: every task, gold ones left out, is answered
+	// or validated. AnsweredAt and ValidatedAt are since when.
+	IsAnswered  bool       `bson:"isAnswered" json:"isAnswered"`
+	IsValidated bool       `bson:"isValidated" json:"isValidated"`
+	AnsweredAt  *time.Time `bson:"answeredAt,omitempty" json:"answeredAt,omitempty"`
+	ValidatedAt *time.Time `bson:"validatedAt,omitempty" json:"validatedAt,omitempty"`
 }
 
 //TableName return name of database table
@@ -2,14 +2,29 @@ package models
 
 import (
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Roles a user can hold in a project, one Auth document per role.
+const (
+	RoleOwner       = "owner"
+	RoleManager     = "manager"
+	RoleAnnotator   = "annotator"
+	RoleValidator   = "validator"
+	RoleAdjudicator = "adjudicator"
+)
+
+var Roles = []string{RoleOwner, RoleManager, RoleAnnotator, RoleValidator, RoleAdjudicator}
+
 //User structure
 type Auth struct {
-	ProjectId  int    `bson:"projectId" json:"projectId"`
-	UserId     string `bson:"userId" json:"userId"`
-	CodeType   string `bson:"codeType" json:"codeType"`
-	StatusCode string `bson:"statusCode" json:"statusCode"`
+	ProjectId  primitive.ObjectID `bson:"projectId" json:"projectId"`
+	UserId     string             `bson:"userId" json:"userId"`
+	Role       string             `bson:"role" json:"role"`
+	CodeType   string             `bson:"codeType" json:"codeType"`
+	StatusCode string             `bson:"statusCode" json:"statusCode"`
+	// Paused annotator roles only allow reading, see GoldSettings.
+	Paused bool `bson:"paused,omitempty" json:"paused,omitempty"`
 }
 
 type Auths []Auth
@@ -34,8 +49,29 @@ func (u *Auth) ToQueryBson() bson.M {
 	return queryObject
 }
 
-func (a Auths) SelectProjectIdList() []int {
-	var list []int
+// EffectiveRole maps documents written before roles existed: the "max auth"
+// StatusCode "0" becomes owner, anything else annotator.
+func (u *Auth) EffectiveRole() string {
+	if u.Role != "" {
+		return u.Role
+	}
+	if u.StatusCode == "0" {
+		return RoleOwner
+	}
+	return RoleAnnotator
+}
+
+func IsRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Auths) SelectProjectIdList() []primitive.ObjectID {
+	var list []primitive.ObjectID
 	for _, user := range a {
 		list = append(list, user.ProjectId)
 	}
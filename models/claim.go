@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Queues whose items are claimed by one user at a time. ItemId of a Claim
+// is an MRCAnswer id for ClaimMRCValidation, a SentiTask id for
+// ClaimSentiValidation and an MRCValidation id for ClaimMRCDecision.
+const (
+	ClaimMRCValidation   = "MRCValidation"
+	ClaimSentiValidation = "SentiValidation"
+	ClaimMRCDecision     = "MRCDecision"
+)
+
+// ClaimKinds lists every queue.
+var ClaimKinds = []string{ClaimMRCValidation, ClaimSentiValidation, ClaimMRCDecision}
+
+// Claim reserves a queue item for a user until ExpiresAt. There is at most
+// one claim per Kind and ItemId; an expired one may be taken over by anyone.
+type Claim struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectId primitive.ObjectID `bson:"projectId" json:"projectId"`
+	Kind      string             `bson:"kind" json:"kind"`
+	ItemId    primitive.ObjectID `bson:"itemId" json:"itemId"`
+	UserId    string             `bson:"userId" json:"userId"`
+	ClaimedAt time.Time          `bson:"claimedAt" json:"claimedAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
+
+func (c *Claim) TableName() string {
+	return "Claim"
+}
+
+// Live tells whether the claim still holds at now.
+func (c *Claim) Live(now time.Time) bool {
+	return now.Before(c.ExpiresAt)
+}
@@ -1,17 +1,31 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-//User structure
+// AnswerStatusGold is the status of MRC answers to gold questions, which
+// are scored against their label instead of validated.
+const AnswerStatusGold = "gold"
+
+// Label is the known answer of a gold task, which annotators are scored
+// against. An MRC label is a question on the task ArticleId/TaskId with its
+// TrueAnswer at StartIdx; a sentiment label holds the polarity of every
+// aspect category of the SentiTask whose hex id is TaskId.
 type Label struct {
-	TaskId     string
-	TaskType   string
-	ImagePath  string
-	TrueAnswer string
-	Example    bool
-	LabelId    string
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectId primitive.ObjectID `bson:"projectId" json:"projectId"`
+	TaskType  string             `bson:"taskType" json:"taskType"`
+	ArticleId string             `bson:"articleId,omitempty" json:"articleId,omitempty"`
+	TaskId    string             `bson:"taskId" json:"taskId"`
+	Question  string             `bson:"question,omitempty" json:"question,omitempty"`
+	// TrueAnswer is empty for questions the context does not answer.
+	TrueAnswer string          `bson:"trueAnswer,omitempty" json:"trueAnswer,omitempty"`
+	StartIdx   int             `bson:"startIdx" json:"startIdx"`
+	Sentiments []GoldSentiment `bson:"sentiments,omitempty" json:"sentiments,omitempty"`
 }
 
 //TableName return name of database table
@@ -19,9 +33,57 @@ func (u *Label) TableName() string {
 	return "Label"
 }
 
+// SameItem tells whether other labels the same MRC question or sentiment
+// task, as ToQueryBson finds it.
+func (u *Label) SameItem(other Label) bool {
+	if u.ProjectId != other.ProjectId || u.TaskType != other.TaskType || u.TaskId != other.TaskId {
+		return false
+	}
+	return u.TaskType != "MRC" || (u.ArticleId == other.ArticleId && u.Question == other.Question)
+}
+
+// ToQueryBson finds the label of the same MRC question or sentiment task.
 func (u *Label) ToQueryBson() bson.M {
 	queryObject := bson.M{
-		"taskId": u.TaskId,
+		"projectId": u.ProjectId,
+		"taskType":  u.TaskType,
+		"taskId":    u.TaskId,
+	}
+	if u.TaskType == "MRC" {
+		queryObject["articleId"] = u.ArticleId
+		queryObject["question"] = u.Question
 	}
 	return queryObject
 }
+
+// SentiTaskId is the id of the SentiTask a sentiment label makes gold; false
+// for MRC labels.
+func (u *Label) SentiTaskId() (primitive.ObjectID, bool) {
+	if u.TaskType != "Sentiment" {
+		return primitive.NilObjectID, false
+	}
+	taskId, err := primitive.ObjectIDFromHex(u.TaskId)
+	return taskId, err == nil
+}
+
+// GoldSentiment is the polarity a gold sentiment task expects for one
+// aspect category.
+type GoldSentiment struct {
+	Category string `bson:"category" json:"category"`
+	Polarity string `bson:"polarity" json:"polarity"`
+}
+
+// GoldResult is how an annotator did on a gold task. Correct answers match
+// the label, Score gives partial credit from 0 to 1.
+type GoldResult struct {
+	ProjectId  primitive.ObjectID `bson:"projectId" json:"projectId"`
+	LabelId    primitive.ObjectID `bson:"labelId" json:"labelId"`
+	UserId     string             `bson:"userId" json:"userId"`
+	Score      float64            `bson:"score" json:"score"`
+	Correct    bool               `bson:"correct" json:"correct"`
+	AnsweredAt time.Time          `bson:"answeredAt" json:"answeredAt"`
+}
+
+func (r *GoldResult) TableName() string {
+	return "GoldResult"
+}
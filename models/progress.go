@@ -0,0 +1,75 @@
+package models
+
+// States of the work in a project, in the order it goes through them.
+// Sentiment tasks never go past ProgressValidated, they are not adjudicated.
+const (
+	// ProgressUnassigned tasks still need annotators.
+	ProgressUnassigned = "unassigned"
+	// ProgressClaimed items are held by a live claim of a validator or
+	// adjudicator.
+	ProgressClaimed = "claimed"
+	// ProgressAnswered items wait for a validator.
+	ProgressAnswered = "answered"
+	// ProgressValidated items passed validation; they are done.
+	ProgressValidated = "validated"
+	// ProgressPendingDecision answers wait for an adjudicator.
+	ProgressPendingDecision = "pendingDecision"
+	// ProgressFinal answers were decided by an adjudicator; they are done.
+	ProgressFinal = "final"
+)
+
+// ProgressCounts counts the items of a project, or of an article, in each
+// state. Items are sentiment tasks; in MRC projects they are the tasks that
+// still need annotators and the answers of the annotators, gold ones left out.
+type ProgressCounts struct {
+	Unassigned      int `bson:"unassigned" json:"unassigned"`
+	Claimed         int `bson:"claimed" json:"claimed"`
+	Answered        int `bson:"answered" json:"answered"`
+	Validated       int `bson:"validated" json:"validated"`
+	PendingDecision int `bson:"pendingDecision" json:"pendingDecision"`
+	Final           int `bson:"final" json:"final"`
+}
+
+// Add counts n more items in state.
+func (c *ProgressCounts) Add(state string, n int) {
+	switch state {
+	case ProgressUnassigned:
+		c.Unassigned += n
+	case ProgressClaimed:
+		c.Claimed += n
+	case ProgressAnswered:
+		c.Answered += n
+	case ProgressValidated:
+		c.Validated += n
+	case ProgressPendingDecision:
+		c.PendingDecision += n
+	case ProgressFinal:
+		c.Final += n
+	}
+}
+
+// ArticleProgress is the progress of one article.
+type ArticleProgress struct {
+	ArticleId    string `json:"articleId"`
+	ArticleTitle string `json:"articleTitle"`
+	ProgressCounts
+}
+
+// DayProgress counts the work done on one day, in UTC: the annotations
+// saved, the validations posted and the decisions made.
+type DayProgress struct {
+	Date      string `json:"date"`
+	Answered  int    `json:"answered"`
+	Validated int    `json:"validated"`
+	Decided   int    `json:"decided"`
+}
+
+// Progress is where the work of a project stands: over the project, per
+// article, and day by day.
+type Progress struct {
+	ProjectId   string            `json:"projectId"`
+	ProjectType string            `json:"projectType"`
+	Total       ProgressCounts    `json:"total"`
+	Articles    []ArticleProgress `json:"articles"`
+	Days        []DayProgress     `json:"days"`
+}
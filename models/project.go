@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -11,12 +13,97 @@ type Project struct {
 	ProjectType string             `bson:"type" json:"type"`
 	Rule        string             `bson:"rule" json:"rule"`
 	ManagerId   string             `bson:"managerId" json:"managerId"`
+	// Archived projects stay readable but take no new annotations.
+	Archived bool `bson:"archived" json:"archived"`
+	// Redundancy is how many distinct annotators answer every task; 0, in
+	// projects from before it existed, means one.
+	Redundancy int `bson:"redundancy" json:"redundancy"`
+	// MatchRule decides which MRC validations verify an answer.
+	MatchRule MatchRule `bson:"matchRule" json:"matchRule"`
+	// Gold mixes gold tasks into the annotation queue.
+	Gold GoldSettings `bson:"gold" json:"gold"`
+	// AnsweredAt is when every article of the project was answered, and
+	// ValidatedAt when every sentiment article was validated; nil while
+	// one is not. The store keeps them up to date with the articles.
+	AnsweredAt  *time.Time `bson:"answeredAt,omitempty" json:"answeredAt,omitempty"`
+	ValidatedAt *time.Time `bson:"validatedAt,omitempty" json:"validatedAt,omitempty"`
+}
+
+// GoldSettings mixes gold tasks, see Label, into /nextTask and pauses the
+// annotators who keep failing them. The zero GoldSettings serves no gold
+// tasks and pauses nobody.
+type GoldSettings struct {
+	// Rate is the share of tasks served that are gold, from 0 to 1.
+	Rate float64 `bson:"rate" json:"rate"`
+	// MinAccuracy pauses an annotator whose share of correct answers over
+	// the last Window gold tasks is below it; 0 pauses nobody.
+	MinAccuracy float64 `bson:"minAccuracy" json:"minAccuracy"`
+	Window      int     `bson:"window" json:"window"`
+}
+
+// DefaultGoldWindow is the GoldSettings.Window of projects that set none.
+const DefaultGoldWindow = 10
+
+// MaxGoldWindow bounds GoldSettings.Window.
+const MaxGoldWindow = 1000
+
+// EffectiveWindow is Window, or DefaultGoldWindow when it is not set.
+func (g GoldSettings) EffectiveWindow() int {
+	if g.Window < 1 {
+		return DefaultGoldWindow
+	}
+	return g.Window
+}
+
+// MaxRedundancy bounds Project.Redundancy.
+const MaxRedundancy = 10
+
+// MatchRule scores the answer of an MRC validator against the answer it
+// validates from 0 to 1 by Method; a score of at least Threshold verifies
+// the answer, anything less leaves it pending for an adjudicator. The zero
+// MatchRule, of projects from before rules existed, is MatchExact.
+type MatchRule struct {
+	Method    string  `bson:"method" json:"method"`
+	Threshold float64 `bson:"threshold" json:"threshold"`
+}
+
+// Methods of a MatchRule. Spans are compared by their rune offsets.
+const (
+	// MatchExact scores 1 for the same answer at the same startIdx.
+	MatchExact = "exact"
+	// MatchOverlap is the share of the shorter span the other one covers.
+	MatchOverlap = "overlap"
+	// MatchTokenF1 is the F1 of the SQuAD normalized tokens of both answers.
+	MatchTokenF1 = "tokenF1"
+	// MatchCharIoU is the intersection over the union of both spans.
+	MatchCharIoU = "charIoU"
+)
+
+// MatchMethods lists the methods of a MatchRule.
+var MatchMethods = []string{MatchExact, MatchOverlap, MatchTokenF1, MatchCharIoU}
+
+// Effective is the rule as it applies: exact matches with an empty method,
+// and exact matches only ever verify with a score of 1.
+func (r MatchRule) Effective() MatchRule {
+	if r.Method == "" || r.Method == MatchExact {
+		return MatchRule{Method: MatchExact, Threshold: 1}
+	}
+	return r
 }
 
 func (p *Project) TableName() string {
 	return "Project"
 }
 
+// RequiredAnnotators is how many distinct annotators a task needs before it
+// counts as answered.
+func (p *Project) RequiredAnnotators() int {
+	if p.Redundancy < 1 {
+		return 1
+	}
+	return p.Redundancy
+}
+
 func (p *Project) ToQueryBson() bson.M {
 	var queryObject bson.M
 	if p.ProjectId.Hex() != "000000000000000000000000" {
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of annotation a Revision keeps the versions of.
+const (
+	// RevisionSentiTask versions a sentiment task with the aspects and
+	// sentiments of its annotators; TargetId is the SentiTask id.
+	RevisionSentiTask = "SentiTask"
+	// RevisionMRCAnswer versions an MRC answer; TargetId is its id.
+	RevisionMRCAnswer = "MRCAnswer"
+)
+
+// Changes a Revision records.
+const (
+	// RevisionAnswered is an annotator saving an answer.
+	RevisionAnswered = "answered"
+	// RevisionValidated is a validator checking the annotation.
+	RevisionValidated = "validated"
+	// RevisionDecided is an adjudicator deciding on an MRC answer.
+	RevisionDecided = "decided"
+	// RevisionDiscarded is a validator sending a sentiment task back to
+	// the annotators, without their aspects and sentiments.
+	RevisionDiscarded = "discarded"
+	// RevisionRestored is a manager bringing back an earlier revision.
+	RevisionRestored = "restored"
+)
+
+// Revision is one version of an annotation, written with every change to
+// it and never modified afterwards. It holds the whole annotation as the
+// change left it: SentiTask for RevisionSentiTask, MRCAnswer for
+// RevisionMRCAnswer.
+type Revision struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectId primitive.ObjectID `bson:"projectId" json:"projectId"`
+	Kind      string             `bson:"kind" json:"kind"`
+	TargetId  primitive.ObjectID `bson:"targetId" json:"targetId"`
+	// Version numbers the revisions of a target from 1.
+	Version   int       `bson:"version" json:"version"`
+	Action    string    `bson:"action" json:"action"`
+	Author    string    `bson:"author" json:"author"`
+	Reason    string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	// RestoredFrom is the version a RevisionRestored went back to.
+	RestoredFrom int `bson:"restoredFrom,omitempty" json:"restoredFrom,omitempty"`
+
+	SentiTask *SentiTaskVersion `bson:"sentiTask,omitempty" json:"sentiTask,omitempty"`
+	MRCAnswer *MRCAnswer        `bson:"mrcAnswer,omitempty" json:"mrcAnswer,omitempty"`
+}
+
+//TableName return name of database table
+func (r *Revision) TableName() string {
+	return "Revision"
+}
+
+// SentiTaskVersion is a sentiment task as a Revision keeps it.
+type SentiTaskVersion struct {
+	Annotators []string         `bson:"annotators" json:"annotators"`
+	IsAnswered bool             `bson:"isAnswered" json:"isAnswered"`
+	IsValidate bool             `bson:"isValidate" json:"isValidate"`
+	Aspects    []SentiAspect    `bson:"aspects" json:"aspects"`
+	Sentiments []SentiSentiment `bson:"sentiments" json:"sentiments"`
+}
+
+// RevisionChange is a field two revisions of an annotation disagree on.
+// From or To is missing when the field only exists on one side, e.g. an
+// aspect one of them does not have.
+type RevisionChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from,omitempty"`
+	To    *string `json:"to,omitempty"`
+}
+
+// RevisionDiff is what changed from one revision of an annotation to
+// another.
+type RevisionDiff struct {
+	From    Revision         `json:"from"`
+	To      Revision         `json:"to"`
+	Changes []RevisionChange `json:"changes"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -15,12 +17,28 @@ type SentiTask struct {
 	ProjectId  primitive.ObjectID `bson:"projectId" json:"projectId"`
 	IsAnswered bool               `bson:"isAnswered" json:"isAnswered"`
 	IsValidate bool               `bson:"isValidate" json:"isValidate"`
+	// Annotators are the distinct users who answered the task. IsAnswered
+	// is set once there are as many as the project's RequiredAnnotators.
+	Annotators []string `bson:"annotators,omitempty" json:"annotators,omitempty"`
+	// Gold tasks have a Label. They are scored instead of annotated, so
+	// they take every annotator and never count as answered.
+	Gold bool `bson:"gold,omitempty" json:"gold,omitempty"`
 }
 
 func (t *SentiTask) TableName() string {
 	return "SentiTask"
 }
 
+// AnsweredBy tells whether userId is one of the annotators of the task.
+func (t *SentiTask) AnsweredBy(userId string) bool {
+	for _, annotator := range t.Annotators {
+		if annotator == userId {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *SentiTask) ToQueryBson() bson.M {
 	var queryObject bson.M
 	if t.TaskId.Hex() != "000000000000000000000000" {
@@ -43,6 +61,8 @@ type SentiSentiment struct {
 	Offset    int                `bson:"offset" json:"offset"`
 	Sentiment string             `bson:"sentiment" json:"sentiment"`
 	Dir       string             `bson:"dir" json:"dir"`
+	// UserId is the annotator, always taken from the session token.
+	UserId string `bson:"userId" json:"userId"`
 }
 
 func (t *SentiSentiment) TableName() string {
@@ -71,6 +91,10 @@ type SentiAspect struct {
 	MajorAspect string             `bson:"majorAspect" json:"majorAspect"`
 	MinorAspect string             `bson:"minorAspect" json:"minorAspect"`
 	Offset      int                `bson:"offset" json:"offset"`
+	// UserId is the annotator, always taken from the session token.
+	UserId string `bson:"userId" json:"userId"`
+	// AnsweredAt is when the annotator saved the aspect.
+	AnsweredAt time.Time `bson:"answeredAt,omitempty" json:"answeredAt,omitempty"`
 	// SentimentList []SentiSentiment `bson:"sentimentList" json:"sentimentList"`
 }
 
@@ -93,12 +117,51 @@ func (a *SentiAspect) ToQueryBson() bson.M {
 // 	AnswerList []SentiAspect `bson:"answerList" json:"answerList"`
 // 	IsValidate bool          `bson:"isValidate" json:"isValidate"`
 // }
+
+// States of a validated SentiAnswer: whether the validator agreed with
+// every sentiment of the annotator.
+const (
+	SentiAllMatch = "All Match"
+	SentiNotMatch = "Not Match"
+)
+
 type SentiAnswer struct {
 	Task      SentiTask          `bson:"task" json:"task"`
 	Aspect    []SentiAspect      `bson:"aspect" json:"aspect"`
 	Sentiment []SentiSentiment   `bson:"sentiment" json:"sentiment"`
 	State     string             `bson:"state" json:"state"`
 	ProjectId primitive.ObjectID `bson:"projectId" json:"projectId"`
+	// UserId is the validator, always taken from the session token.
+	UserId string `bson:"userId" json:"userId"`
+	// Diff lists where the validator disagreed with the annotators; the
+	// State is SentiAllMatch when it is empty.
+	Diff []SentiDiff `bson:"diff" json:"diff"`
+	// ValidatedAt is when the validator posted the validation.
+	ValidatedAt time.Time `bson:"validatedAt,omitempty" json:"validatedAt,omitempty"`
+}
+
+// Kinds of a SentiDiff.
+const (
+	// SentiDiffMissing is an annotated sentiment the validator left out.
+	SentiDiffMissing = "missing"
+	// SentiDiffExtra is a sentiment the validator added.
+	SentiDiffExtra = "extra"
+	// SentiDiffWrong is a sentiment the validator changed; Fields names
+	// what differs: sentiment, dir or offset.
+	SentiDiffWrong = "wrong"
+)
+
+// SentiDiff is one sentiment of an aspect on which a validator and an
+// annotator disagreed.
+type SentiDiff struct {
+	Kind        string   `bson:"kind" json:"kind"`
+	AnnotatorId string   `bson:"annotatorId" json:"annotatorId"`
+	AspectId    string   `bson:"aspectId" json:"aspectId"`
+	Fields      []string `bson:"fields,omitempty" json:"fields,omitempty"`
+	// Annotated and Validated are the two sides, one of them missing for
+	// missing and extra sentiments.
+	Annotated *SentiSentiment `bson:"annotated,omitempty" json:"annotated,omitempty"`
+	Validated *SentiSentiment `bson:"validated,omitempty" json:"validated,omitempty"`
 }
 
 // func (a *SentiAnswer) ToQueryBson() bson.M {
@@ -0,0 +1,32 @@
+package models
+
+// SquadDataset is a SQuAD v1.1 or v2.0 file. Only v2.0 has unanswerable
+// questions, marked with IsImpossible.
+type SquadDataset struct {
+	Version string         `json:"version"`
+	Data    []SquadArticle `json:"data"`
+}
+
+type SquadArticle struct {
+	Title      string           `json:"title"`
+	Paragraphs []SquadParagraph `json:"paragraphs"`
+}
+
+type SquadParagraph struct {
+	Context string    `json:"context"`
+	QAs     []SquadQA `json:"qas"`
+}
+
+type SquadQA struct {
+	Id               string        `json:"id"`
+	Question         string        `json:"question"`
+	Answers          []SquadAnswer `json:"answers"`
+	IsImpossible     bool          `json:"is_impossible,omitempty"`
+	PlausibleAnswers []SquadAnswer `json:"plausible_answers,omitempty"`
+}
+
+// SquadAnswer starts AnswerStart characters (code points) into the context.
+type SquadAnswer struct {
+	Text        string `json:"text"`
+	AnswerStart int    `json:"answer_start"`
+}
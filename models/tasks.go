@@ -7,12 +7,15 @@ import (
 )
 
 type MRCTask struct {
+	ProjectId primitive.ObjectID `bson:"projectId,omitempty" json:"projectId"`
 	TaskId    string `bson:"taskId" json:"taskId"`
 	ArticleId string `bson:"articleId" json:"articleId"`
 	TaskType  string `bson:"taskType" json:"taskType"`
 	TaskTitle string `bson:"taskTitle" json:"taskTitle"`
 	Context   string `bson:"context" json:"context"`
-	Answered  int    `bson:"answered" json:"answered"`
+	// Answered counts the distinct Annotators who answered the task.
+	Answered   int      `bson:"answered" json:"answered"`
+	Annotators []string `bson:"annotators,omitempty" json:"annotators,omitempty"`
 }
 
 func (t *MRCTask) TableName() string {
@@ -37,6 +40,7 @@ func (t *MRCTask) ToQueryBson() bson.M {
 
 type MRCAnswer struct {
 	Id 				 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectId  primitive.ObjectID `bson:"projectId,omitempty" json:"projectId"`
 	UserId     string `bson:"userId" json:"userId"`
 	ArticleId  string `bson:"articleId" json:"articleId"`
 	TaskId     string `bson:"taskId" json:"taskId"`
@@ -45,6 +49,8 @@ type MRCAnswer struct {
 	Question   string `bson:"question" json:"question"`
 	Answer     string `bson:"answer" json:"answer"`
 	StartIdx   int    `bson:"startIdx" json:"startIdx"`
+	// IsImpossible marks SQuAD v2.0 questions the context does not answer.
+	IsImpossible bool `bson:"isImpossible,omitempty" json:"isImpossible,omitempty"`
 }
 
 func (a *MRCAnswer) ToQueryBson() bson.M {
@@ -52,6 +58,7 @@ func (a *MRCAnswer) ToQueryBson() bson.M {
 	if  a.TaskType == "MRCValidation" {
 		log.Println("validation", a.UserId)
 		queryObject = bson.M{
+			"projectId": a.ProjectId,
 			"userId": bson.M{"$ne": a.UserId},
 			"status": "unverified",
 			"taskType": "MRC",
@@ -74,14 +81,28 @@ func (a *MRCAnswer) ToQueryBson() bson.M {
 
 type MRCValidation struct {
 	Id				 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectId		 primitive.ObjectID `bson:"projectId,omitempty" json:"projectId"`
 	LabelUserId 	 string `bson:"labelUserId" json:"labelUserId"`
 	ValidationUserId string `bson:"validationUserId" json:"validationUserId"`
 	OriginalId 		 primitive.ObjectID `bson:"originalId,omitempty" json:"originalId"`
 	ValidationId	 primitive.ObjectID `bson:"validationId,omitempty" json:"validationId"`
 	Status   		 string `bson:"status" json:"status"`
+	// Score is how well the validation matched the original answer by the
+	// MatchRule Method of the project at the time.
+	Score			 float64 `bson:"score" json:"score"`
+	Method			 string `bson:"method,omitempty" json:"method,omitempty"`
 }
 
+// Statuses an adjudicator decides an MRC answer with.
+const (
+	// DecisionVerified accepts the original answer.
+	DecisionVerified = "verified"
+	// DecisionRejected rejects it.
+	DecisionRejected = "rejected"
+)
+
 type MRCDecision struct {
+	Id					 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserId				 string `bson:"userId" json:"userId"`
 	OriginalId			 primitive.ObjectID `bson:"originalId,omitempty" json:"originalId"`
 	ValidationId	 	 primitive.ObjectID `bson:"validationId,omitempty" json:"validationId"`
@@ -7,7 +7,7 @@ import (
 //User structure
 type User struct {
 	Name        string `bson:"name" json:"name"`
-	AccessToken string `bson:"accessToken" json:"accessToken"`
+	AccessToken string `bson:"accessToken" json:"-"`
 	ImageUrl    string `bson:"imageUrl" json:"imageUrl"`
 	Email       string `bson:"email" json:"email"`
 	FamilyName  string `bson:"familyName" json:"familyName"`
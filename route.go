@@ -3,13 +3,103 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"sync/atomic"
 
+	"Lynx/auth"
 	respond "Lynx/controller"
+	"Lynx/logging"
+	"Lynx/models"
 )
 
 type RouteMux struct {
+	// ready is 1 while Store is connected and the server is not shutting down.
+	ready int32
+}
+
+func (p *RouteMux) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&p.ready, v)
+}
+
+func (p *RouteMux) isReady() bool {
+	return atomic.LoadInt32(&p.ready) == 1
+}
+
+// routePermissions is what each route needs in the project named by the
+// projectId of its request. Routes missing here are not served.
+var routePermissions = map[string]auth.Permission{
+	"/":                    auth.PermLogin,
+	"/test":                auth.PermLogin,
+	"/saveProject":         auth.PermLogin,
+	"/projects":            auth.PermLogin,
+	"/updateProject":       auth.PermManage,
+	"/archiveProject":      auth.PermManage,
+	"/unarchiveProject":    auth.PermManage,
+	"/deleteProject":       auth.PermOwn,
+	"/importSquad":         auth.PermManage,
+	"/exportSquad":         auth.PermManage,
+	"/importAbsa":          auth.PermManage,
+	"/exportAbsa":          auth.PermManage,
+	"/agreement":           auth.PermManage,
+	"/leaderboard":         auth.PermManage,
+	"/progress":            auth.PermManage,
+	"/history":             auth.PermManage,
+	"/revisionDiff":        auth.PermManage,
+	"/restoreRevision":     auth.PermManage,
+	"/sentiFinalAnswers":   auth.PermManage,
+	"/saveGold":            auth.PermManage,
+	"/deleteGold":          auth.PermManage,
+	"/golds":               auth.PermManage,
+	"/goldStats":           auth.PermManage,
+	"/resumeAnnotator":     auth.PermManage,
+	"/users":               auth.PermManage,
+	"/projectUsers":        auth.PermManage,
+	"/saveAuth":            auth.PermManage,
+	"/sentiArticles":       auth.PermView,
+	"/sentiTasks":          auth.PermView,
+	"/getTask":             auth.PermView,
+	"/getSentiTask":        auth.PermView,
+	"/getSentiAspects":     auth.PermView,
+	"/saveAnswer":          auth.PermAnnotate,
+	"/saveSentiAnswer":     auth.PermAnnotate,
+	"/nextTask":            auth.PermAnnotate,
+	"/checkIsAnswered":     auth.PermAnnotate,
+	"/getValidation":       auth.PermValidate,
+	"/saveValidation":      auth.PermValidate,
+	"/getSentiValidation":  auth.PermValidate,
+	"/postSentiValidation": auth.PermValidate,
+	"/checkIsValidated":    auth.PermValidate,
+	"/discardSentiAnswer":  auth.PermValidate,
+	"/getDecision":         auth.PermAdjudicate,
+	"/saveDecision":        auth.PermAdjudicate,
+	"/renewClaim":          auth.PermView,
+	"/releaseClaim":        auth.PermView,
+	"/claims":              auth.PermManage,
+}
+
+// isAnnotationWrite tells the permissions that add labels, which archived
+// projects no longer accept.
+func isAnnotationWrite(perm auth.Permission) bool {
+	return perm == auth.PermAnnotate || perm == auth.PermValidate || perm == auth.PermAdjudicate
+}
+
+// projectWritable answers 409 for archived projects.
+func projectWritable(w http.ResponseWriter, r *http.Request) bool {
+	projectId, _ := auth.ProjectId(r.Context())
+	project, err := Store.GetProjectByProjectId(models.Project{ProjectId: projectId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if project.Archived {
+		http.Error(w, "project is archived", http.StatusConflict)
+		return false
+	}
+	return true
 }
 
 // example
@@ -28,98 +118,224 @@ func sayhelloName(w http.ResponseWriter, r *http.Request) error {
 func (p *RouteMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	w.Header().Set("Content-Type", "application/json")
+	// probes answer even while the store is not ready
+	switch path {
+	case "/healthz":
+		healthz(w, r)
+		return
+	case "/readyz":
+		p.readyz(w, r)
+		return
+	case "/version":
+		versionInfo(w, r)
+		return
+	}
+	if !p.isReady() {
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "service not ready", http.StatusServiceUnavailable)
+		return
+	}
+	if path == "/login" {
+		respond.Login(Store, Identities, Tokens, w, r)
+		return
+	}
+	// every other route acts as the user of the session token
+	if _, ok := auth.UserId(r.Context()); !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid session token", http.StatusUnauthorized)
+		return
+	}
+	perm, ok := routePermissions[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	r, status, err := auth.Authorize(Store, r, perm)
+	if err != nil {
+		logging.Debugf("%s denied: %v", path, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if isAnnotationWrite(perm) && !projectWritable(w, r) {
+		return
+	}
 	switch path {
 	case "/":
 		sayhelloName(w, r)
 		return
-	case "/login":
-		respond.Login(Database, w, r)
-		return
 	case "/users":
-		respond.GetUsers(Database, w, r)
+		respond.GetUsers(Store, w, r)
 		return
 	case "/projectUsers":
-		respond.GetUsersByProject(Database, w, r)
+		respond.GetUsersByProject(Store, w, r)
 		return
 	case "/saveAuth":
-		respond.SaveAuth(Database, w, r)
+		respond.SaveAuth(Store, w, r)
+		return
+	case "/saveProject":
+		logging.Debugf("POST /saveProject")
+		respond.SaveProject(Store, w, r)
+		return
+	case "/projects":
+		respond.GetProjects(Store, w, r)
+		return
+	case "/updateProject":
+		logging.Debugf("POST /updateProject")
+		respond.UpdateProject(Store, w, r)
+		return
+	case "/archiveProject":
+		logging.Debugf("POST /archiveProject")
+		respond.ArchiveProject(Store, true, w, r)
+		return
+	case "/unarchiveProject":
+		logging.Debugf("POST /unarchiveProject")
+		respond.ArchiveProject(Store, false, w, r)
+		return
+	case "/importSquad":
+		logging.Debugf("POST /importSquad")
+		respond.ImportSquad(Store, w, r)
+		return
+	case "/importAbsa":
+		logging.Debugf("POST /importAbsa")
+		respond.ImportAbsa(Store, w, r)
+		return
+	case "/exportAbsa":
+		logging.Debugf("POST /exportAbsa")
+		respond.ExportAbsa(Store, w, r)
+		return
+	case "/exportSquad":
+		logging.Debugf("POST /exportSquad")
+		respond.ExportSquad(Store, w, r)
+		return
+	case "/sentiFinalAnswers":
+		respond.GetSentiFinalAnswers(Store, w, r)
+		return
+	case "/agreement":
+		respond.GetAgreement(Store, w, r)
+		return
+	case "/leaderboard":
+		respond.GetLeaderboard(Store, w, r)
+		return
+	case "/progress":
+		respond.GetProgress(Store, w, r)
+		return
+	case "/history":
+		respond.GetHistory(Store, w, r)
+		return
+	case "/revisionDiff":
+		respond.GetRevisionDiff(Store, w, r)
+		return
+	case "/restoreRevision":
+		// restoring rewrites annotations, as annotation writes do
+		if !projectWritable(w, r) {
+			return
+		}
+		respond.RestoreRevision(Store, w, r)
+		return
+	case "/saveGold":
+		logging.Debugf("POST /saveGold")
+		respond.SaveGold(Store, w, r)
+		return
+	case "/deleteGold":
+		logging.Debugf("POST /deleteGold")
+		respond.DeleteGold(Store, w, r)
+		return
+	case "/golds":
+		respond.GetGolds(Store, w, r)
+		return
+	case "/goldStats":
+		respond.GetGoldStats(Store, w, r)
+		return
+	case "/resumeAnnotator":
+		logging.Debugf("POST /resumeAnnotator")
+		respond.ResumeAnnotator(Store, w, r)
+		return
+	case "/nextTask":
+		respond.NextTask(Store, w, r)
+		return
+	case "/deleteProject":
+		logging.Debugf("POST /deleteProject")
+		respond.DeleteProject(Store, w, r)
 		return
-	// case "/saveProject":
-	// 	respond.SaveProject(Database, w, r)
-	// 	return
-	// case "/projects":
-	// 	respond.GetProjects(Database, w, r)
-	// 	return
 	// case "/articles":
-	// 	respond.GetArticles(Database, w, r)
+	// 	respond.GetArticles(Store, w, r)
 	// 	return
 	case "/sentiArticles":
-		respond.GetSentiArticles(Database, w, r)
+		respond.GetSentiArticles(Store, w, r)
 		return
 	// case "/saveArticles":
-	// 	respond.SaveArticles(Database, w, r)
+	// 	respond.SaveArticles(Store, w, r)
 	// 	return
 	// case "/tasks":
-	// 	respond.GetTasksByArticleId(Database, w, r)
+	// 	respond.GetTasksByArticleId(Store, w, r)
 	// 	return
 	case "/sentiTasks":
-		respond.GetSentiTasksByArticleId(Database, w, r)
+		respond.GetSentiTasksByArticleId(Store, w, r)
 		return
 	case "/getTask":
-		log.Println("POST /getTask")
-		respond.GetTaskById(Database, w, r)
+		logging.Debugf("POST /getTask")
+		respond.GetTaskById(Store, w, r)
 		return
 	case "/getSentiTask":
-		log.Println("POST /getSentiTask")
-		respond.GetSentiTaskById(Database, w, r)
+		logging.Debugf("POST /getSentiTask")
+		respond.GetSentiTaskById(Store, w, r)
 		return
 	case "/getSentiValidation":
-		log.Println("POST /getSentiValidation")
-		respond.GetSentiValidation(Database, w, r)
+		logging.Debugf("POST /getSentiValidation")
+		respond.GetSentiValidation(Store, ClaimTTL, w, r)
 		return
 	case "/saveAnswer":
-		log.Println("POST /SaveAnswer")
-		respond.SaveAnswer(Database, w, r)
+		logging.Debugf("POST /SaveAnswer")
+		respond.SaveAnswer(Store, w, r)
 		return
 	case "/getValidation":
-		log.Println("POST /GetValidation")
-		respond.GetValidation(Database, w, r)
+		logging.Debugf("POST /GetValidation")
+		respond.GetValidation(Store, ClaimTTL, w, r)
 		return
 	case "/getSentiAspects":
-		log.Println("POST /getSentiAspects")
-		respond.GetSentiAspects(Database, w, r)
+		logging.Debugf("POST /getSentiAspects")
+		respond.GetSentiAspects(Store, w, r)
 		return
 	case "/postSentiValidation":
-		log.Println("POST /PostSentiValidation")
-		respond.PostSentiValidation(Database, w, r)
+		logging.Debugf("POST /PostSentiValidation")
+		respond.PostSentiValidation(Store, w, r)
 		return
 	case "/saveValidation":
-		log.Println("POST /SaveValidation")
-		respond.SaveValidation(Database, w, r)
+		logging.Debugf("POST /SaveValidation")
+		respond.SaveValidation(Store, w, r)
 		return
 	case "/saveSentiAnswer":
-		log.Println("POST /SaveSentiAnswer")
-		respond.SaveSentiAnswer(Database, w, r)
+		logging.Debugf("POST /SaveSentiAnswer")
+		respond.SaveSentiAnswer(Store, w, r)
 		return
 	case "/checkIsAnswered":
-		log.Println("POST /CheckIsAnswered")
-		respond.CheckIsAnswered(Database, w, r)
+		logging.Debugf("POST /CheckIsAnswered")
+		respond.CheckIsAnswered(Store, w, r)
 		return
 	case "/checkIsValidated":
-		log.Println("POST /CheckIsValidated")
-		respond.CheckIsValidated(Database, w, r)
+		logging.Debugf("POST /CheckIsValidated")
+		respond.CheckIsValidated(Store, w, r)
 		return
 	case "/discardSentiAnswer":
-		log.Println("POST /discardSentiAnswer")
-		respond.DiscardSentiAnswer(Database, w, r)
+		logging.Debugf("POST /discardSentiAnswer")
+		respond.DiscardSentiAnswer(Store, w, r)
 		return
 	case "/getDecision":
-		log.Println("POST /getRandomDecision")
-		respond.GetDecision(Database, w, r)
+		logging.Debugf("POST /getRandomDecision")
+		respond.GetDecision(Store, ClaimTTL, w, r)
 		return
 	case "/saveDecision":
-		log.Println("POST /SaveDecision")
-		respond.SaveDecision(Database, w, r)
+		logging.Debugf("POST /SaveDecision")
+		respond.SaveDecision(Store, w, r)
+		return
+	case "/renewClaim":
+		respond.RenewClaim(Store, ClaimTTL, w, r)
+		return
+	case "/releaseClaim":
+		respond.ReleaseClaim(Store, w, r)
+		return
+	case "/claims":
+		respond.GetClaims(Store, w, r)
 		return
 	case "/test":
 		respond.Test(w, r)
@@ -0,0 +1,428 @@
+// Package scoring rolls up how the work of every user of a project fared:
+// how much of it the reviewers accepted, how often an adjudicator overturned
+// their reviews, how much they did and how they did on gold tasks. Managers
+// rank the users of a project by these scores in a leaderboard.
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"Lynx/importer"
+	"Lynx/models"
+	"Lynx/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Metrics a Leaderboard can be sorted by.
+const (
+	ByAcceptance = "acceptanceRate"
+	ByOverturn   = "overturnRate"
+	ByThroughput = "throughput"
+	ByGold       = "goldAccuracy"
+)
+
+// Period limits the scores to the work done from Since up to, but not
+// including, Until. A zero time leaves that end open.
+type Period struct {
+	Since time.Time
+	Until time.Time
+}
+
+// Contains tells whether work done at t falls in the period. Work saved
+// before Lynx recorded its time only falls in a period open at both ends.
+func (p Period) Contains(t time.Time) bool {
+	if t.IsZero() {
+		return p.Since.IsZero() && p.Until.IsZero()
+	}
+	return (p.Since.IsZero() || !t.Before(p.Since)) && (p.Until.IsZero() || t.Before(p.Until))
+}
+
+// UserScores are the scores of one user in a project. Rates that are
+// undefined, e.g. the acceptance rate of a user nobody reviewed, are left out.
+type UserScores struct {
+	UserId string `json:"userId"`
+	// Rank is the place of the user by the metric the leaderboard is
+	// sorted by, shared by ties and left out when the user has no value
+	// for it.
+	Rank int `json:"rank,omitempty"`
+	// Annotated counts the MRC answers, or the sentiment tasks, the user
+	// annotated, gold ones included.
+	Annotated int `json:"annotated"`
+	// Reviewed counts the annotations a validator or adjudicator settled,
+	// Accepted the ones they found right.
+	Reviewed       int      `json:"reviewed"`
+	Accepted       int      `json:"accepted"`
+	AcceptanceRate *float64 `json:"acceptanceRate,omitempty"`
+	// Validated counts the reviews the user made as a validator,
+	// Overturned the ones an adjudicator decided against.
+	Validated    int      `json:"validated"`
+	Overturned   int      `json:"overturned"`
+	OverturnRate *float64 `json:"overturnRate,omitempty"`
+	// Decided counts the decisions the user made as an adjudicator.
+	Decided int `json:"decided"`
+	// ActiveDays counts the days, in UTC, the user did any work on;
+	// Throughput is the work done per active day.
+	ActiveDays   int      `json:"activeDays"`
+	Throughput   *float64 `json:"throughput,omitempty"`
+	GoldAnswered int      `json:"goldAnswered"`
+	GoldCorrect  int      `json:"goldCorrect"`
+	GoldAccuracy *float64 `json:"goldAccuracy,omitempty"`
+}
+
+// Leaderboard is the scores of the users of a project, sorted by one of
+// them.
+type Leaderboard struct {
+	ProjectId   string       `json:"projectId"`
+	ProjectType string       `json:"projectType"`
+	SortBy      string       `json:"sortBy"`
+	Users       []UserScores `json:"users"`
+}
+
+// tally is the work of a user as it is counted.
+type tally struct {
+	UserScores
+	days map[string]bool
+}
+
+// board counts the work of the users of a project in a period.
+type board struct {
+	period Period
+	users  map[string]*tally
+	// adjudicated is whether reviews can be overturned at all
+	adjudicated bool
+}
+
+func (b *board) user(userId string) *tally {
+	t, ok := b.users[userId]
+	if !ok {
+		t = &tally{UserScores: UserScores{UserId: userId}, days: make(map[string]bool)}
+		b.users[userId] = t
+	}
+	return t
+}
+
+// worked marks the day of t as active, unless the time is unknown.
+func (t *tally) worked(at time.Time) {
+	if !at.IsZero() {
+		t.days[at.UTC().Format("2006-01-02")] = true
+	}
+}
+
+// ProjectLeaderboard scores the users of a project in period, sorted by
+// sortBy, the acceptance rate when it is empty:
+//
+// MRC answers are accepted when validated or decided "verified" and count
+// as reviewed once they are neither waiting for validation nor for a
+// decision. A review is overturned when the validator found the answers did
+// not match and the adjudicator then verified the original answer.
+//
+// A sentiment task counts as reviewed for every annotator of it once it has
+// a validation; the latest one accepts an annotator when its diff has no
+// item of theirs. Validations posted before diffs were kept only accept
+// when they are "All Match". Sentiment projects have no adjudicators.
+//
+// Gold answers count as annotations but are scored against their label
+// instead of reviewed, see service.Store.AnswerMRCTask.
+func ProjectLeaderboard(store service.Store, project models.Project, period Period, sortBy string) (*Leaderboard, error) {
+	if sortBy == "" {
+		sortBy = ByAcceptance
+	}
+	less, err := ordering(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	b := &board{period: period, users: make(map[string]*tally), adjudicated: project.ProjectType == importer.TypeMRC}
+	switch project.ProjectType {
+	case importer.TypeMRC:
+		err = b.mrc(store, project)
+	case importer.TypeSentiment:
+		err = b.sentiment(store, project)
+	default:
+		return nil, fmt.Errorf("cannot score the users of %q projects", project.ProjectType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = b.gold(store, project)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]UserScores, 0, len(b.users))
+	for _, t := range b.users {
+		scores := t.scores()
+		if !b.adjudicated {
+			scores.OverturnRate = nil
+		}
+		users = append(users, scores)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, c := metric(users[i], sortBy), metric(users[j], sortBy)
+		switch {
+		case a != nil && c != nil && *a != *c:
+			return less(*a, *c)
+		case (a == nil) != (c == nil):
+			return a != nil
+		}
+		return users[i].UserId < users[j].UserId
+	})
+	for i := range users {
+		value := metric(users[i], sortBy)
+		switch {
+		case value == nil:
+		case i > 0 && *value == *metric(users[i-1], sortBy):
+			users[i].Rank = users[i-1].Rank
+		default:
+			users[i].Rank = i + 1
+		}
+	}
+	return &Leaderboard{
+		ProjectId:   project.ProjectId.Hex(),
+		ProjectType: project.ProjectType,
+		SortBy:      sortBy,
+		Users:       users,
+	}, nil
+}
+
+// ordering tells how users are sorted by a metric, the best first: a low
+// overturn rate is better, any other metric is better when higher.
+func ordering(sortBy string) (func(a, b float64) bool, error) {
+	switch sortBy {
+	case ByOverturn:
+		return func(a, b float64) bool { return a < b }, nil
+	case ByAcceptance, ByThroughput, ByGold:
+		return func(a, b float64) bool { return a > b }, nil
+	}
+	return nil, fmt.Errorf("cannot sort by %q", sortBy)
+}
+
+func metric(scores UserScores, sortBy string) *float64 {
+	switch sortBy {
+	case ByOverturn:
+		return scores.OverturnRate
+	case ByThroughput:
+		return scores.Throughput
+	case ByGold:
+		return scores.GoldAccuracy
+	}
+	return scores.AcceptanceRate
+}
+
+func (t *tally) scores() UserScores {
+	scores := t.UserScores
+	scores.ActiveDays = len(t.days)
+	scores.AcceptanceRate = rate(scores.Accepted, scores.Reviewed)
+	scores.OverturnRate = rate(scores.Overturned, scores.Validated)
+	scores.Throughput = rate(scores.Annotated+scores.Validated+scores.Decided, scores.ActiveDays)
+	scores.GoldAccuracy = rate(scores.GoldCorrect, scores.GoldAnswered)
+	return scores
+}
+
+func rate(count, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	r := float64(count) / float64(total)
+	return &r
+}
+
+// idTime is when a document was inserted, by its ObjectId.
+func idTime(id primitive.ObjectID) time.Time {
+	if id.IsZero() {
+		return time.Time{}
+	}
+	return id.Timestamp()
+}
+
+func (b *board) mrc(store service.Store, project models.Project) error {
+	articles, err := store.GetArticlesByProjectId(project.ProjectId)
+	if err != nil {
+		return err
+	}
+	articleIds := make([]string, len(articles))
+	for i, article := range articles {
+		articleIds[i] = article.ArticleId.Hex()
+	}
+	answers, err := store.GetAnswersByArticleIds(articleIds)
+	if err != nil {
+		return err
+	}
+	for _, answer := range answers {
+		at := idTime(answer.Id)
+		if !b.period.Contains(at) {
+			continue
+		}
+		t := b.user(answer.UserId)
+		t.worked(at)
+		t.Annotated++
+		switch answer.Status {
+		case models.AnswerStatusGold, "unverified", "pending":
+		case "verified":
+			t.Reviewed++
+			t.Accepted++
+		default:
+			t.Reviewed++
+		}
+	}
+
+	validations, err := store.GetValidationsByProjectId(project.ProjectId)
+	if err != nil {
+		return err
+	}
+	originalIds := make([]primitive.ObjectID, len(validations))
+	for i, validation := range validations {
+		originalIds[i] = validation.OriginalId
+	}
+	decisions, err := store.GetDecisionsByOriginalIds(originalIds)
+	if err != nil {
+		return err
+	}
+	decided := make(map[primitive.ObjectID]bool)
+	for _, decision := range decisions {
+		decided[decision.ValidationStatusId] = true
+		at := idTime(decision.Id)
+		if !b.period.Contains(at) {
+			continue
+		}
+		t := b.user(decision.UserId)
+		t.worked(at)
+		t.Decided++
+	}
+	for _, validation := range validations {
+		at := idTime(validation.Id)
+		if !b.period.Contains(at) {
+			continue
+		}
+		t := b.user(validation.ValidationUserId)
+		t.worked(at)
+		t.Validated++
+		// only mismatches go to an adjudicator, who overturns the
+		// validator by verifying the original answer after all
+		if decided[validation.Id] && validation.Status == "verified" {
+			t.Overturned++
+		}
+	}
+	return nil
+}
+
+func (b *board) sentiment(store service.Store, project models.Project) error {
+	tasks, err := store.GetSentiTasksByProjectId(project.ProjectId)
+	if err != nil {
+		return err
+	}
+	taskIds := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		taskIds[i] = task.TaskId
+	}
+	aspects, err := store.GetAspectsByTaskIds(taskIds)
+	if err != nil {
+		return err
+	}
+	finals, err := store.GetFinalAnswersByProjectId(project.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	// the annotators of every task, with when they answered it
+	type answerKey struct {
+		taskId primitive.ObjectID
+		userId string
+	}
+	answeredAt := make(map[answerKey]time.Time)
+	annotators := make(map[primitive.ObjectID][]string)
+	annotated := func(taskId primitive.ObjectID, userId string) {
+		if userId == "" {
+			return
+		}
+		if _, ok := answeredAt[answerKey{taskId, userId}]; !ok {
+			answeredAt[answerKey{taskId, userId}] = time.Time{}
+			annotators[taskId] = append(annotators[taskId], userId)
+		}
+	}
+	for _, aspect := range aspects {
+		annotated(aspect.TaskId, aspect.UserId)
+		key := answerKey{aspect.TaskId, aspect.UserId}
+		if at := answeredAt[key]; !aspect.AnsweredAt.IsZero() && (at.IsZero() || aspect.AnsweredAt.Before(at)) {
+			answeredAt[key] = aspect.AnsweredAt
+		}
+	}
+	for _, task := range tasks {
+		for _, userId := range task.Annotators {
+			annotated(task.TaskId, userId)
+		}
+	}
+
+	latest := make(map[primitive.ObjectID]models.SentiAnswer)
+	for _, final := range finals {
+		latest[final.Task.TaskId] = final
+		for _, item := range final.Diff {
+			annotated(final.Task.TaskId, item.AnnotatorId)
+		}
+		if !b.period.Contains(final.ValidatedAt) {
+			continue
+		}
+		t := b.user(final.UserId)
+		t.worked(final.ValidatedAt)
+		t.Validated++
+	}
+
+	for _, task := range tasks {
+		final, validated := latest[task.TaskId]
+		for _, userId := range annotators[task.TaskId] {
+			at := answeredAt[answerKey{task.TaskId, userId}]
+			if !b.period.Contains(at) {
+				continue
+			}
+			t := b.user(userId)
+			t.worked(at)
+			t.Annotated++
+			if !validated || task.Gold {
+				continue
+			}
+			t.Reviewed++
+			if acceptsSentiment(final, userId) {
+				t.Accepted++
+			}
+		}
+	}
+	return nil
+}
+
+// acceptsSentiment tells whether a validation found the answer of an
+// annotator right.
+func acceptsSentiment(final models.SentiAnswer, userId string) bool {
+	if final.State == models.SentiAllMatch {
+		return true
+	}
+	if len(final.Diff) == 0 {
+		// a mismatch from before diffs were kept blames every annotator
+		return false
+	}
+	for _, item := range final.Diff {
+		if item.AnnotatorId == userId {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *board) gold(store service.Store, project models.Project) error {
+	results, err := store.GetGoldResults(project.ProjectId, "")
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !b.period.Contains(result.AnsweredAt) {
+			continue
+		}
+		t := b.user(result.UserId)
+		t.GoldAnswered++
+		if result.Correct {
+			t.GoldCorrect++
+		}
+	}
+	return nil
+}
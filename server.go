@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"Lynx/auth"
+	"Lynx/config"
+	"Lynx/logging"
+	"Lynx/service"
+	"Lynx/span"
+
+	"github.com/rs/cors"
+)
+
+// newAuth builds the login verifier and the session token issuer.
+func newAuth(cfg *config.Config) (auth.IdentityProvider, *auth.TokenIssuer, error) {
+	secret := []byte(cfg.AuthSecret)
+	var identities auth.IdentityProvider
+	switch cfg.IdentityProvider {
+	case "google":
+		identities = &auth.GoogleProvider{ClientID: cfg.GoogleClientID}
+	case "local":
+		logging.Warnf("The local identity provider lets anyone log in as anyone, never expose it to real users")
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			_, err := rand.Read(secret)
+			if err != nil {
+				return nil, nil, err
+			}
+			logging.Warnf("No authSecret configured, session tokens will not survive a restart")
+		}
+		identities = auth.LocalProvider{}
+	}
+	return identities, auth.NewTokenIssuer(secret, cfg.TokenTTL.Duration), nil
+}
+
+// run serves until SIGINT or SIGTERM. The listener comes up first so the
+// process answers while the store is still connecting; API routes reply
+// 503 until the store is ready and again once shutdown has begun. Shutdown
+// lets in-flight requests finish before the store is released.
+func run(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	ClaimTTL = cfg.ClaimTTL.Duration
+	Identities, Tokens, err = newAuth(cfg)
+	if err != nil {
+		return err
+	}
+	mux := &RouteMux{}
+	handler := cors.New(cors.Options{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodHead},
+		AllowedHeaders: []string{"Origin", "Accept", "Content-Type", "X-Requested-With", "Authorization", span.Header},
+		ExposedHeaders: []string{span.Header, "Content-Disposition"},
+	}).Handler(Tokens.Middleware(mux))
+	server := &http.Server{
+		Addr:         cfg.ListenAddr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout.Duration,
+		WriteTimeout: cfg.WriteTimeout.Duration,
+	}
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+	logging.Infof("Server Launched on %s with the %s store", cfg.ListenAddr, cfg.Store)
+
+	type opened struct {
+		store service.Store
+		close func()
+		err   error
+	}
+	storeReady := make(chan opened, 1)
+	go func() {
+		store, close, err := service.Open(ctx, cfg)
+		storeReady <- opened{store, close, err}
+	}()
+
+	closeStore := func() {}
+	var runErr error
+	select {
+	case err := <-serverErr:
+		// the server failed before the store was ready
+		stop()
+		if s := <-storeReady; s.err == nil {
+			s.close()
+		}
+		return err
+	case s := <-storeReady:
+		if s.err != nil {
+			// a signal during the connection retries is a clean stop
+			if ctx.Err() == nil {
+				runErr = s.err
+			}
+			break
+		}
+		Store = s.store
+		closeStore = s.close
+		mux.setReady(true)
+		logging.Infof("Store ready")
+		select {
+		case <-ctx.Done():
+		case err := <-serverErr:
+			closeStore()
+			return err
+		}
+	}
+
+	logging.Infof("Shutting down, draining in-flight requests")
+	mux.setReady(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logging.Errorf("Shutdown did not finish in %s: %v", cfg.ShutdownTimeout, err)
+	}
+	closeStore()
+	return runErr
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxClaimAttempts bounds how often ClaimNext looks for another item after
+// losing a race for one.
+const maxClaimAttempts = 5
+
+// ClaimNext hands the next item of a queue to userId for ttl. next finds
+// the first free item that is not in exclude, which already holds the items
+// other users claimed; when another user claims the found item first, the
+// search goes on without it. Claiming an item releases the other claims of
+// the user in the queue, so skipping an item gives it back.
+func ClaimNext(store Store, kind string, projectId primitive.ObjectID, userId string, ttl time.Duration, next func(exclude []primitive.ObjectID) (primitive.ObjectID, error)) (*models.Claim, error) {
+	live, err := store.GetLiveClaims(projectId, kind)
+	if err != nil {
+		return nil, err
+	}
+	var exclude []primitive.ObjectID
+	for _, c := range live {
+		if c.UserId != userId {
+			exclude = append(exclude, c.ItemId)
+		}
+	}
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		itemId, err := next(exclude)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		claim := models.Claim{
+			ProjectId: projectId,
+			Kind:      kind,
+			ItemId:    itemId,
+			UserId:    userId,
+			ClaimedAt: now,
+			ExpiresAt: now.Add(ttl),
+		}
+		err = store.TakeClaim(claim)
+		if err == ErrClaimed {
+			exclude = append(exclude, itemId)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = store.ReleaseOtherClaims(projectId, kind, userId, itemId)
+		if err != nil {
+			return nil, err
+		}
+		return &claim, nil
+	}
+	return nil, ErrClaimed
+}
+
+// CheckClaim returns ErrClaimed when a user other than userId holds a live
+// claim on the item. Items nobody claimed, or whose claim expired, are free
+// to be submitted by anyone.
+func CheckClaim(store Store, kind string, itemId primitive.ObjectID, userId string) error {
+	claim, err := store.GetClaim(kind, itemId)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claim.UserId != userId && claim.Live(time.Now()) {
+		return ErrClaimed
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"math/rand"
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
+// PickGold decides whether the next task of userId is gold, at the rate of
+// the project, and picks one of the labels the user has not answered yet.
+// It returns nil for a regular task.
+func PickGold(store Store, project models.Project, userId string) (*models.Label, error) {
+	if project.Gold.Rate <= 0 || rand.Float64() >= project.Gold.Rate {
+		return nil, nil
+	}
+	labels, err := store.GetLabels(project.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	results, err := store.GetGoldResults(project.ProjectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	var answered []primitive.ObjectID
+	for _, result := range results {
+		answered = append(answered, result.LabelId)
+	}
+	var open []models.Label
+	for _, label := range labels {
+		if label.TaskType == project.ProjectType && !containsId(answered, label.Id) {
+			open = append(open, label)
+		}
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+	return &open[rand.Intn(len(open))], nil
+}
+
+// FindGoldLabel returns the label of the MRC question or sentiment task
+// query names, see models.Label.SameItem; nil when it is not gold.
+func FindGoldLabel(store Store, query models.Label) (*models.Label, error) {
+	labels, err := store.GetLabels(query.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		if label.SameItem(query) {
+			return &label, nil
+		}
+	}
+	return nil, nil
+}
+
+// WindowAccuracy is the share of correct results among the last window
+// ones, with how many that were. results are in the order they were answered.
+func WindowAccuracy(results []models.GoldResult, window int) (float64, int) {
+	if len(results) > window {
+		results = results[len(results)-window:]
+	}
+	if len(results) == 0 {
+		return 0, 0
+	}
+	correct := 0
+	for _, result := range results {
+		if result.Correct {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(results)), len(results)
+}
+
+// belowMinAccuracy tells whether results, the gold results of a user in the
+// order they were answered, fill a window of gold tasks with an accuracy
+// below the MinAccuracy of project, which pauses the user.
+func belowMinAccuracy(project models.Project, results []models.GoldResult) bool {
+	if project.Gold.MinAccuracy <= 0 {
+		return false
+	}
+	window := project.Gold.EffectiveWindow()
+	accuracy, counted := WindowAccuracy(results, window)
+	return counted >= window && accuracy < project.Gold.MinAccuracy
+}
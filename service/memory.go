@@ -0,0 +1,1866 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errEmptyInsert mirrors the driver refusing an InsertMany without documents.
+var errEmptyInsert = errors.New("must provide at least one element in input slice")
+
+// MemoryStore is a Store that keeps every collection in process memory.
+// It reproduces the filters MongoStore sends to Mongo so the server can be
+// developed and tested without a cluster. Nothing is persisted.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	users             []models.User
+	auths             []models.Auth
+	projects          []models.Project
+	articles          []models.Article
+	sentiArticles     []models.SentiArticle
+	mrcTasks          []models.MRCTask
+	mrcAnswers        []models.MRCAnswer
+	mrcValidations    []models.MRCValidation
+	mrcDecisions      []models.MRCDecision
+	sentiTasks        []models.SentiTask
+	sentiAspects      []models.SentiAspect
+	sentiSentiments   []models.SentiSentiment
+	sentiFinalAnswers []models.SentiAnswer
+	claims            []models.Claim
+	labels            []models.Label
+	goldResults       []models.GoldResult
+	revisions         []models.Revision
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Ping always succeeds, there is nothing to reach.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Seed is the JSON layout accepted by LoadSeed, one list per collection.
+type Seed struct {
+	Users             []models.User           `json:"users"`
+	Auths             []models.Auth           `json:"auths"`
+	Projects          []models.Project        `json:"projects"`
+	Articles          []models.Article        `json:"articles"`
+	SentiArticles     []models.SentiArticle   `json:"sentiArticles"`
+	MRCTasks          []models.MRCTask        `json:"mrcTasks"`
+	MRCAnswers        []models.MRCAnswer      `json:"mrcAnswers"`
+	MRCValidations    []models.MRCValidation  `json:"mrcValidations"`
+	MRCDecisions      []models.MRCDecision    `json:"mrcDecisions"`
+	SentiTasks        []models.SentiTask      `json:"sentiTasks"`
+	SentiAspects      []models.SentiAspect    `json:"sentiAspects"`
+	SentiSentiments   []models.SentiSentiment `json:"sentiSentiments"`
+	SentiFinalAnswers []models.SentiAnswer    `json:"sentiFinalAnswers"`
+	Labels            []models.Label          `json:"labels"`
+}
+
+// LoadSeed appends the documents of a Seed JSON file to the store.
+func (s *MemoryStore) LoadSeed(r io.Reader) error {
+	var seed Seed
+	err := json.NewDecoder(r).Decode(&seed)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, seed.Users...)
+	s.auths = append(s.auths, seed.Auths...)
+	s.projects = append(s.projects, seed.Projects...)
+	s.articles = append(s.articles, seed.Articles...)
+	s.sentiArticles = append(s.sentiArticles, seed.SentiArticles...)
+	s.mrcTasks = append(s.mrcTasks, seed.MRCTasks...)
+	for _, answer := range seed.MRCAnswers {
+		if answer.Id.IsZero() {
+			answer.Id = primitive.NewObjectID()
+		}
+		s.mrcAnswers = append(s.mrcAnswers, answer)
+	}
+	for _, validation := range seed.MRCValidations {
+		if validation.Id.IsZero() {
+			validation.Id = primitive.NewObjectID()
+		}
+		s.mrcValidations = append(s.mrcValidations, validation)
+	}
+	s.mrcDecisions = append(s.mrcDecisions, seed.MRCDecisions...)
+	s.sentiTasks = append(s.sentiTasks, seed.SentiTasks...)
+	s.sentiAspects = append(s.sentiAspects, seed.SentiAspects...)
+	s.sentiSentiments = append(s.sentiSentiments, seed.SentiSentiments...)
+	s.sentiFinalAnswers = append(s.sentiFinalAnswers, seed.SentiFinalAnswers...)
+	for _, label := range seed.Labels {
+		if label.Id.IsZero() {
+			label.Id = primitive.NewObjectID()
+		}
+		s.labels = append(s.labels, label)
+	}
+	log.Println("Seeded memory store:", len(seed.Users), "users,", len(seed.Projects), "projects,", len(seed.SentiTasks)+len(seed.MRCTasks), "tasks")
+	return nil
+}
+
+// ================================= users & auths =================================
+func (s *MemoryStore) GetUser(user models.User) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// same branches as models.User.ToQueryBson
+	for _, u := range s.users {
+		if (user.UserId != "" && u.UserId == user.UserId) || (user.UserId == "" && u.Email == user.Email) {
+			result := u
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetUsers() ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.User{}, s.users...), nil
+}
+
+func (s *MemoryStore) GetUsersByIds(userIds []string) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// {"userId": {"$in": userIds}}
+	ids := make(map[string]bool, len(userIds))
+	for _, id := range userIds {
+		ids[id] = true
+	}
+	var result = []models.User{}
+	for _, u := range s.users {
+		if ids[u.UserId] {
+			result = append(result, u)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SaveUser(user models.User) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user)
+	return primitive.NewObjectID(), nil
+}
+
+func (s *MemoryStore) GetAuthByProjectId(auth models.Auth) ([]models.Auth, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result = []models.Auth{}
+	for _, a := range s.auths {
+		if a.ProjectId == auth.ProjectId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) GetUserProjectAuths(userId string, projectId primitive.ObjectID) ([]models.Auth, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result = []models.Auth{}
+	for _, a := range s.auths {
+		if a.UserId == userId && a.ProjectId == projectId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) GetAuthsByUserId(userId string) (models.Auths, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result = models.Auths{}
+	for _, a := range s.auths {
+		if a.UserId == userId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SaveAuth(auth models.Auth) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths = append(s.auths, auth)
+	return primitive.NewObjectID(), nil
+}
+
+func (s *MemoryStore) SetAnnotatorPaused(projectId primitive.ObjectID, userId string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := false
+	for i := range s.auths {
+		a := &s.auths[i]
+		if a.ProjectId == projectId && a.UserId == userId && a.Role == models.RoleAnnotator {
+			a.Paused = paused
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MemoryStore) SaveAuths(auths []models.Auth) error {
+	if len(auths) == 0 {
+		return errEmptyInsert
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths = append(s.auths, auths...)
+	return nil
+}
+
+// ================================= projects & articles =================================
+func (s *MemoryStore) GetProjectByProjectId(project models.Project) (*models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, p := range s.projects {
+		if (!project.ProjectId.IsZero() && p.ProjectId == project.ProjectId) || (project.ProjectId.IsZero() && p.ProjectName == project.ProjectName) {
+			result := p
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetProjectsByIds(projectIds []primitive.ObjectID) ([]models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var projects = []models.Project{}
+	for _, p := range s.projects {
+		for _, id := range projectIds {
+			if p.ProjectId == id {
+				projects = append(projects, p)
+				break
+			}
+		}
+	}
+	return projects, nil
+}
+
+// findProject must be called with the lock held.
+func (s *MemoryStore) findProject(projectId primitive.ObjectID) *models.Project {
+	for i := range s.projects {
+		if s.projects[i].ProjectId == projectId {
+			return &s.projects[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateProject(project models.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.findProject(project.ProjectId)
+	if p == nil {
+		return ErrNotFound
+	}
+	p.ProjectName = project.ProjectName
+	p.Rule = project.Rule
+	p.ProjectType = project.ProjectType
+	p.MatchRule = project.MatchRule
+	p.Gold = project.Gold
+	changed := p.RequiredAnnotators() != project.RequiredAnnotators()
+	p.Redundancy = project.Redundancy
+	if changed {
+		s.applyRedundancy(p.ProjectId, p.RequiredAnnotators())
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetRedundancy(projectId primitive.ObjectID, redundancy int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.findProject(projectId)
+	if p == nil {
+		return ErrNotFound
+	}
+	p.Redundancy = redundancy
+	s.applyRedundancy(projectId, p.RequiredAnnotators())
+	return nil
+}
+
+// applyRedundancy must be called with the write lock held, see
+// MongoStore.applyRedundancy.
+func (s *MemoryStore) applyRedundancy(projectId primitive.ObjectID, required int) {
+	for i := range s.sentiTasks {
+		t := &s.sentiTasks[i]
+		if t.ProjectId != projectId || t.Gold {
+			continue
+		}
+		if !t.IsValidate && t.Annotators != nil {
+			t.IsAnswered = len(t.Annotators) >= required
+		}
+	}
+	s.refreshCompletion(projectId)
+}
+
+func (s *MemoryStore) SetProjectArchived(projectId primitive.ObjectID, archived bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.findProject(projectId)
+	if p == nil {
+		return ErrNotFound
+	}
+	p.Archived = archived
+	return nil
+}
+
+func (s *MemoryStore) CountProjectArticles(projectId primitive.ObjectID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var total int64
+	for _, a := range s.articles {
+		if a.ProjectId == projectId {
+			total++
+		}
+	}
+	for _, a := range s.sentiArticles {
+		if a.ProjectId == projectId {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// DeleteProject follows the same cascade as MongoStore.DeleteProject.
+func (s *MemoryStore) DeleteProject(projectId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findProject(projectId) == nil {
+		return ErrNotFound
+	}
+	articleHexes := make(map[string]bool)
+	for _, a := range s.articles {
+		if a.ProjectId == projectId {
+			articleHexes[a.ArticleId.Hex()] = true
+		}
+	}
+	answerIds := make(map[primitive.ObjectID]bool)
+	for _, a := range s.mrcAnswers {
+		if a.ProjectId == projectId || articleHexes[a.ArticleId] {
+			answerIds[a.Id] = true
+		}
+	}
+	sentiTaskIds := make(map[primitive.ObjectID]bool)
+	for _, t := range s.sentiTasks {
+		if t.ProjectId == projectId {
+			sentiTaskIds[t.TaskId] = true
+		}
+	}
+
+	var projects []models.Project
+	for _, p := range s.projects {
+		if p.ProjectId != projectId {
+			projects = append(projects, p)
+		}
+	}
+	s.projects = projects
+	var auths []models.Auth
+	for _, a := range s.auths {
+		if a.ProjectId != projectId {
+			auths = append(auths, a)
+		}
+	}
+	s.auths = auths
+	var articles []models.Article
+	for _, a := range s.articles {
+		if a.ProjectId != projectId {
+			articles = append(articles, a)
+		}
+	}
+	s.articles = articles
+	var mrcTasks []models.MRCTask
+	for _, t := range s.mrcTasks {
+		if t.ProjectId != projectId && !articleHexes[t.ArticleId] {
+			mrcTasks = append(mrcTasks, t)
+		}
+	}
+	s.mrcTasks = mrcTasks
+	var mrcAnswers []models.MRCAnswer
+	for _, a := range s.mrcAnswers {
+		if !answerIds[a.Id] {
+			mrcAnswers = append(mrcAnswers, a)
+		}
+	}
+	s.mrcAnswers = mrcAnswers
+	var mrcValidations []models.MRCValidation
+	for _, v := range s.mrcValidations {
+		if v.ProjectId != projectId && !answerIds[v.OriginalId] {
+			mrcValidations = append(mrcValidations, v)
+		}
+	}
+	s.mrcValidations = mrcValidations
+	var mrcDecisions []models.MRCDecision
+	for _, d := range s.mrcDecisions {
+		if !answerIds[d.OriginalId] {
+			mrcDecisions = append(mrcDecisions, d)
+		}
+	}
+	s.mrcDecisions = mrcDecisions
+	var sentiArticles []models.SentiArticle
+	for _, a := range s.sentiArticles {
+		if a.ProjectId != projectId {
+			sentiArticles = append(sentiArticles, a)
+		}
+	}
+	s.sentiArticles = sentiArticles
+	var sentiTasks []models.SentiTask
+	for _, t := range s.sentiTasks {
+		if t.ProjectId != projectId {
+			sentiTasks = append(sentiTasks, t)
+		}
+	}
+	s.sentiTasks = sentiTasks
+	var sentiAspects []models.SentiAspect
+	for _, a := range s.sentiAspects {
+		if !sentiTaskIds[a.TaskId] {
+			sentiAspects = append(sentiAspects, a)
+		}
+	}
+	s.sentiAspects = sentiAspects
+	var sentiSentiments []models.SentiSentiment
+	for _, a := range s.sentiSentiments {
+		if !sentiTaskIds[a.TaskId] {
+			sentiSentiments = append(sentiSentiments, a)
+		}
+	}
+	s.sentiSentiments = sentiSentiments
+	var sentiFinalAnswers []models.SentiAnswer
+	for _, a := range s.sentiFinalAnswers {
+		if a.ProjectId != projectId {
+			sentiFinalAnswers = append(sentiFinalAnswers, a)
+		}
+	}
+	s.sentiFinalAnswers = sentiFinalAnswers
+	var claims []models.Claim
+	for _, c := range s.claims {
+		if c.ProjectId != projectId {
+			claims = append(claims, c)
+		}
+	}
+	s.claims = claims
+	var labels []models.Label
+	for _, l := range s.labels {
+		if l.ProjectId != projectId {
+			labels = append(labels, l)
+		}
+	}
+	s.labels = labels
+	var goldResults []models.GoldResult
+	for _, r := range s.goldResults {
+		if r.ProjectId != projectId {
+			goldResults = append(goldResults, r)
+		}
+	}
+	s.goldResults = goldResults
+	var revisions []models.Revision
+	for _, r := range s.revisions {
+		if r.ProjectId != projectId {
+			revisions = append(revisions, r)
+		}
+	}
+	s.revisions = revisions
+	return nil
+}
+
+func (s *MemoryStore) GetProjectCount() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.projects)), nil
+}
+
+func (s *MemoryStore) SaveProject(project models.Project) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if project.ProjectId.IsZero() {
+		project.ProjectId = primitive.NewObjectID()
+	}
+	s.projects = append(s.projects, project)
+	return project.ProjectId, nil
+}
+
+// CreateProject holds the write lock for the whole project, so readers
+// never see it half created.
+func (s *MemoryStore) CreateProject(project models.Project, content ProjectContent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.projects {
+		if p.ProjectId == project.ProjectId {
+			return fmt.Errorf("project %s already exists", project.ProjectId.Hex())
+		}
+	}
+	s.projects = append(s.projects, project)
+	s.addContent(content)
+	return nil
+}
+
+func (s *MemoryStore) AddProjectContent(content ProjectContent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addContent(content)
+	return nil
+}
+
+// addContent must be called with the write lock held.
+func (s *MemoryStore) addContent(content ProjectContent) {
+	s.auths = append(s.auths, content.Auths...)
+	s.articles = append(s.articles, content.Articles...)
+	s.mrcTasks = append(s.mrcTasks, content.MRCTasks...)
+	s.mrcAnswers = append(s.mrcAnswers, content.MRCAnswers...)
+	s.sentiArticles = append(s.sentiArticles, content.SentiArticles...)
+	s.sentiTasks = append(s.sentiTasks, content.SentiTasks...)
+	s.sentiAspects = append(s.sentiAspects, content.SentiAspects...)
+	s.sentiSentiments = append(s.sentiSentiments, content.SentiSentiments...)
+	for projectId, articleIds := range content.articleIds() {
+		s.refreshCompletion(projectId, articleIds...)
+	}
+}
+
+func (s *MemoryStore) GetArticlesByProjectId(projectId primitive.ObjectID) ([]models.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var articles = []models.Article{}
+	for _, a := range s.articles {
+		if a.ProjectId == projectId {
+			articles = append(articles, a)
+		}
+	}
+	return articles, nil
+}
+
+func (s *MemoryStore) GetArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.articles {
+		if a.ArticleId == articleId {
+			result := a
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SaveArticles(articles []models.Article) error {
+	if len(articles) == 0 {
+		return errEmptyInsert
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.articles = append(s.articles, articles...)
+	return nil
+}
+
+// sentiArticleToArticle decodes a SentiArticles document the way Mongo does
+// when it is read into a models.Article.
+func sentiArticleToArticle(a models.SentiArticle) models.Article {
+	return models.Article{
+		ArticleId:    a.ArticleId,
+		ProjectId:    a.ProjectId,
+		ArticleTitle: a.ArticleTitle,
+		TotalTasks:   a.TotalTasks,
+		IsAnswered:   a.IsAnswered,
+		AnsweredAt:   a.AnsweredAt,
+	}
+}
+
+func (s *MemoryStore) GetSentiArticles(query models.Project) ([]models.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var articles = []models.Article{}
+	for _, a := range s.sentiArticles {
+		if a.ProjectId == query.ProjectId && !a.IsAnswered {
+			articles = append(articles, sentiArticleToArticle(a))
+		}
+	}
+	return articles, nil
+}
+
+func (s *MemoryStore) GetSentiArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.sentiArticles {
+		if a.ArticleId == articleId {
+			result := sentiArticleToArticle(a)
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ================================= MRC =================================
+func (s *MemoryStore) GetTasksByArticleId(articleId string) ([]models.MRCTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var tasks []models.MRCTask
+	for _, t := range s.mrcTasks {
+		if t.ArticleId == articleId {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) GetTaskById(taskModel models.MRCTask) (*models.MRCTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// same branches as models.MRCTask.ToQueryBson
+	for _, t := range s.mrcTasks {
+		if t.ArticleId != taskModel.ArticleId {
+			continue
+		}
+		if taskModel.TaskId != "" && (t.TaskId != taskModel.TaskId || t.TaskType != "MRC") {
+			continue
+		}
+		result := t
+		return &result, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SaveTasks(tasks []models.MRCTask) error {
+	if len(tasks) == 0 {
+		return errEmptyInsert
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mrcTasks = append(s.mrcTasks, tasks...)
+	return nil
+}
+
+// matchMRCAnswer evaluates models.MRCAnswer.ToQueryBson against an answer.
+func matchMRCAnswer(query models.MRCAnswer, answer models.MRCAnswer) bool {
+	if query.TaskType == "MRCValidation" {
+		// {"projectId": projectId, "userId": {"$ne": userId}, "status": "unverified", "taskType": "MRC"}
+		return answer.ProjectId == query.ProjectId && answer.UserId != query.UserId && answer.Status == "unverified" && answer.TaskType == "MRC"
+	}
+	if !query.Id.IsZero() {
+		return answer.Id == query.Id
+	}
+	return answer.ArticleId == query.ArticleId && answer.TaskId == query.TaskId && answer.TaskType == query.TaskType
+}
+
+func (s *MemoryStore) GetAnswers(query models.MRCAnswer) ([]*models.MRCAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var answers []*models.MRCAnswer
+	for _, a := range s.mrcAnswers {
+		if matchMRCAnswer(query, a) {
+			result := a
+			answers = append(answers, &result)
+		}
+	}
+	return answers, nil
+}
+
+func (s *MemoryStore) FindAnswerById(id primitive.ObjectID) (*models.MRCAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.mrcAnswers {
+		if a.Id == id {
+			result := a
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetRandomValidationQuestion(question models.MRCAnswer, exclude []primitive.ObjectID) (*models.MRCAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	question.TaskType = "MRCValidation"
+	for _, a := range s.mrcAnswers {
+		if matchMRCAnswer(question, a) && !containsId(exclude, a.Id) {
+			result := a
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) AddMRCAnnotator(task models.MRCTask, userId string, required int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addMRCAnnotator(task, userId, required)
+	return nil
+}
+
+// addMRCAnnotator must be called with the write lock held.
+func (s *MemoryStore) addMRCAnnotator(task models.MRCTask, userId string, required int) {
+	for i := range s.mrcTasks {
+		t := &s.mrcTasks[i]
+		if t.ArticleId != task.ArticleId || t.TaskId != task.TaskId || t.TaskType != "MRC" {
+			continue
+		}
+		if containsString(t.Annotators, userId) {
+			return
+		}
+		t.Annotators = append(t.Annotators, userId)
+		t.Answered = len(t.Annotators)
+		if t.Answered >= required {
+			// tasks written before projectId existed only know their article
+			for _, a := range s.articles {
+				if a.ArticleId.Hex() == t.ArticleId {
+					s.refreshCompletion(a.ProjectId, a.ArticleId)
+					break
+				}
+			}
+		}
+		return
+	}
+}
+
+func (s *MemoryStore) GetOpenMRCTask(projectId primitive.ObjectID, userId string, required int) (*models.MRCTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.mrcTasks {
+		if t.ProjectId == projectId && t.TaskType == "MRC" && t.Answered < required && !containsString(t.Annotators, userId) {
+			result := t
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SaveAnswer(answer models.MRCAnswer) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveAnswer(answer)
+}
+
+func (s *MemoryStore) AnswerMRCTask(project models.Project, task models.MRCTask, answer models.MRCAnswer, gold *models.GoldResult) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gold != nil {
+		err := s.recordGoldResult(project, *gold)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		return s.saveAnswer(answer)
+	}
+	id, err := s.saveAnswer(answer)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	s.addMRCAnnotator(task, answer.UserId, project.RequiredAnnotators())
+	return id, nil
+}
+
+// saveAnswer must be called with the write lock held.
+func (s *MemoryStore) saveAnswer(answer models.MRCAnswer) (primitive.ObjectID, error) {
+	if answer.Id.IsZero() {
+		answer.Id = primitive.NewObjectID()
+	}
+	s.mrcAnswers = append(s.mrcAnswers, answer)
+	_, err := s.revise(models.Revision{Kind: models.RevisionMRCAnswer, TargetId: answer.Id, Action: models.RevisionAnswered, Author: answer.UserId})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return answer.Id, nil
+}
+
+func (s *MemoryStore) UpdateAnswer(answer models.MRCValidation, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.mrcAnswers {
+		if s.mrcAnswers[i].Id == answer.OriginalId {
+			s.mrcAnswers[i].Status = answer.Status
+			_, err := s.revise(models.Revision{Kind: models.RevisionMRCAnswer, TargetId: answer.OriginalId, Action: models.RevisionDecided, Author: userId})
+			return err
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) GetAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var answers = []models.MRCAnswer{}
+	for _, a := range s.mrcAnswers {
+		if a.TaskType != "MRC" {
+			continue
+		}
+		for _, id := range articleIds {
+			if a.ArticleId == id {
+				answers = append(answers, a)
+				break
+			}
+		}
+	}
+	return answers, nil
+}
+
+func (s *MemoryStore) GetValidationAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var answers = []models.MRCAnswer{}
+	for _, a := range s.mrcAnswers {
+		if a.TaskType != "Validation" {
+			continue
+		}
+		for _, id := range articleIds {
+			if a.ArticleId == id {
+				answers = append(answers, a)
+				break
+			}
+		}
+	}
+	return answers, nil
+}
+
+func (s *MemoryStore) GetRandomDecisionInfo(projectId primitive.ObjectID, userId string, exclude []primitive.ObjectID) (*models.MRCValidation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// {"projectId": projectId, "status": "pending", "validationUserId": {"$ne": userId}, "labelUserId": {"$ne": userId}, "_id": {"$nin": exclude}}
+	for _, v := range s.mrcValidations {
+		if v.ProjectId == projectId && v.Status == "pending" && v.ValidationUserId != userId && v.LabelUserId != userId && !containsId(exclude, v.Id) {
+			result := v
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SaveValidationStatus(validationAnswer models.MRCValidation) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if validationAnswer.Id.IsZero() {
+		validationAnswer.Id = primitive.NewObjectID()
+	}
+	s.mrcValidations = append(s.mrcValidations, validationAnswer)
+	return validationAnswer.Id, nil
+}
+
+func (s *MemoryStore) SaveValidation(answer models.MRCAnswer, validation models.MRCValidation) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var original *models.MRCAnswer
+	for i := range s.mrcAnswers {
+		if s.mrcAnswers[i].Id == validation.OriginalId {
+			original = &s.mrcAnswers[i]
+			break
+		}
+	}
+	if original == nil {
+		return primitive.NilObjectID, ErrNotFound
+	}
+	if original.Status != "unverified" {
+		return primitive.NilObjectID, ErrDecided
+	}
+	original.Status = validation.Status
+	if answer.Id.IsZero() {
+		answer.Id = primitive.NewObjectID()
+	}
+	if validation.Id.IsZero() {
+		validation.Id = primitive.NewObjectID()
+	}
+	validation.ValidationId = answer.Id
+	s.mrcAnswers = append(s.mrcAnswers, answer)
+	s.mrcValidations = append(s.mrcValidations, validation)
+	_, err := s.revise(models.Revision{Kind: models.RevisionMRCAnswer, TargetId: validation.OriginalId, Action: models.RevisionValidated, Author: validation.ValidationUserId})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return validation.Id, nil
+}
+
+func (s *MemoryStore) UpdateValidationStatus(status models.MRCValidation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.mrcValidations {
+		if s.mrcValidations[i].Id == status.OriginalId {
+			s.mrcValidations[i].Status = status.Status
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetValidationsByProjectId(projectId primitive.ObjectID) ([]models.MRCValidation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var validations = []models.MRCValidation{}
+	for _, v := range s.mrcValidations {
+		if v.ProjectId == projectId {
+			validations = append(validations, v)
+		}
+	}
+	return validations, nil
+}
+
+func (s *MemoryStore) GetDecisionsByOriginalIds(originalIds []primitive.ObjectID) ([]models.MRCDecision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var decisions = []models.MRCDecision{}
+	for _, d := range s.mrcDecisions {
+		for _, id := range originalIds {
+			if d.OriginalId == id {
+				decisions = append(decisions, d)
+				break
+			}
+		}
+	}
+	return decisions, nil
+}
+
+func (s *MemoryStore) SaveDecision(decisionResult models.MRCDecision) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if decisionResult.Id.IsZero() {
+		decisionResult.Id = primitive.NewObjectID()
+	}
+	s.mrcDecisions = append(s.mrcDecisions, decisionResult)
+	return decisionResult.Id, nil
+}
+
+func (s *MemoryStore) Decide(projectId primitive.ObjectID, decision models.MRCDecision, status string) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var validation *models.MRCValidation
+	for i := range s.mrcValidations {
+		v := &s.mrcValidations[i]
+		if v.Id == decision.ValidationStatusId && v.ProjectId == projectId && v.OriginalId == decision.OriginalId {
+			validation = v
+			break
+		}
+	}
+	if validation == nil || (!decision.ValidationId.IsZero() && decision.ValidationId != validation.ValidationId) {
+		return primitive.NilObjectID, ErrNotFound
+	}
+	if decision.UserId == validation.LabelUserId || decision.UserId == validation.ValidationUserId {
+		return primitive.NilObjectID, ErrOwnWork
+	}
+	if validation.Status != "pending" {
+		return primitive.NilObjectID, ErrDecided
+	}
+	var original *models.MRCAnswer
+	for i := range s.mrcAnswers {
+		if s.mrcAnswers[i].Id == decision.OriginalId {
+			original = &s.mrcAnswers[i]
+			break
+		}
+	}
+	if original == nil {
+		return primitive.NilObjectID, ErrNotFound
+	}
+	original.Status = status
+	validation.Status = status
+	decision.ValidationId = validation.ValidationId
+	if decision.Id.IsZero() {
+		decision.Id = primitive.NewObjectID()
+	}
+	s.mrcDecisions = append(s.mrcDecisions, decision)
+	_, err := s.revise(models.Revision{Kind: models.RevisionMRCAnswer, TargetId: decision.OriginalId, Action: models.RevisionDecided, Author: decision.UserId})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return decision.Id, nil
+}
+
+// ================================= sentiment =================================
+func (s *MemoryStore) GetSentiTasksByArticleId(articleId primitive.ObjectID, isAnswered bool) ([]models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var tasks []models.SentiTask
+	for _, t := range s.sentiTasks {
+		if t.ArticleId == articleId && t.IsAnswered == isAnswered {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) GetSentiTaskById(taskId primitive.ObjectID, taskType string) (*models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.sentiTasks {
+		if t.TaskId == taskId && t.TaskType == taskType {
+			result := t
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) FindSentiTaskById(taskId primitive.ObjectID) (*models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.sentiTasks {
+		if t.TaskId == taskId {
+			result := t
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetSentiTasksByProjectId(projectId primitive.ObjectID) ([]models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var tasks = []models.SentiTask{}
+	for _, t := range s.sentiTasks {
+		if t.ProjectId == projectId {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) GetRandomSentiTask(query models.SentiTask, userId string, exclude []primitive.ObjectID) (*models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.sentiTasks {
+		if t.TaskType == query.TaskType && t.ProjectId == query.ProjectId && t.IsAnswered && !t.IsValidate && !containsString(t.Annotators, userId) && !containsId(exclude, t.TaskId) {
+			result := t
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetOpenSentiTask(projectId primitive.ObjectID, userId string) (*models.SentiTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.sentiTasks {
+		if t.ProjectId == projectId && !t.IsAnswered && !t.Gold && !containsString(t.Annotators, userId) {
+			result := t
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) CheckIsAnswered(query models.SentiTask) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	article := s.checkSentiArticle(query.ArticleId)
+	if article == nil {
+		return false, ErrNotFound
+	}
+	return article.IsAnswered, nil
+}
+
+func (s *MemoryStore) CheckIsValidated(query models.SentiTask) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	article := s.checkSentiArticle(query.ArticleId)
+	if article == nil {
+		return false, ErrNotFound
+	}
+	return article.IsValidated, nil
+}
+
+// checkSentiArticle must be called with the write lock held.
+func (s *MemoryStore) checkSentiArticle(articleId primitive.ObjectID) *models.SentiArticle {
+	for i := range s.sentiArticles {
+		if s.sentiArticles[i].ArticleId == articleId {
+			s.refreshCompletion(s.sentiArticles[i].ProjectId, articleId)
+			return &s.sentiArticles[i]
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DiscardSentiAnswer(query models.SentiTask, userId string, reason string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var task *models.SentiTask
+	for i := range s.sentiTasks {
+		if s.sentiTasks[i].TaskId == query.TaskId {
+			task = &s.sentiTasks[i]
+			break
+		}
+	}
+	if task == nil {
+		return false, ErrNotFound
+	}
+	task.IsAnswered = false
+	task.IsValidate = false
+	task.Annotators = []string{}
+	s.refreshCompletion(task.ProjectId, task.ArticleId)
+
+	//刪除掉被deny的錯誤標注內容
+	// they live on in the revisions before this one
+	s.removeSentiAnnotations(query.TaskId)
+	_, err := s.revise(models.Revision{Kind: models.RevisionSentiTask, TargetId: query.TaskId, Action: models.RevisionDiscarded, Author: userId, Reason: reason})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeSentiAnnotations must be called with the write lock held.
+func (s *MemoryStore) removeSentiAnnotations(taskId primitive.ObjectID) {
+	var aspects []models.SentiAspect
+	for _, a := range s.sentiAspects {
+		if a.TaskId != taskId {
+			aspects = append(aspects, a)
+		}
+	}
+	s.sentiAspects = aspects
+	var sentiments []models.SentiSentiment
+	for _, a := range s.sentiSentiments {
+		if a.TaskId != taskId {
+			sentiments = append(sentiments, a)
+		}
+	}
+	s.sentiSentiments = sentiments
+}
+
+func (s *MemoryStore) GetAspectByTaskId(query models.SentiAspect) ([]*models.SentiAspect, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var aspects []*models.SentiAspect
+	for _, a := range s.sentiAspects {
+		if a.TaskId == query.TaskId {
+			result := a
+			aspects = append(aspects, &result)
+		}
+	}
+	return aspects, nil
+}
+
+func (s *MemoryStore) GetAspectsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiAspect, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var aspects = []models.SentiAspect{}
+	for _, a := range s.sentiAspects {
+		if containsId(taskIds, a.TaskId) {
+			aspects = append(aspects, a)
+		}
+	}
+	return aspects, nil
+}
+
+func (s *MemoryStore) GetSentimentsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiSentiment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sentiments = []models.SentiSentiment{}
+	for _, a := range s.sentiSentiments {
+		if containsId(taskIds, a.TaskId) {
+			sentiments = append(sentiments, a)
+		}
+	}
+	return sentiments, nil
+}
+
+func (s *MemoryStore) GetSentiAnswer(query models.SentiSentiment) ([]*models.SentiSentiment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sentiList []*models.SentiSentiment
+	for _, a := range s.sentiSentiments {
+		if a.TaskId == query.TaskId && (query.AspectId == "" || a.AspectId == query.AspectId) {
+			result := a
+			sentiList = append(sentiList, &result)
+		}
+	}
+	return sentiList, nil
+}
+
+func (s *MemoryStore) SaveSentiAnswer(answer models.SentiAnswer, required int) error {
+	return s.AnswerSentiTask(models.Project{}, answer, required, nil)
+}
+
+func (s *MemoryStore) AnswerSentiTask(project models.Project, answer models.SentiAnswer, required int, gold *models.GoldResult) error {
+	if len(answer.Aspect) == 0 || len(answer.Sentiment) == 0 {
+		return errEmptyInsert
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	taskId, userId := answer.Aspect[0].TaskId, answer.Aspect[0].UserId
+	var task *models.SentiTask
+	for i := range s.sentiTasks {
+		if s.sentiTasks[i].TaskId == taskId {
+			task = &s.sentiTasks[i]
+			break
+		}
+	}
+	if task == nil {
+		return ErrNotFound
+	}
+	if containsString(task.Annotators, userId) {
+		return ErrAnnotated
+	}
+	if required > 0 && task.IsAnswered {
+		return ErrTaskAnswered
+	}
+	task.Annotators = append(task.Annotators, userId)
+	if required > 0 {
+		task.IsAnswered = len(task.Annotators) >= required
+	}
+	s.sentiAspects = append(s.sentiAspects, answer.Aspect...)
+	s.sentiSentiments = append(s.sentiSentiments, answer.Sentiment...)
+	s.refreshCompletion(task.ProjectId, task.ArticleId)
+	_, err := s.revise(models.Revision{Kind: models.RevisionSentiTask, TargetId: taskId, Action: models.RevisionAnswered, Author: userId})
+	if err != nil || gold == nil {
+		return err
+	}
+	err = s.recordGoldResult(project, *gold)
+	if err == ErrAnnotated {
+		// answered before a discard, the first result stays
+		return nil
+	}
+	return err
+}
+
+func (s *MemoryStore) SaveFinalAnswer(answer models.SentiAnswer) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.sentiTasks {
+		if s.sentiTasks[i].TaskId == answer.Task.TaskId {
+			if s.sentiTasks[i].IsValidate {
+				return primitive.NilObjectID, ErrDecided
+			}
+			s.sentiFinalAnswers = append(s.sentiFinalAnswers, answer)
+			s.sentiTasks[i].IsValidate = true
+			s.refreshCompletion(s.sentiTasks[i].ProjectId, s.sentiTasks[i].ArticleId)
+			_, err := s.revise(models.Revision{Kind: models.RevisionSentiTask, TargetId: answer.Task.TaskId, Action: models.RevisionValidated, Author: answer.UserId})
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			return primitive.NewObjectID(), nil
+		}
+	}
+	return primitive.NilObjectID, ErrNotFound
+}
+
+func (s *MemoryStore) GetFinalAnswersByProjectId(projectId primitive.ObjectID) ([]models.SentiAnswer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var answers = []models.SentiAnswer{}
+	for _, a := range s.sentiFinalAnswers {
+		if a.ProjectId == projectId {
+			answers = append(answers, a)
+		}
+	}
+	return answers, nil
+}
+
+// ================================= gold =================================
+
+func (s *MemoryStore) SaveLabel(label models.Label) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if taskId, ok := label.SentiTaskId(); ok {
+		var task *models.SentiTask
+		for i := range s.sentiTasks {
+			if s.sentiTasks[i].TaskId == taskId && s.sentiTasks[i].ProjectId == label.ProjectId {
+				task = &s.sentiTasks[i]
+				break
+			}
+		}
+		if task == nil {
+			return primitive.NilObjectID, ErrNotFound
+		}
+		task.Gold = true
+		s.refreshCompletion(task.ProjectId, task.ArticleId)
+	}
+	for i, l := range s.labels {
+		if l.SameItem(label) {
+			label.Id = l.Id
+			s.labels[i] = label
+			return label.Id, nil
+		}
+	}
+	label.Id = primitive.NewObjectID()
+	s.labels = append(s.labels, label)
+	return label.Id, nil
+}
+
+func (s *MemoryStore) DeleteLabel(projectId primitive.ObjectID, labelId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, l := range s.labels {
+		if l.Id != labelId || l.ProjectId != projectId {
+			continue
+		}
+		s.labels = append(s.labels[:i], s.labels[i+1:]...)
+		if taskId, ok := l.SentiTaskId(); ok {
+			for j := range s.sentiTasks {
+				t := &s.sentiTasks[j]
+				if t.TaskId != taskId {
+					continue
+				}
+				t.Gold = false
+				// the gold answers count as annotations, which may be all
+				// the task needs
+				if p := s.findProject(t.ProjectId); p != nil && !t.IsValidate && t.Annotators != nil {
+					t.IsAnswered = len(t.Annotators) >= p.RequiredAnnotators()
+				}
+				s.refreshCompletion(t.ProjectId, t.ArticleId)
+			}
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) GetLabels(projectId primitive.ObjectID) ([]models.Label, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var labels = []models.Label{}
+	for _, l := range s.labels {
+		if l.ProjectId == projectId {
+			labels = append(labels, l)
+		}
+	}
+	return labels, nil
+}
+
+func (s *MemoryStore) SaveGoldResult(result models.GoldResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recordGoldResult(models.Project{}, result)
+}
+
+// recordGoldResult must be called with the write lock held, see
+// MongoStore.recordGoldResult.
+func (s *MemoryStore) recordGoldResult(project models.Project, result models.GoldResult) error {
+	var results []models.GoldResult
+	for _, r := range s.goldResults {
+		if r.LabelId == result.LabelId && r.UserId == result.UserId {
+			return ErrAnnotated
+		}
+		if r.ProjectId == result.ProjectId && r.UserId == result.UserId {
+			results = append(results, r)
+		}
+	}
+	s.goldResults = append(s.goldResults, result)
+	results = append(results, result)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].AnsweredAt.Before(results[j].AnsweredAt) })
+	if !belowMinAccuracy(project, results) {
+		return nil
+	}
+	for i := range s.auths {
+		a := &s.auths[i]
+		if a.ProjectId == project.ProjectId && a.UserId == result.UserId && a.Role == models.RoleAnnotator {
+			a.Paused = true
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetGoldResults(projectId primitive.ObjectID, userId string) ([]models.GoldResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var results = []models.GoldResult{}
+	for _, r := range s.goldResults {
+		if r.ProjectId == projectId && (userId == "" || r.UserId == userId) {
+			results = append(results, r)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].AnsweredAt.Before(results[j].AnsweredAt) })
+	return results, nil
+}
+
+// ================================= revisions =================================
+
+// revise must be called with the write lock held, see MongoStore.revise.
+func (s *MemoryStore) revise(revision models.Revision) (*models.Revision, error) {
+	switch revision.Kind {
+	case models.RevisionSentiTask:
+		var task *models.SentiTask
+		for i := range s.sentiTasks {
+			if s.sentiTasks[i].TaskId == revision.TargetId {
+				task = &s.sentiTasks[i]
+				break
+			}
+		}
+		if task == nil {
+			return nil, ErrNotFound
+		}
+		version := models.SentiTaskVersion{
+			Annotators: append([]string{}, task.Annotators...),
+			IsAnswered: task.IsAnswered,
+			IsValidate: task.IsValidate,
+			Aspects:    []models.SentiAspect{},
+			Sentiments: []models.SentiSentiment{},
+		}
+		for _, a := range s.sentiAspects {
+			if a.TaskId == task.TaskId {
+				version.Aspects = append(version.Aspects, a)
+			}
+		}
+		for _, a := range s.sentiSentiments {
+			if a.TaskId == task.TaskId {
+				version.Sentiments = append(version.Sentiments, a)
+			}
+		}
+		revision.ProjectId = task.ProjectId
+		revision.SentiTask = &version
+	case models.RevisionMRCAnswer:
+		var answer *models.MRCAnswer
+		for i := range s.mrcAnswers {
+			if s.mrcAnswers[i].Id == revision.TargetId {
+				answer = &s.mrcAnswers[i]
+				break
+			}
+		}
+		if answer == nil {
+			return nil, ErrNotFound
+		}
+		saved := *answer
+		revision.ProjectId = saved.ProjectId
+		if revision.ProjectId.IsZero() {
+			// answers saved before projectId existed only know their article
+			for _, a := range s.articles {
+				if a.ArticleId.Hex() == saved.ArticleId {
+					revision.ProjectId = a.ProjectId
+					break
+				}
+			}
+		}
+		revision.MRCAnswer = &saved
+	default:
+		return nil, fmt.Errorf("cannot keep revisions of %q", revision.Kind)
+	}
+
+	version := 1
+	for _, r := range s.revisions {
+		if r.TargetId == revision.TargetId {
+			version++
+		}
+	}
+	revision.Id = primitive.NewObjectID()
+	revision.Version = version
+	revision.CreatedAt = time.Now()
+	s.revisions = append(s.revisions, revision)
+	return cloneRevision(revision), nil
+}
+
+// cloneRevision copies what a caller could change in a stored revision.
+func cloneRevision(revision models.Revision) *models.Revision {
+	if task := revision.SentiTask; task != nil {
+		version := *task
+		version.Annotators = append([]string{}, task.Annotators...)
+		version.Aspects = append([]models.SentiAspect{}, task.Aspects...)
+		version.Sentiments = append([]models.SentiSentiment{}, task.Sentiments...)
+		revision.SentiTask = &version
+	}
+	if revision.MRCAnswer != nil {
+		answer := *revision.MRCAnswer
+		revision.MRCAnswer = &answer
+	}
+	return &revision
+}
+
+func (s *MemoryStore) GetRevisions(projectId primitive.ObjectID, targetId primitive.ObjectID) ([]models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var revisions = []models.Revision{}
+	for _, r := range s.revisions {
+		if r.ProjectId == projectId && (targetId.IsZero() || r.TargetId == targetId) {
+			revisions = append(revisions, *cloneRevision(r))
+		}
+	}
+	return revisions, nil
+}
+
+func (s *MemoryStore) GetRevision(projectId primitive.ObjectID, id primitive.ObjectID) (*models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.revisions {
+		if r.Id == id && r.ProjectId == projectId {
+			return cloneRevision(r), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) RestoreRevision(projectId primitive.ObjectID, id primitive.ObjectID, userId string, reason string) (*models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var revision *models.Revision
+	for _, r := range s.revisions {
+		if r.Id == id && r.ProjectId == projectId {
+			revision = cloneRevision(r)
+			break
+		}
+	}
+	if revision == nil {
+		return nil, ErrNotFound
+	}
+	switch {
+	case revision.SentiTask != nil:
+		var task *models.SentiTask
+		for i := range s.sentiTasks {
+			if s.sentiTasks[i].TaskId == revision.TargetId {
+				task = &s.sentiTasks[i]
+				break
+			}
+		}
+		if task == nil {
+			return nil, ErrNotFound
+		}
+		// as restoreSentiTask of MongoStore
+		version := revision.SentiTask
+		if task.IsValidate != version.IsValidate {
+			return nil, ErrReviewed
+		}
+		for _, r := range s.revisions {
+			if r.TargetId == task.TaskId && r.Action == models.RevisionValidated && r.Version > revision.Version {
+				return nil, ErrReviewed
+			}
+		}
+		task.Annotators = version.Annotators
+		task.IsAnswered = version.IsAnswered
+		// the annotations being replaced live on in earlier revisions
+		s.removeSentiAnnotations(task.TaskId)
+		s.sentiAspects = append(s.sentiAspects, version.Aspects...)
+		s.sentiSentiments = append(s.sentiSentiments, version.Sentiments...)
+		s.refreshCompletion(task.ProjectId, task.ArticleId)
+	case revision.MRCAnswer != nil:
+		var answer *models.MRCAnswer
+		for i := range s.mrcAnswers {
+			if s.mrcAnswers[i].Id == revision.TargetId {
+				answer = &s.mrcAnswers[i]
+				break
+			}
+		}
+		if answer == nil {
+			return nil, ErrNotFound
+		}
+		// as restoreMRCAnswer of MongoStore
+		for _, d := range s.mrcDecisions {
+			if d.OriginalId == answer.Id {
+				return nil, ErrDecided
+			}
+		}
+		if answer.Status != revision.MRCAnswer.Status {
+			return nil, ErrReviewed
+		}
+		answer.Question = revision.MRCAnswer.Question
+		answer.Answer = revision.MRCAnswer.Answer
+		answer.StartIdx = revision.MRCAnswer.StartIdx
+		answer.IsImpossible = revision.MRCAnswer.IsImpossible
+	default:
+		return nil, fmt.Errorf("revision %s holds no annotation", id.Hex())
+	}
+	return s.revise(models.Revision{
+		Kind:         revision.Kind,
+		TargetId:     revision.TargetId,
+		Action:       models.RevisionRestored,
+		Author:       userId,
+		Reason:       reason,
+		RestoredFrom: revision.Version,
+	})
+}
+
+// ================================= completion =================================
+
+// completedSince is the completion time of a document, see completedAt.
+func completedSince(done bool, at *time.Time, now time.Time) *time.Time {
+	if !done {
+		return nil
+	}
+	if at != nil {
+		return at
+	}
+	t := now
+	return &t
+}
+
+// refreshCompletion must be called with the write lock held, see
+// MongoStore.refreshCompletion.
+func (s *MemoryStore) refreshCompletion(projectId primitive.ObjectID, articleIds ...primitive.ObjectID) {
+	now := time.Now()
+	refreshed := func(articleId primitive.ObjectID) bool {
+		return len(articleIds) == 0 || containsId(articleIds, articleId)
+	}
+	for i := range s.sentiArticles {
+		a := &s.sentiArticles[i]
+		if a.ProjectId != projectId || !refreshed(a.ArticleId) {
+			continue
+		}
+		answered, validated := true, true
+		for _, t := range s.sentiTasks {
+			if t.ArticleId == a.ArticleId && !t.Gold {
+				answered = answered && t.IsAnswered
+				validated = validated && t.IsValidate
+			}
+		}
+		a.IsAnswered, a.AnsweredAt = answered, completedSince(answered, a.AnsweredAt, now)
+		a.IsValidated, a.ValidatedAt = validated, completedSince(validated, a.ValidatedAt, now)
+	}
+
+	p := s.findProject(projectId)
+	if p == nil {
+		return
+	}
+	required := p.RequiredAnnotators()
+	for i := range s.articles {
+		a := &s.articles[i]
+		if a.ProjectId != projectId || !refreshed(a.ArticleId) {
+			continue
+		}
+		tasks, answered := 0, 0
+		for _, t := range s.mrcTasks {
+			if t.ArticleId == a.ArticleId.Hex() {
+				tasks++
+				if t.Answered >= required {
+					answered++
+				}
+			}
+		}
+		a.Answered = answered
+		a.IsAnswered = answered == tasks
+		a.AnsweredAt = completedSince(a.IsAnswered, a.AnsweredAt, now)
+	}
+
+	articles, unanswered := 0, 0
+	senti, unvalidated := 0, 0
+	for _, a := range s.articles {
+		if a.ProjectId == projectId {
+			articles++
+			if !a.IsAnswered {
+				unanswered++
+			}
+		}
+	}
+	for _, a := range s.sentiArticles {
+		if a.ProjectId == projectId {
+			senti++
+			if !a.IsAnswered {
+				unanswered++
+			}
+			if !a.IsValidated {
+				unvalidated++
+			}
+		}
+	}
+	p.AnsweredAt = completedSince(articles+senti > 0 && unanswered == 0, p.AnsweredAt, now)
+	p.ValidatedAt = completedSince(senti > 0 && unvalidated == 0, p.ValidatedAt, now)
+}
+
+// ================================= progress =================================
+
+func (s *MemoryStore) GetProgress(project models.Project) (*models.Progress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b := newProgressBuilder(project)
+	now := time.Now()
+	claimed := func(kind string, itemId primitive.ObjectID) bool {
+		for _, c := range s.claims {
+			if c.Kind == kind && c.ItemId == itemId && c.Live(now) {
+				return true
+			}
+		}
+		return false
+	}
+	switch project.ProjectType {
+	case "Sentiment":
+		for _, a := range s.sentiArticles {
+			if a.ProjectId == project.ProjectId {
+				b.article(a.ArticleId.Hex(), a.ArticleTitle)
+			}
+		}
+		var taskIds []primitive.ObjectID
+		for _, t := range s.sentiTasks {
+			if t.ProjectId != project.ProjectId || t.Gold {
+				continue
+			}
+			taskIds = append(taskIds, t.TaskId)
+			state := models.ProgressUnassigned
+			switch {
+			case t.IsValidate:
+				state = models.ProgressValidated
+			case claimed(models.ClaimSentiValidation, t.TaskId):
+				state = models.ProgressClaimed
+			case t.IsAnswered:
+				state = models.ProgressAnswered
+			}
+			b.count(t.ArticleId.Hex(), state, 1)
+		}
+		type annotation struct {
+			taskId primitive.ObjectID
+			userId string
+		}
+		answeredAt := make(map[annotation]time.Time)
+		for _, a := range s.sentiAspects {
+			key := annotation{a.TaskId, a.UserId}
+			if at, ok := answeredAt[key]; containsId(taskIds, a.TaskId) && (!ok || !a.AnsweredAt.IsZero() && (at.IsZero() || a.AnsweredAt.Before(at))) {
+				answeredAt[key] = a.AnsweredAt
+			}
+		}
+		for _, at := range answeredAt {
+			if !at.IsZero() {
+				b.day(progressDay(at)).Answered++
+			}
+		}
+		for _, f := range s.sentiFinalAnswers {
+			if f.ProjectId == project.ProjectId && containsId(taskIds, f.Task.TaskId) && !f.ValidatedAt.IsZero() {
+				b.day(progressDay(f.ValidatedAt)).Validated++
+			}
+		}
+	case "MRC":
+		var articleHexes []string
+		for _, a := range s.articles {
+			if a.ProjectId == project.ProjectId {
+				articleHexes = append(articleHexes, a.ArticleId.Hex())
+				b.article(a.ArticleId.Hex(), a.ArticleTitle)
+			}
+		}
+		for _, t := range s.mrcTasks {
+			if containsString(articleHexes, t.ArticleId) && t.Answered < project.RequiredAnnotators() {
+				b.count(t.ArticleId, models.ProgressUnassigned, 1)
+			}
+		}
+		for _, a := range s.mrcAnswers {
+			if a.TaskType != "MRC" || a.Status == models.AnswerStatusGold || !containsString(articleHexes, a.ArticleId) {
+				continue
+			}
+			if !a.Id.IsZero() {
+				b.day(progressDay(a.Id.Timestamp())).Answered++
+			}
+			decided, claim := false, claimed(models.ClaimMRCValidation, a.Id)
+			for _, v := range s.mrcValidations {
+				if v.OriginalId == a.Id && !v.Id.IsZero() {
+					b.day(progressDay(v.Id.Timestamp())).Validated++
+					claim = claim || claimed(models.ClaimMRCDecision, v.Id)
+				}
+			}
+			for _, d := range s.mrcDecisions {
+				if d.OriginalId == a.Id {
+					if !d.Id.IsZero() {
+						b.day(progressDay(d.Id.Timestamp())).Decided++
+					}
+					decided = true
+				}
+			}
+			// any status but these was set by an adjudicator
+			state := models.ProgressFinal
+			switch {
+			case decided:
+			case claim:
+				state = models.ProgressClaimed
+			case a.Status == "unverified":
+				state = models.ProgressAnswered
+			case a.Status == "verified":
+				state = models.ProgressValidated
+			case a.Status == "pending":
+				state = models.ProgressPendingDecision
+			}
+			b.count(a.ArticleId, state, 1)
+		}
+	default:
+		return nil, fmt.Errorf("cannot report the progress of %q projects", project.ProjectType)
+	}
+	return b.build(), nil
+}
+
+// ================================= claims =================================
+
+func containsId(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// claimIndex must be called with the lock held.
+func (s *MemoryStore) claimIndex(kind string, itemId primitive.ObjectID) int {
+	for i, c := range s.claims {
+		if c.Kind == kind && c.ItemId == itemId {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) TakeClaim(claim models.Claim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.claimIndex(claim.Kind, claim.ItemId)
+	if i < 0 {
+		claim.Id = primitive.NewObjectID()
+		s.claims = append(s.claims, claim)
+		return nil
+	}
+	held := s.claims[i]
+	if held.UserId != claim.UserId && held.Live(claim.ClaimedAt) {
+		return ErrClaimed
+	}
+	claim.Id = held.Id
+	s.claims[i] = claim
+	return nil
+}
+
+func (s *MemoryStore) RenewClaim(kind string, itemId primitive.ObjectID, userId string, expiresAt time.Time) (*models.Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.claimIndex(kind, itemId)
+	if i < 0 || s.claims[i].UserId != userId {
+		return nil, ErrNotFound
+	}
+	s.claims[i].ExpiresAt = expiresAt
+	result := s.claims[i]
+	return &result, nil
+}
+
+func (s *MemoryStore) ReleaseClaim(kind string, itemId primitive.ObjectID, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.claimIndex(kind, itemId)
+	if i >= 0 && s.claims[i].UserId == userId {
+		s.claims = append(s.claims[:i], s.claims[i+1:]...)
+	}
+	return nil
+}
+
+func (s *MemoryStore) ReleaseOtherClaims(projectId primitive.ObjectID, kind string, userId string, keep primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claims []models.Claim
+	for _, c := range s.claims {
+		if c.ProjectId == projectId && c.Kind == kind && c.UserId == userId && c.ItemId != keep {
+			continue
+		}
+		claims = append(claims, c)
+	}
+	s.claims = claims
+	return nil
+}
+
+func (s *MemoryStore) GetClaim(kind string, itemId primitive.ObjectID) (*models.Claim, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.claimIndex(kind, itemId)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	result := s.claims[i]
+	return &result, nil
+}
+
+func (s *MemoryStore) GetLiveClaims(projectId primitive.ObjectID, kind string) ([]models.Claim, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	var claims = []models.Claim{}
+	for _, c := range s.claims {
+		if c.ProjectId == projectId && (kind == "" || c.Kind == kind) && c.Live(now) {
+			claims = append(claims, c)
+		}
+	}
+	sort.SliceStable(claims, func(i, j int) bool { return claims[i].ClaimedAt.Before(claims[j].ClaimedAt) })
+	return claims, nil
+}
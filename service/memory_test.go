@@ -0,0 +1,550 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryGetUsersByIds(t *testing.T) {
+	s := NewMemoryStore()
+	s.users = []models.User{{UserId: "a"}, {UserId: "b"}, {UserId: "c"}}
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"none", nil, []string{}},
+		{"one", []string{"b"}, []string{"b"}},
+		{"in store order", []string{"c", "a"}, []string{"a", "c"}},
+		{"unknown ids are skipped", []string{"x", "b"}, []string{"b"}},
+		{"duplicates match once", []string{"a", "a"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := s.GetUsersByIds(tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, u := range users {
+				got = append(got, u.UserId)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("GetUsersByIds(%v) = %v, want %v", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMRCAnswerQuery(t *testing.T) {
+	project, other := primitive.NewObjectID(), primitive.NewObjectID()
+	mine := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: project, UserId: "a", ArticleId: "art", TaskId: "1-1", TaskType: "MRC", Status: "unverified"}
+	theirs := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: project, UserId: "b", ArticleId: "art", TaskId: "1-1", TaskType: "MRC", Status: "unverified"}
+	verified := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: project, UserId: "b", ArticleId: "art", TaskId: "1-2", TaskType: "MRC", Status: "verified"}
+	elsewhere := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: other, UserId: "b", ArticleId: "art2", TaskId: "1-1", TaskType: "MRC", Status: "unverified"}
+	s := NewMemoryStore()
+	s.mrcAnswers = []models.MRCAnswer{mine, theirs, verified, elsewhere}
+
+	tests := []struct {
+		name  string
+		query models.MRCAnswer
+		want  []primitive.ObjectID
+	}{
+		// {"projectId", "userId": {"$ne"}, "status": "unverified", "taskType": "MRC"}
+		{"validation skips own answers", models.MRCAnswer{TaskType: "MRCValidation", ProjectId: project, UserId: "a"}, []primitive.ObjectID{theirs.Id}},
+		{"validation of someone else", models.MRCAnswer{TaskType: "MRCValidation", ProjectId: project, UserId: "b"}, []primitive.ObjectID{mine.Id}},
+		{"validation of nobody", models.MRCAnswer{TaskType: "MRCValidation", ProjectId: project}, []primitive.ObjectID{mine.Id, theirs.Id}},
+		{"validation of an empty project", models.MRCAnswer{TaskType: "MRCValidation", ProjectId: primitive.NewObjectID(), UserId: "a"}, nil},
+		// {"_id"}
+		{"by id", models.MRCAnswer{Id: verified.Id, TaskType: "MRC"}, []primitive.ObjectID{verified.Id}},
+		// {"articleId", "taskId", "taskType"}
+		{"by task", models.MRCAnswer{ArticleId: "art", TaskId: "1-1", TaskType: "MRC"}, []primitive.ObjectID{mine.Id, theirs.Id}},
+		{"by task of another type", models.MRCAnswer{ArticleId: "art", TaskId: "1-1", TaskType: "Sentiment"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers, err := s.GetAnswers(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []primitive.ObjectID
+			for _, a := range answers {
+				got = append(got, a.Id)
+			}
+			if !equalIds(got, tt.want) {
+				t.Errorf("GetAnswers(%+v) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryGetRandomDecisionInfo(t *testing.T) {
+	project := primitive.NewObjectID()
+	pending := models.MRCValidation{Id: primitive.NewObjectID(), ProjectId: project, LabelUserId: "a", ValidationUserId: "b", Status: "pending"}
+	decided := models.MRCValidation{Id: primitive.NewObjectID(), ProjectId: project, LabelUserId: "c", ValidationUserId: "d", Status: "verified"}
+	s := NewMemoryStore()
+	s.mrcValidations = []models.MRCValidation{decided, pending}
+
+	tests := []struct {
+		name      string
+		projectId primitive.ObjectID
+		userId    string
+		exclude   []primitive.ObjectID
+		want      primitive.ObjectID
+	}{
+		{"adjudicator", project, "m", nil, pending.Id},
+		{"not the annotator", project, "a", nil, primitive.NilObjectID},
+		{"not the validator", project, "b", nil, primitive.NilObjectID},
+		{"not excluded", project, "m", []primitive.ObjectID{pending.Id}, primitive.NilObjectID},
+		{"not of another project", primitive.NewObjectID(), "m", nil, primitive.NilObjectID},
+		{"decided ones are not pending", project, "a", nil, primitive.NilObjectID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetRandomDecisionInfo(tt.projectId, tt.userId, tt.exclude)
+			if tt.want.IsZero() {
+				if err != ErrNotFound {
+					t.Errorf("got %v, %v, want ErrNotFound", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Id != tt.want {
+				t.Errorf("got %s, want %s", got.Id.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestMemoryValidationQueues(t *testing.T) {
+	project := primitive.NewObjectID()
+	mine := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: project, UserId: "a", TaskType: "MRC", Status: "unverified"}
+	theirs := models.MRCAnswer{Id: primitive.NewObjectID(), ProjectId: project, UserId: "b", TaskType: "MRC", Status: "unverified"}
+	annotated := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project, TaskType: "Sentiment", IsAnswered: true, Annotators: []string{"a"}}
+	unannotated := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project, TaskType: "Sentiment", IsAnswered: true, Annotators: []string{"b"}}
+	s := NewMemoryStore()
+	s.mrcAnswers = []models.MRCAnswer{mine, theirs}
+	s.sentiTasks = []models.SentiTask{annotated, unannotated}
+
+	// whatever task type the client asks for, a user never gets their own
+	for _, taskType := range []string{"MRCValidation", "MRC", ""} {
+		got, err := s.GetRandomValidationQuestion(models.MRCAnswer{ProjectId: project, UserId: "a", TaskType: taskType}, nil)
+		if err != nil || got.Id != theirs.Id {
+			t.Errorf("%q: GetRandomValidationQuestion = %v, %v, want %s", taskType, got, err, theirs.Id.Hex())
+		}
+	}
+	if got, err := s.GetRandomValidationQuestion(models.MRCAnswer{ProjectId: project, UserId: "a"}, []primitive.ObjectID{theirs.Id}); err != ErrNotFound {
+		t.Errorf("GetRandomValidationQuestion without theirs = %v, %v, want ErrNotFound", got, err)
+	}
+
+	query := models.SentiTask{ProjectId: project, TaskType: "Sentiment"}
+	if got, err := s.GetRandomSentiTask(query, "a", nil); err != nil || got.TaskId != unannotated.TaskId {
+		t.Errorf("GetRandomSentiTask = %v, %v, want %s", got, err, unannotated.TaskId.Hex())
+	}
+	if got, err := s.GetRandomSentiTask(query, "a", []primitive.ObjectID{unannotated.TaskId}); err != ErrNotFound {
+		t.Errorf("GetRandomSentiTask without the unannotated task = %v, %v, want ErrNotFound", got, err)
+	}
+}
+
+func TestMemorySentiTaskFlags(t *testing.T) {
+	answer := func(taskId primitive.ObjectID, userId string) models.SentiAnswer {
+		return models.SentiAnswer{
+			Aspect:    []models.SentiAspect{{TaskId: taskId, AspectId: "1", MajorAspect: "food", UserId: userId}},
+			Sentiment: []models.SentiSentiment{{TaskId: taskId, AspectId: "1", Sentiment: "positive", UserId: userId}},
+		}
+	}
+	type step struct {
+		do             func(s *MemoryStore, taskId primitive.ObjectID) error
+		wantErr        error
+		wantAnswered   bool
+		wantValidated  bool
+		wantAnnotators int
+	}
+	saveAnswer := func(userId string, required int) func(*MemoryStore, primitive.ObjectID) error {
+		return func(s *MemoryStore, taskId primitive.ObjectID) error {
+			return s.SaveSentiAnswer(answer(taskId, userId), required)
+		}
+	}
+	validate := func(s *MemoryStore, taskId primitive.ObjectID) error {
+		_, err := s.SaveFinalAnswer(models.SentiAnswer{Task: models.SentiTask{TaskId: taskId}, UserId: "v"})
+		return err
+	}
+	discard := func(s *MemoryStore, taskId primitive.ObjectID) error {
+		_, err := s.DiscardSentiAnswer(models.SentiTask{TaskId: taskId}, "v", "")
+		return err
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"one annotator answers", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+		}},
+		{"answered once the redundancy is met", []step{
+			{do: saveAnswer("a", 2), wantAnnotators: 1},
+			{do: saveAnswer("b", 2), wantAnswered: true, wantAnnotators: 2},
+		}},
+		{"the same annotator twice", []step{
+			{do: saveAnswer("a", 2), wantAnnotators: 1},
+			{do: saveAnswer("a", 2), wantErr: ErrAnnotated, wantAnnotators: 1},
+		}},
+		{"no annotator past the redundancy", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+			{do: saveAnswer("b", 1), wantErr: ErrTaskAnswered, wantAnswered: true, wantAnnotators: 1},
+		}},
+		{"validated", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+			{do: validate, wantAnswered: true, wantValidated: true, wantAnnotators: 1},
+		}},
+		{"validated once", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+			{do: validate, wantAnswered: true, wantValidated: true, wantAnnotators: 1},
+			{do: validate, wantErr: ErrDecided, wantAnswered: true, wantValidated: true, wantAnnotators: 1},
+		}},
+		{"discarded", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+			{do: discard},
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+		}},
+		{"discarded after a validation", []step{
+			{do: saveAnswer("a", 1), wantAnswered: true, wantAnnotators: 1},
+			{do: validate, wantAnswered: true, wantValidated: true, wantAnnotators: 1},
+			{do: discard},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "Sentiment"}
+			task := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project.ProjectId, ArticleId: primitive.NewObjectID()}
+			s := NewMemoryStore()
+			s.projects = []models.Project{project}
+			s.sentiTasks = []models.SentiTask{task}
+			for i, step := range tt.steps {
+				err := step.do(s, task.TaskId)
+				if err != step.wantErr {
+					t.Fatalf("step %d: err = %v, want %v", i, err, step.wantErr)
+				}
+				got, err := s.FindSentiTaskById(task.TaskId)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.IsAnswered != step.wantAnswered || got.IsValidate != step.wantValidated || len(got.Annotators) != step.wantAnnotators {
+					t.Errorf("step %d: isAnswered %v, isValidate %v, %d annotators, want %v, %v, %d", i,
+						got.IsAnswered, got.IsValidate, len(got.Annotators), step.wantAnswered, step.wantValidated, step.wantAnnotators)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryAddMRCAnnotator(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotators []string
+		userId     string
+		required   int
+		want       bool
+	}{
+		{"first of two", nil, "a", 2, false},
+		{"meets the redundancy", []string{"a"}, "b", 2, true},
+		// the redundancy was lowered after the task had more annotators
+		{"past the redundancy", []string{"a", "b"}, "c", 1, true},
+		{"the same annotator again", []string{"a"}, "a", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "MRC", Redundancy: tt.required}
+			article := models.Article{ArticleId: primitive.NewObjectID(), ProjectId: project.ProjectId, TotalTasks: 1}
+			task := models.MRCTask{ArticleId: article.ArticleId.Hex(), TaskId: "1-1", TaskType: "MRC", Annotators: tt.annotators, Answered: len(tt.annotators)}
+			s := NewMemoryStore()
+			s.projects = []models.Project{project}
+			s.articles = []models.Article{article}
+			s.mrcTasks = []models.MRCTask{task}
+			err := s.AddMRCAnnotator(task, tt.userId, tt.required)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.articles[0].IsAnswered; got != tt.want {
+				t.Errorf("article isAnswered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryUpdateProjectRedundancy(t *testing.T) {
+	project := models.Project{ProjectId: primitive.NewObjectID(), ProjectName: "p", ProjectType: "Sentiment", Redundancy: 2}
+	task := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project.ProjectId, ArticleId: primitive.NewObjectID(), Annotators: []string{"a"}}
+	s := NewMemoryStore()
+	s.projects = []models.Project{project}
+	s.sentiTasks = []models.SentiTask{task}
+
+	project.ProjectName = "renamed"
+	project.Redundancy = 1
+	if err := s.UpdateProject(project); err != nil {
+		t.Fatal(err)
+	}
+	if p := s.projects[0]; p.ProjectName != "renamed" || p.Redundancy != 1 || !s.sentiTasks[0].IsAnswered {
+		t.Errorf("name %q, redundancy %d, task isAnswered %v, want the update and the task answered", p.ProjectName, p.Redundancy, s.sentiTasks[0].IsAnswered)
+	}
+	project.Redundancy = 2
+	if err := s.UpdateProject(project); err != nil || s.sentiTasks[0].IsAnswered {
+		t.Errorf("task isAnswered %v, %v, want it open again", s.sentiTasks[0].IsAnswered, err)
+	}
+}
+
+func TestMemoryDeleteLabel(t *testing.T) {
+	project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "Sentiment", Redundancy: 2}
+	task := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project.ProjectId, ArticleId: primitive.NewObjectID(), Gold: true, Annotators: []string{"a", "b"}}
+	label := models.Label{Id: primitive.NewObjectID(), ProjectId: project.ProjectId, TaskType: "Sentiment", TaskId: task.TaskId.Hex()}
+	s := NewMemoryStore()
+	s.projects = []models.Project{project}
+	s.sentiTasks = []models.SentiTask{task}
+	s.labels = []models.Label{label}
+
+	if err := s.DeleteLabel(project.ProjectId, label.Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.sentiTasks[0]; got.Gold || !got.IsAnswered {
+		t.Errorf("gold %v, isAnswered %v, want a regular task with the annotators it needs", got.Gold, got.IsAnswered)
+	}
+	if err := s.DeleteLabel(project.ProjectId, label.Id); err != ErrNotFound {
+		t.Errorf("second delete: %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryAnswerMRCTask(t *testing.T) {
+	project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "MRC", Gold: models.GoldSettings{MinAccuracy: 0.5, Window: 2}}
+	article := models.Article{ArticleId: primitive.NewObjectID(), ProjectId: project.ProjectId, TotalTasks: 1}
+	task := models.MRCTask{ArticleId: article.ArticleId.Hex(), TaskId: "1-1", TaskType: "MRC"}
+	s := NewMemoryStore()
+	s.projects = []models.Project{project}
+	s.articles = []models.Article{article}
+	s.mrcTasks = []models.MRCTask{task}
+	s.auths = []models.Auth{{ProjectId: project.ProjectId, UserId: "a", Role: models.RoleAnnotator}}
+	answer := models.MRCAnswer{ProjectId: project.ProjectId, UserId: "a", ArticleId: task.ArticleId, TaskId: task.TaskId, TaskType: "MRC"}
+	gold := func(correct bool) *models.GoldResult {
+		return &models.GoldResult{ProjectId: project.ProjectId, LabelId: primitive.NewObjectID(), UserId: "a", Correct: correct, AnsweredAt: time.Now()}
+	}
+
+	if _, err := s.AnswerMRCTask(project, task, answer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !s.articles[0].IsAnswered || s.mrcTasks[0].Answered != 1 {
+		t.Errorf("article isAnswered %v, task answered %d, want the answer counted", s.articles[0].IsAnswered, s.mrcTasks[0].Answered)
+	}
+	first := gold(false)
+	if _, err := s.AnswerMRCTask(project, task, answer, first); err != nil {
+		t.Fatal(err)
+	}
+	if s.auths[0].Paused || s.mrcTasks[0].Answered != 1 {
+		t.Errorf("paused %v, task answered %d, want a gold answer counted as neither", s.auths[0].Paused, s.mrcTasks[0].Answered)
+	}
+	// a second answer to the label is refused as a whole
+	if _, err := s.AnswerMRCTask(project, task, answer, first); err != ErrAnnotated {
+		t.Errorf("second gold answer: %v, want ErrAnnotated", err)
+	}
+	if len(s.mrcAnswers) != 2 || len(s.goldResults) != 1 {
+		t.Errorf("%d answers and %d gold results, want 2 and 1", len(s.mrcAnswers), len(s.goldResults))
+	}
+	// a full window with half of it correct is not below 0.5
+	if _, err := s.AnswerMRCTask(project, task, answer, gold(true)); err != nil || s.auths[0].Paused {
+		t.Errorf("paused %v, %v, want 1 of 2 correct to keep annotating", s.auths[0].Paused, err)
+	}
+	for _, want := range []bool{false, true} {
+		if _, err := s.AnswerMRCTask(project, task, answer, gold(false)); err != nil || s.auths[0].Paused != want {
+			t.Errorf("paused %v, %v, want %v", s.auths[0].Paused, err, want)
+		}
+	}
+}
+
+func TestMemoryRestoreMRCAnswer(t *testing.T) {
+	project := primitive.NewObjectID()
+	s := NewMemoryStore()
+	s.projects = []models.Project{{ProjectId: project, ProjectType: "MRC"}}
+	original := models.MRCAnswer{ProjectId: project, UserId: "a", ArticleId: "art", TaskId: "1-1", TaskType: "MRC", Status: "unverified", Answer: "臺北市", StartIdx: 10}
+	answerId, err := s.SaveAnswer(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validation := models.MRCValidation{ProjectId: project, LabelUserId: "a", ValidationUserId: "b", OriginalId: answerId, Status: "pending"}
+	validationId, err := s.SaveValidation(models.MRCAnswer{ProjectId: project, UserId: "b", TaskType: "MRCValidation", Answer: "東亞", StartIdx: 4}, validation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := func(version int) error {
+		revisions, err := s.GetRevisions(project, answerId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.RestoreRevision(project, revisions[version-1].Id, "m", "")
+		return err
+	}
+	// v1 answered unverified, v2 validated pending
+	if err := restore(1); err != ErrReviewed {
+		t.Errorf("restoring the unverified answer after its validation: %v, want ErrReviewed", err)
+	}
+	if err := restore(2); err != nil {
+		t.Errorf("restoring the validated answer: %v", err)
+	}
+	decide := func(userId string) error {
+		_, err := s.Decide(project, models.MRCDecision{UserId: userId, OriginalId: answerId, ValidationStatusId: validationId, DecisionResult: "original"}, models.DecisionVerified)
+		return err
+	}
+	for _, userId := range []string{"a", "b"} {
+		if err := decide(userId); err != ErrOwnWork {
+			t.Errorf("%s deciding on their own work: %v, want ErrOwnWork", userId, err)
+		}
+	}
+	if err := decide("m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(2); err != ErrDecided {
+		t.Errorf("restoring a decided answer: %v, want ErrDecided", err)
+	}
+	answer, err := s.FindAnswerById(answerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Status != models.DecisionVerified {
+		t.Errorf("status %q after the refused restores, want %q", answer.Status, models.DecisionVerified)
+	}
+}
+
+func TestMemorySaveValidationOnce(t *testing.T) {
+	project := primitive.NewObjectID()
+	s := NewMemoryStore()
+	s.projects = []models.Project{{ProjectId: project, ProjectType: "MRC"}}
+	answerId, err := s.SaveAnswer(models.MRCAnswer{ProjectId: project, UserId: "a", TaskType: "MRC", Status: "unverified", Answer: "臺北市", StartIdx: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(userId, status string) error {
+		answer := models.MRCAnswer{ProjectId: project, UserId: userId, TaskType: "Validation", Answer: "臺北市", StartIdx: 10}
+		_, err := s.SaveValidation(answer, models.MRCValidation{ProjectId: project, LabelUserId: "a", ValidationUserId: userId, OriginalId: answerId, Status: status})
+		return err
+	}
+	if err := validate("b", "pending"); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate("c", "verified"); err != ErrDecided {
+		t.Errorf("second validation: %v, want ErrDecided", err)
+	}
+	if err := validate("c", "verified"); err != ErrDecided {
+		t.Errorf("third validation: %v, want ErrDecided", err)
+	}
+	answer, _ := s.FindAnswerById(answerId)
+	if answer.Status != "pending" || len(s.mrcAnswers) != 2 || len(s.mrcValidations) != 1 {
+		t.Errorf("status %q with %d answers and %d validations, want the first validation only", answer.Status, len(s.mrcAnswers), len(s.mrcValidations))
+	}
+	revisions, _ := s.GetRevisions(project, answerId)
+	if len(revisions) != 2 {
+		t.Errorf("%d revisions, want answered and validated", len(revisions))
+	}
+}
+
+func TestMemoryDiscardRevision(t *testing.T) {
+	project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "Sentiment"}
+	task := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project.ProjectId, ArticleId: primitive.NewObjectID()}
+	s := NewMemoryStore()
+	s.projects = []models.Project{project}
+	s.sentiTasks = []models.SentiTask{task}
+	err := s.SaveSentiAnswer(models.SentiAnswer{
+		Aspect:    []models.SentiAspect{{TaskId: task.TaskId, AspectId: "1", UserId: "a"}},
+		Sentiment: []models.SentiSentiment{{TaskId: task.TaskId, AspectId: "1", Sentiment: "positive", UserId: "a"}},
+	}, 1)
+	if err == nil {
+		_, err = s.SaveFinalAnswer(models.SentiAnswer{Task: task, UserId: "v"})
+	}
+	if err == nil {
+		_, err = s.DiscardSentiAnswer(task, "v", "wrong aspect")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := s.GetRevisions(project.ProjectId, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("%d revisions, want answered, validated and discarded", len(revisions))
+	}
+	discarded := revisions[2]
+	version := discarded.SentiTask
+	if discarded.Action != models.RevisionDiscarded || discarded.Reason != "wrong aspect" || version.IsAnswered || version.IsValidate || len(version.Aspects) != 0 || len(version.Sentiments) != 0 {
+		t.Errorf("discarded revision %+v of %+v", discarded, version)
+	}
+	if !revisions[1].SentiTask.IsValidate {
+		t.Errorf("the validated revision is not validated")
+	}
+}
+
+func TestMemoryRestoreSentiTask(t *testing.T) {
+	project := models.Project{ProjectId: primitive.NewObjectID(), ProjectType: "Sentiment"}
+	task := models.SentiTask{TaskId: primitive.NewObjectID(), ProjectId: project.ProjectId, ArticleId: primitive.NewObjectID()}
+	s := NewMemoryStore()
+	s.projects = []models.Project{project}
+	s.sentiTasks = []models.SentiTask{task}
+	err := s.SaveSentiAnswer(models.SentiAnswer{
+		Aspect:    []models.SentiAspect{{TaskId: task.TaskId, AspectId: "1", UserId: "a"}},
+		Sentiment: []models.SentiSentiment{{TaskId: task.TaskId, AspectId: "1", Sentiment: "positive", UserId: "a"}},
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions, _ := s.GetRevisions(project.ProjectId, task.TaskId)
+	answered := revisions[0]
+	// annotations can be restored until a validation is made against them
+	if _, err := s.RestoreRevision(project.ProjectId, answered.Id, "m", ""); err != nil {
+		t.Fatalf("restore before the validation: %v", err)
+	}
+	if _, err := s.SaveFinalAnswer(models.SentiAnswer{Task: task, UserId: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreRevision(project.ProjectId, answered.Id, "m", ""); err != ErrReviewed {
+		t.Errorf("restore of the answer under a validation: %v, want ErrReviewed", err)
+	}
+	revisions, _ = s.GetRevisions(project.ProjectId, task.TaskId)
+	validated := revisions[len(revisions)-1]
+	if _, err := s.RestoreRevision(project.ProjectId, validated.Id, "m", ""); err != nil {
+		t.Errorf("restore of the validated version: %v", err)
+	}
+	if _, err := s.DiscardSentiAnswer(task, "v", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreRevision(project.ProjectId, validated.Id, "m", ""); err != ErrReviewed {
+		t.Errorf("restore of a validation after a discard: %v, want ErrReviewed", err)
+	}
+	if got := s.sentiTasks[0]; got.IsValidate || len(s.sentiFinalAnswers) != 1 {
+		t.Errorf("isValidate %v with %d validations, want the discard to stand", got.IsValidate, len(s.sentiFinalAnswers))
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalIds(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"os"
+
+	"Lynx/config"
+	"Lynx/db"
+	"Lynx/logging"
+)
+
+// Open connects the backend configured in cfg. The returned func releases it.
+func Open(ctx context.Context, cfg *config.Config) (Store, func(), error) {
+	if cfg.Store == "memory" {
+		memory := NewMemoryStore()
+		if cfg.SeedFile != "" {
+			f, err := os.Open(cfg.SeedFile)
+			if err != nil {
+				return nil, nil, err
+			}
+			defer f.Close()
+			err = memory.LoadSeed(f)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return memory, func() {}, nil
+	}
+	client, err := db.ConnectWithRetry(ctx, cfg.DBURI, cfg.ConnectTimeout.Duration, cfg.ConnectAttempts, cfg.ConnectBackoff.Duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	disconnect := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout.Duration)
+		defer cancel()
+		err := client.Disconnect(ctx)
+		if err != nil {
+			logging.Errorf("Disconnect from MongoDB failed: %v", err)
+			return
+		}
+		logging.Infof("Disconnected from MongoDB")
+	}
+	store := NewMongoStore(client.Database(cfg.DBName), cfg.QueryTimeout.Duration)
+	indexCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout.Duration)
+	defer cancel()
+	// a standalone server is not retried, it will not become a replica set
+	err = store.Ping(indexCtx)
+	if err == nil {
+		err = store.EnsureIndexes(indexCtx)
+	}
+	if err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	return store, disconnect, nil
+}
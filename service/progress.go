@@ -0,0 +1,84 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// progressBuilder adds the counts of a Progress up, article by article and
+// day by day, in whatever order the store finds them.
+type progressBuilder struct {
+	progress *models.Progress
+	articles map[string]int
+	days     map[string]*models.DayProgress
+}
+
+func newProgressBuilder(project models.Project) *progressBuilder {
+	return &progressBuilder{
+		progress: &models.Progress{
+			ProjectId:   project.ProjectId.Hex(),
+			ProjectType: project.ProjectType,
+			Articles:    []models.ArticleProgress{},
+			Days:        []models.DayProgress{},
+		},
+		articles: make(map[string]int),
+		days:     make(map[string]*models.DayProgress),
+	}
+}
+
+// article lists an article of the project, with nothing counted yet.
+func (b *progressBuilder) article(articleId string, title string) *models.ArticleProgress {
+	i, ok := b.articles[articleId]
+	if !ok {
+		i = len(b.progress.Articles)
+		b.articles[articleId] = i
+		b.progress.Articles = append(b.progress.Articles, models.ArticleProgress{ArticleId: articleId, ArticleTitle: title})
+	}
+	return &b.progress.Articles[i]
+}
+
+// count adds n items in state to an article and to the project.
+func (b *progressBuilder) count(articleId string, state string, n int) {
+	b.article(articleId, "").Add(state, n)
+	b.progress.Total.Add(state, n)
+}
+
+// day returns the counts of a day, formatted as progressDay does.
+func (b *progressBuilder) day(date string) *models.DayProgress {
+	d, ok := b.days[date]
+	if !ok {
+		d = &models.DayProgress{Date: date}
+		b.days[date] = d
+	}
+	return d
+}
+
+func (b *progressBuilder) build() *models.Progress {
+	for _, d := range b.days {
+		b.progress.Days = append(b.progress.Days, *d)
+	}
+	sort.Slice(b.progress.Days, func(i, j int) bool { return b.progress.Days[i].Date < b.progress.Days[j].Date })
+	return b.progress
+}
+
+// progressDay is the UTC day of t, as MongoDB's $dateToString "%Y-%m-%d"
+// writes it.
+func progressDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// hexOf is the hex string of an article id, stored as an ObjectId by
+// sentiment projects and as its hex string by MRC ones.
+func hexOf(id interface{}) string {
+	switch id := id.(type) {
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Lynx/models"
+)
+
+// revisionFields flattens the annotation a revision holds into named
+// fields. Aspects are named by annotator and aspect id, sentiments by
+// annotator, aspect id and offset.
+func revisionFields(revision models.Revision) map[string]string {
+	fields := make(map[string]string)
+	set := func(field, value string) {
+		if given, ok := fields[field]; ok {
+			value = given + ", " + value
+		}
+		fields[field] = value
+	}
+	if task := revision.SentiTask; task != nil {
+		annotators := append([]string{}, task.Annotators...)
+		sort.Strings(annotators)
+		set("annotators", strings.Join(annotators, ", "))
+		set("isAnswered", strconv.FormatBool(task.IsAnswered))
+		set("isValidate", strconv.FormatBool(task.IsValidate))
+		for _, a := range task.Aspects {
+			set(fmt.Sprintf("aspect %s/%s", a.UserId, a.AspectId), fmt.Sprintf("%s/%s@%d", a.MajorAspect, a.MinorAspect, a.Offset))
+		}
+		for _, s := range task.Sentiments {
+			set(fmt.Sprintf("sentiment %s/%s@%d", s.UserId, s.AspectId, s.Offset), strings.TrimSpace(s.Sentiment+" "+s.Dir))
+		}
+	}
+	if answer := revision.MRCAnswer; answer != nil {
+		set("question", answer.Question)
+		set("answer", answer.Answer)
+		set("startIdx", strconv.Itoa(answer.StartIdx))
+		set("isImpossible", strconv.FormatBool(answer.IsImpossible))
+		set("status", answer.Status)
+	}
+	return fields
+}
+
+// DiffRevisions lists the fields of an annotation that differ from one of
+// its revisions to another, sorted by name.
+func DiffRevisions(from, to models.Revision) []models.RevisionChange {
+	before, after := revisionFields(from), revisionFields(to)
+	var names []string
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.RevisionChange{}
+	for _, name := range names {
+		b, inBefore := before[name]
+		a, inAfter := after[name]
+		if inBefore && inAfter && a == b {
+			continue
+		}
+		change := models.RevisionChange{Field: name}
+		if inBefore {
+			change.From = &b
+		}
+		if inAfter {
+			change.To = &a
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
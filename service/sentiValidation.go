@@ -0,0 +1,130 @@
+package service
+
+import (
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MatchSentiValidation compares the sentiments a validator posted for a task
+// with the ones its annotators saved, see DiffSentiments.
+func MatchSentiValidation(store Store, taskId primitive.ObjectID, validated []models.SentiSentiment) ([]models.SentiDiff, error) {
+	stored, err := store.GetSentiAnswer(models.SentiSentiment{TaskId: taskId})
+	if err != nil {
+		return nil, err
+	}
+	annotated := make([]models.SentiSentiment, len(stored))
+	for i, sentiment := range stored {
+		annotated[i] = *sentiment
+	}
+	return DiffSentiments(annotated, validated), nil
+}
+
+// DiffSentiments lists every disagreement between the sentiments of a
+// validator and of the annotators of a task, annotator by annotator and
+// aspect by aspect. Validated sentiments whose UserId names one of the
+// annotators are compared with that annotator only, the others with every
+// annotator. Within an aspect, equal sentiments pair up first, the rest in
+// order as wrong ones; what is left over is missing or extra.
+func DiffSentiments(annotated, validated []models.SentiSentiment) []models.SentiDiff {
+	var annotators []string
+	for _, sentiment := range annotated {
+		if !containsString(annotators, sentiment.UserId) {
+			annotators = append(annotators, sentiment.UserId)
+		}
+	}
+	diff := []models.SentiDiff{}
+	if len(annotators) == 0 {
+		return diffAspect("", nil, validated)
+	}
+	for _, annotator := range annotators {
+		var own, theirs []models.SentiSentiment
+		for _, sentiment := range annotated {
+			if sentiment.UserId == annotator {
+				own = append(own, sentiment)
+			}
+		}
+		for _, sentiment := range validated {
+			if sentiment.UserId == annotator || !containsString(annotators, sentiment.UserId) {
+				theirs = append(theirs, sentiment)
+			}
+		}
+		var aspectIds []string
+		for _, sentiment := range append(own, theirs...) {
+			if !containsString(aspectIds, sentiment.AspectId) {
+				aspectIds = append(aspectIds, sentiment.AspectId)
+			}
+		}
+		for _, aspectId := range aspectIds {
+			diff = append(diff, diffAspect(annotator, ofAspect(own, aspectId), ofAspect(theirs, aspectId))...)
+		}
+	}
+	return diff
+}
+
+func ofAspect(sentiments []models.SentiSentiment, aspectId string) []models.SentiSentiment {
+	var result []models.SentiSentiment
+	for _, sentiment := range sentiments {
+		if sentiment.AspectId == aspectId {
+			result = append(result, sentiment)
+		}
+	}
+	return result
+}
+
+// diffAspect compares the sentiments an annotator and the validator gave
+// one aspect.
+func diffAspect(annotator string, annotated, validated []models.SentiSentiment) []models.SentiDiff {
+	diff := []models.SentiDiff{}
+	pairedA := make([]bool, len(annotated))
+	pairedV := make([]bool, len(validated))
+	for i := range annotated {
+		for j := range validated {
+			if !pairedV[j] && len(sentiDiffFields(annotated[i], validated[j])) == 0 {
+				pairedA[i], pairedV[j] = true, true
+				break
+			}
+		}
+	}
+	j := 0
+	for i := range annotated {
+		if pairedA[i] {
+			continue
+		}
+		for j < len(validated) && pairedV[j] {
+			j++
+		}
+		a := annotated[i]
+		item := models.SentiDiff{AnnotatorId: annotator, AspectId: a.AspectId, Annotated: &a}
+		if j < len(validated) {
+			v := validated[j]
+			pairedV[j] = true
+			item.Kind, item.Fields, item.Validated = models.SentiDiffWrong, sentiDiffFields(a, v), &v
+		} else {
+			item.Kind = models.SentiDiffMissing
+		}
+		diff = append(diff, item)
+	}
+	for j := range validated {
+		if !pairedV[j] {
+			v := validated[j]
+			diff = append(diff, models.SentiDiff{Kind: models.SentiDiffExtra, AnnotatorId: annotator, AspectId: v.AspectId, Validated: &v})
+		}
+	}
+	return diff
+}
+
+// sentiDiffFields names the fields two sentiments of an aspect differ in.
+func sentiDiffFields(a, b models.SentiSentiment) []string {
+	var fields []string
+	if a.Sentiment != b.Sentiment {
+		fields = append(fields, "sentiment")
+	}
+	if a.Dir != b.Dir {
+		fields = append(fields, "dir")
+	}
+	if a.Offset != b.Offset {
+		fields = append(fields, "offset")
+	}
+	return fields
+}
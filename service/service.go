@@ -2,8 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"reflect"
 	"time"
 
 	"Lynx/models"
@@ -11,10 +12,102 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-func GetAuthByProjectId(db *mongo.Database, auth models.Auth) ([]models.Auth, error) {
-	collection := db.Collection(auth.TableName())
+// MongoStore is the Store backed by a MongoDB database.
+type MongoStore struct {
+	db *mongo.Database
+	// timeout bounds every write issued by the store.
+	timeout time.Duration
+}
+
+func NewMongoStore(db *mongo.Database, timeout time.Duration) *MongoStore {
+	return &MongoStore{db: db, timeout: timeout}
+}
+
+// ErrNoReplicaSet is returned by Ping when MongoDB runs standalone, where
+// the transactions of the store fail.
+var ErrNoReplicaSet = errors.New("MongoDB is not a replica set member, which Lynx needs for transactions: start mongod with --replSet rs0, run rs.initiate() once and add ?replicaSet=rs0 to the dbUri")
+
+// codeCommandNotFound is the error code of MongoDB servers before 4.4.2,
+// which only know hello as isMaster.
+const codeCommandNotFound = 59
+
+// Ping checks the primary the same way db.Connect does at startup, then
+// that it belongs to a replica set, or is the mongos of a sharded
+// cluster, so that transactions can run.
+func (s *MongoStore) Ping(ctx context.Context) error {
+	err := s.db.Client().Ping(ctx, readpref.Primary())
+	if err != nil {
+		return err
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	admin := s.db.Client().Database("admin")
+	err = admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == codeCommandNotFound {
+		err = admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+	}
+	if err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return ErrNoReplicaSet
+	}
+	return nil
+}
+
+// notFound maps the driver's empty result onto the store-wide ErrNotFound.
+func notFound(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	return err
+}
+
+// insertedId unwraps the id the driver generated for an InsertOne.
+func insertedId(res *mongo.InsertOneResult) primitive.ObjectID {
+	id, _ := res.InsertedID.(primitive.ObjectID)
+	return id
+}
+
+// excluding adds to a queue filter that its _id is none of exclude.
+func excluding(filter bson.M, exclude []primitive.ObjectID) bson.M {
+	if len(exclude) == 0 {
+		return filter
+	}
+	notExcluded := bson.M{"_id": bson.M{"$nin": exclude}}
+	if _, ok := filter["_id"]; ok {
+		return bson.M{"$and": bson.A{filter, notExcluded}}
+	}
+	filter["_id"] = notExcluded["_id"]
+	return filter
+}
+
+// isDuplicateKey tells the errors of writes that break a unique index.
+func isDuplicateKey(err error) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if e.Code == 11000 {
+				return true
+			}
+		}
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 11000
+	}
+	return false
+}
+
+func (s *MongoStore) GetAuthByProjectId(auth models.Auth) ([]models.Auth, error) {
+	collection := s.db.Collection(auth.TableName())
 	var serviceResult = []models.Auth{}
 	cur, err := collection.Find(context.Background(), bson.M{"projectId": auth.ProjectId})
 	if err != nil {
@@ -33,104 +126,124 @@ func GetAuthByProjectId(db *mongo.Database, auth models.Auth) ([]models.Auth, er
 	return serviceResult, nil
 }
 
-// func GetProjectsByAuth(db *mongo.Database, auth models.Auth) ([]models.Project, error) {
-// 	collection := db.Collection(auth.TableName())
-// 	var authList = models.Auths{}
-// 	cur, err := collection.Find(context.Background(), auth.ToQueryBson())
-// 	log.Println("cur", cur)
-// 	log.Println("auth", auth.ToQueryBson())
-// 	if err != nil {
-// 		log.Println("Find Project Error", err)
-// 		return nil, err
-// 	}
-// 	for cur.Next(context.Background()) {
-// 		result := models.Auth{}
-// 		err := cur.Decode(&result)
-// 		if err != nil {
-// 			log.Println("Decode Project Error", err)
-// 			return nil, err
-// 		}
-// 		authList = append(authList, result)
-// 	}
-// 	var projectIds = authList.SelectProjectIdList()
-// 	var serviceResult = []models.Project{}
-// 	for _, projectId := range projectIds {
-// 		project, err := GetProjectByProjectId(db, models.Project{ProjectId: projectId})
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		serviceResult = append(serviceResult, *project)
-// 	}
+func (s *MongoStore) GetUserProjectAuths(userId string, projectId primitive.ObjectID) ([]models.Auth, error) {
+	collection := s.db.Collection("Authentication")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var auths = []models.Auth{}
+	cur, err := collection.Find(ctx, bson.M{"userId": userId, "projectId": projectId})
+	if err != nil {
+		log.Println("Find Auth Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &auths)
+	if err != nil {
+		log.Println("Decode Auth Error", err)
+		return nil, err
+	}
+	return auths, nil
+}
 
-// 	return serviceResult, nil
-// }
+func (s *MongoStore) GetAuthsByUserId(userId string) (models.Auths, error) {
+	collection := s.db.Collection("Authentication")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var auths = models.Auths{}
+	cur, err := collection.Find(ctx, bson.M{"userId": userId})
+	if err != nil {
+		log.Println("Find Auth Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &auths)
+	if err != nil {
+		log.Println("Decode Auth Error", err)
+		return nil, err
+	}
+	return auths, nil
+}
 
-func SaveAuth(db *mongo.Database, auth models.Auth) (*mongo.InsertOneResult, error) {
-	AuthCollection := db.Collection(auth.TableName())
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveAuth(auth models.Auth) (primitive.ObjectID, error) {
+	AuthCollection := s.db.Collection(auth.TableName())
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	res, err := AuthCollection.InsertOne(ctx, auth)
 	if err != nil {
 		log.Println("Insert auth Error", err)
-		return nil, err
+		return primitive.NilObjectID, err
+	}
+	return insertedId(res), nil
+}
+
+func (s *MongoStore) SetAnnotatorPaused(projectId primitive.ObjectID, userId string, paused bool) error {
+	AuthCollection := s.db.Collection("Authentication")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	filter := bson.M{"projectId": projectId, "userId": userId, "role": models.RoleAnnotator}
+	res, err := AuthCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"paused": paused}})
+	if err != nil {
+		log.Println("Pause annotator Error", err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
 	}
-	return res, nil
+	return nil
 }
 
-func SaveAuths(db *mongo.Database, auths []models.Auth) (*mongo.InsertManyResult, error) {
-	AuthCollection := db.Collection("Authentication")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveAuths(auths []models.Auth) error {
+	AuthCollection := s.db.Collection("Authentication")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	// to insert into db, need to convert struct to interface{}
 	insertDatas := make([]interface{}, len(auths))
 	for i, a := range auths {
 		insertDatas[i] = a
 	}
-	res, err := AuthCollection.InsertMany(ctx, insertDatas)
+	_, err := AuthCollection.InsertMany(ctx, insertDatas)
 	if err != nil {
 		log.Println("Insert auth Error", err)
-		return nil, err
+		return err
 	}
-	return res, nil
+	return nil
 }
 
 // save project articles
-func SaveArticles(db *mongo.Database, articles []models.Article) (*mongo.InsertManyResult, error) {
-	ArticleCollection := db.Collection("Articles")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveArticles(articles []models.Article) error {
+	ArticleCollection := s.db.Collection("Articles")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	// to insert into db, need to convert struct to interface{}
 	insertDatas := make([]interface{}, len(articles))
 	for i, a := range articles {
 		insertDatas[i] = a
 	}
-	res, err := ArticleCollection.InsertMany(ctx, insertDatas)
+	_, err := ArticleCollection.InsertMany(ctx, insertDatas)
 	if err != nil {
-		log.Println("Insert auth Error", err)
-		return nil, err
+		log.Println("Insert articles Error", err)
+		return err
 	}
-	return res, nil
+	return nil
 }
 
-func SaveTasks(db *mongo.Database, tasks []models.MRCTask) (*mongo.InsertManyResult, error) {
-	TaskCollection := db.Collection("MRCTask")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveTasks(tasks []models.MRCTask) error {
+	TaskCollection := s.db.Collection("MRCTask")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	// to insert into db, need to convert struct to interface{}
 	insertDatas := make([]interface{}, len(tasks))
 	for i, a := range tasks {
 		insertDatas[i] = a
 	}
-	res, err := TaskCollection.InsertMany(ctx, insertDatas)
+	_, err := TaskCollection.InsertMany(ctx, insertDatas)
 	if err != nil {
-		log.Println("Insert auth Error", err)
-		return nil, err
+		log.Println("Insert tasks Error", err)
+		return err
 	}
-	return res, nil
+	return nil
 }
 
 // func GetProjects(db *mongo.Database, userId string) ([]models.Project, error) {
-// 	collection := db.Collection("Project")
+// 	collection := s.db.Collection("Project")
 // 	var serviceResult = []models.Project{}
 // 	cur, err := collection.Find(context.Background(), bson.M{"manager": userId})
 // 	if err != nil {
@@ -149,8 +262,8 @@ func SaveTasks(db *mongo.Database, tasks []models.MRCTask) (*mongo.InsertManyRes
 // 	return serviceResult, nil
 // }
 
-func GetProjectByProjectId(db *mongo.Database, project models.Project) (*models.Project, error) {
-	collection := db.Collection("projects")
+func (s *MongoStore) GetProjectByProjectId(project models.Project) (*models.Project, error) {
+	collection := s.db.Collection(project.TableName())
 	var serviceResult = models.Project{}
 	// log.Println(project.ToQueryBson())
 	// log.Println(collection)
@@ -158,7 +271,7 @@ func GetProjectByProjectId(db *mongo.Database, project models.Project) (*models.
 	// if no project then return nil
 	if cur.Err() != nil {
 		log.Println("Can't find project in DB", cur.Err())
-		return nil, cur.Err()
+		return nil, notFound(cur.Err())
 	}
 	// if has project then return
 	err := cur.Decode(&serviceResult)
@@ -170,9 +283,237 @@ func GetProjectByProjectId(db *mongo.Database, project models.Project) (*models.
 	return &serviceResult, nil
 }
 
-func GetProjectCount(db *mongo.Database) (int64, error) {
-	ProjectCollection := db.Collection("Project")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) GetProjectsByIds(projectIds []primitive.ObjectID) ([]models.Project, error) {
+	collection := s.db.Collection("Project")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var projects = []models.Project{}
+	if len(projectIds) == 0 {
+		return projects, nil
+	}
+	cur, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": projectIds}})
+	if err != nil {
+		log.Println("Find Project Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &projects)
+	if err != nil {
+		log.Println("Decode Project Error", err)
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (s *MongoStore) UpdateProject(project models.Project) error {
+	collection := s.db.Collection(project.TableName())
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		update := bson.M{"$set": bson.M{"name": project.ProjectName, "rule": project.Rule, "type": project.ProjectType, "redundancy": project.Redundancy, "matchRule": project.MatchRule, "gold": project.Gold}}
+		var before models.Project
+		err := collection.FindOneAndUpdate(ctx, bson.M{"_id": project.ProjectId}, update).Decode(&before)
+		if err != nil {
+			return notFound(err)
+		}
+		if before.RequiredAnnotators() == project.RequiredAnnotators() {
+			return nil
+		}
+		return s.applyRedundancy(ctx, project.ProjectId, project.RequiredAnnotators())
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Update project Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) SetRedundancy(projectId primitive.ObjectID, redundancy int) error {
+	project := models.Project{Redundancy: redundancy}
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		res, err := s.db.Collection("Project").UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": bson.M{"redundancy": redundancy}})
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return ErrNotFound
+		}
+		return s.applyRedundancy(ctx, projectId, project.RequiredAnnotators())
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Set redundancy Error", err)
+	}
+	return err
+}
+
+// applyRedundancy re-evaluates within a transaction which tasks and articles
+// of a project are answered, now that tasks need required annotators.
+func (s *MongoStore) applyRedundancy(ctx mongo.SessionContext, projectId primitive.ObjectID, required int) error {
+	// validated tasks stay answered; tasks answered before annotators
+	// were kept have none and keep their flag
+	filter := bson.M{"projectId": projectId, "isValidate": false, "gold": bson.M{"$ne": true}, "annotators": bson.M{"$exists": true}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"isAnswered": bson.M{"$gte": bson.A{bson.M{"$size": "$annotators"}, required}}}}},
+	}
+	_, err := s.db.Collection("SentiTask").UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	return s.refreshCompletion(ctx, projectId)
+}
+
+func (s *MongoStore) SetProjectArchived(projectId primitive.ObjectID, archived bool) error {
+	collection := s.db.Collection("Project")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": bson.M{"archived": archived}})
+	if err != nil {
+		log.Println("Archive project Error", err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) CountProjectArticles(projectId primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var total int64
+	for _, collection := range []string{"Articles", "SentiArticles"} {
+		count, err := s.db.Collection(collection).CountDocuments(ctx, bson.M{"projectId": projectId})
+		if err != nil {
+			log.Println("Count articles Error", err)
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// idBatch is how many ids eachIdBatch hands over at a time.
+const idBatch = 1000
+
+// eachIdBatch calls fn with the _id of the documents of collection matching
+// filter, idBatch of them at a time, so the ids of a whole project are never
+// held, or sent back in one $in.
+func (s *MongoStore) eachIdBatch(ctx context.Context, collection string, filter bson.M, fn func(ids []primitive.ObjectID) error) error {
+	cur, err := s.db.Collection(collection).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	batch := make([]primitive.ObjectID, 0, idBatch)
+	for cur.Next(ctx) {
+		var doc struct {
+			Id primitive.ObjectID `bson:"_id"`
+		}
+		err = cur.Decode(&doc)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, doc.Id)
+		if len(batch) == idBatch {
+			err = fn(batch)
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err = cur.Err(); err != nil || len(batch) == 0 {
+		return err
+	}
+	return fn(batch)
+}
+
+type collectionFilter struct {
+	collection string
+	filter     bson.M
+}
+
+// deleteMany runs the deletes in order.
+func (s *MongoStore) deleteMany(ctx context.Context, deletes []collectionFilter) error {
+	for _, d := range deletes {
+		_, err := s.db.Collection(d.collection).DeleteMany(ctx, d.filter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMRCAnswers deletes the MRC answers matching filter with their
+// validations and decisions.
+func (s *MongoStore) deleteMRCAnswers(ctx context.Context, filter bson.M) error {
+	return s.eachIdBatch(ctx, "MRCAnswer", filter, func(answerIds []primitive.ObjectID) error {
+		return s.deleteMany(ctx, []collectionFilter{
+			{"MRCDecision", bson.M{"originalId": bson.M{"$in": answerIds}}},
+			{"MRCValidation", bson.M{"originalId": bson.M{"$in": answerIds}}},
+			{"MRCAnswer", bson.M{"_id": bson.M{"$in": answerIds}}},
+		})
+	})
+}
+
+func (s *MongoStore) DeleteProject(projectId primitive.ObjectID) error {
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		res, err := s.db.Collection("Project").DeleteOne(ctx, bson.M{"_id": projectId})
+		if err != nil {
+			return err
+		}
+		if res.DeletedCount == 0 {
+			return ErrNotFound
+		}
+		err = s.deleteMRCAnswers(ctx, bson.M{"projectId": projectId})
+		if err != nil {
+			return err
+		}
+		// MRC tasks and answers keep their article id as a hex string, and
+		// the ones written before projectId existed can only be found by it.
+		err = s.eachIdBatch(ctx, "Articles", bson.M{"projectId": projectId}, func(articleIds []primitive.ObjectID) error {
+			articleHexes := make([]string, len(articleIds))
+			for i, id := range articleIds {
+				articleHexes[i] = id.Hex()
+			}
+			err := s.deleteMRCAnswers(ctx, bson.M{"articleId": bson.M{"$in": articleHexes}})
+			if err != nil {
+				return err
+			}
+			_, err = s.db.Collection("MRCTask").DeleteMany(ctx, bson.M{"articleId": bson.M{"$in": articleHexes}})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		err = s.eachIdBatch(ctx, "SentiTask", bson.M{"projectId": projectId}, func(taskIds []primitive.ObjectID) error {
+			return s.deleteMany(ctx, []collectionFilter{
+				{"SentiAspect", bson.M{"taskId": bson.M{"$in": taskIds}}},
+				{"SentiSentiment", bson.M{"taskId": bson.M{"$in": taskIds}}},
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return s.deleteMany(ctx, []collectionFilter{
+			{"MRCValidation", bson.M{"projectId": projectId}},
+			{"MRCTask", bson.M{"projectId": projectId}},
+			{"Articles", bson.M{"projectId": projectId}},
+			{"SentiFinalAnswer", bson.M{"projectId": projectId}},
+			{"SentiTask", bson.M{"projectId": projectId}},
+			{"SentiArticles", bson.M{"projectId": projectId}},
+			{"Authentication", bson.M{"projectId": projectId}},
+			{"Claim", bson.M{"projectId": projectId}},
+			{"Label", bson.M{"projectId": projectId}},
+			{"GoldResult", bson.M{"projectId": projectId}},
+			{"Revision", bson.M{"projectId": projectId}},
+		})
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Delete project Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) GetProjectCount() (int64, error) {
+	ProjectCollection := s.db.Collection("Project")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	itemCount, err := ProjectCollection.CountDocuments(ctx, bson.M{})
 	if err != nil {
@@ -182,20 +523,175 @@ func GetProjectCount(db *mongo.Database) (int64, error) {
 	return itemCount, nil
 }
 
-func SaveProject(db *mongo.Database, project models.Project) (*mongo.InsertOneResult, error) {
-	ProjectCollection := db.Collection(project.TableName())
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveProject(project models.Project) (primitive.ObjectID, error) {
+	ProjectCollection := s.db.Collection(project.TableName())
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	res, err := ProjectCollection.InsertOne(ctx, project)
 	if err != nil {
 		log.Println("Insert project Error", err)
-		return nil, err
+		return primitive.NilObjectID, err
+	}
+	return insertedId(res), nil
+}
+
+// A transaction that fails with a transient error, e.g. a write conflict or
+// a replica set election, is run again up to transactionAttempts times,
+// waiting transactionBackoff longer before each attempt. A commit whose
+// outcome is unknown is retried alone, running fn twice could write twice.
+const (
+	transactionAttempts = 3
+	transactionBackoff  = 50 * time.Millisecond
+)
+
+// Error labels MongoDB puts on errors that are safe to retry.
+const (
+	labelTransientTransaction = "TransientTransactionError"
+	labelUnknownCommitResult  = "UnknownTransactionCommitResult"
+)
+
+// hasErrorLabel reports whether MongoDB labelled err with label.
+func hasErrorLabel(err error, label string) bool {
+	var labelled interface{ HasErrorLabel(string) bool }
+	return errors.As(err, &labelled) && labelled.HasErrorLabel(label)
+}
+
+// withTransaction runs fn in one multi-document transaction, see
+// transactionAttempts. MongoDB only offers those on replica sets and
+// sharded clusters, so a standalone server fails here instead of leaving
+// half of the writes behind.
+func (s *MongoStore) withTransaction(fn func(ctx mongo.SessionContext) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.runTransaction(fn)
+		if err == nil || attempt == transactionAttempts || !hasErrorLabel(err, labelTransientTransaction) {
+			return err
+		}
+		log.Println("Transaction attempt", attempt, "failed, retrying:", err)
+		time.Sleep(time.Duration(attempt) * transactionBackoff)
+	}
+}
+
+// runTransaction makes one attempt of withTransaction, each with its own
+// timeout.
+func (s *MongoStore) runTransaction(fn func(ctx mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	return mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		err := sc.StartTransaction()
+		if err != nil {
+			return err
+		}
+		err = fn(sc)
+		if err != nil {
+			// the error of fn is the one worth reporting
+			_ = sc.AbortTransaction(sc)
+			return err
+		}
+		for attempt := 1; ; attempt++ {
+			err = sc.CommitTransaction(sc)
+			if err == nil || attempt == transactionAttempts || !hasErrorLabel(err, labelUnknownCommitResult) {
+				return err
+			}
+			log.Println("Commit attempt", attempt, "failed, retrying:", err)
+		}
+	})
+}
+
+// insertMany skips empty batches, which InsertMany rejects.
+func (s *MongoStore) insertMany(ctx context.Context, collection string, docs []interface{}) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err := s.db.Collection(collection).InsertMany(ctx, docs)
+	return err
+}
+
+// insertContent must run inside withTransaction.
+func (s *MongoStore) insertContent(ctx mongo.SessionContext, content ProjectContent) error {
+	batches := []struct {
+		collection string
+		docs       []interface{}
+	}{
+		{"Authentication", make([]interface{}, len(content.Auths))},
+		{"Articles", make([]interface{}, len(content.Articles))},
+		{"MRCTask", make([]interface{}, len(content.MRCTasks))},
+		{"MRCAnswer", make([]interface{}, len(content.MRCAnswers))},
+		{"SentiArticles", make([]interface{}, len(content.SentiArticles))},
+		{"SentiTask", make([]interface{}, len(content.SentiTasks))},
+		{"SentiAspect", make([]interface{}, len(content.SentiAspects))},
+		{"SentiSentiment", make([]interface{}, len(content.SentiSentiments))},
+	}
+	for i, a := range content.Auths {
+		batches[0].docs[i] = a
 	}
-	return res, nil
+	for i, a := range content.Articles {
+		batches[1].docs[i] = a
+	}
+	for i, t := range content.MRCTasks {
+		batches[2].docs[i] = t
+	}
+	for i, a := range content.MRCAnswers {
+		batches[3].docs[i] = a
+	}
+	for i, a := range content.SentiArticles {
+		batches[4].docs[i] = a
+	}
+	for i, t := range content.SentiTasks {
+		batches[5].docs[i] = t
+	}
+	for i, a := range content.SentiAspects {
+		batches[6].docs[i] = a
+	}
+	for i, s := range content.SentiSentiments {
+		batches[7].docs[i] = s
+	}
+	for _, batch := range batches {
+		err := s.insertMany(ctx, batch.collection, batch.docs)
+		if err != nil {
+			return err
+		}
+	}
+	for projectId, articleIds := range content.articleIds() {
+		err := s.refreshCompletion(ctx, projectId, articleIds...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MongoStore) CreateProject(project models.Project, content ProjectContent) error {
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		_, err := s.db.Collection(project.TableName()).InsertOne(ctx, project)
+		if err != nil {
+			return err
+		}
+		return s.insertContent(ctx, content)
+	})
+	if err != nil {
+		log.Println("Create project Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) AddProjectContent(content ProjectContent) error {
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		return s.insertContent(ctx, content)
+	})
+	if err != nil {
+		log.Println("Add project content Error", err)
+	}
+	return err
 }
 
-func GetUsersByIds(db *mongo.Database, userIds []string) ([]models.User, error) {
-	collection := db.Collection("GUser")
+func (s *MongoStore) GetUsersByIds(userIds []string) ([]models.User, error) {
+	collection := s.db.Collection("GUser")
 	var serviceResult = []models.User{}
 	log.Println("userIds", userIds)
 	// batch query from userIds
@@ -216,14 +712,14 @@ func GetUsersByIds(db *mongo.Database, userIds []string) ([]models.User, error)
 	return serviceResult, nil
 }
 
-func GetUser(db *mongo.Database, queryBson bson.M) (*models.User, error) {
-	collection := db.Collection("GUser")
+func (s *MongoStore) GetUser(user models.User) (*models.User, error) {
+	collection := s.db.Collection("GUser")
 	var serviceResult = models.User{}
-	cur := collection.FindOne(context.Background(), queryBson)
+	cur := collection.FindOne(context.Background(), user.ToQueryBson())
 	// if no user then return nil
 	if cur.Err() != nil {
 		log.Println("Can't find user in DB")
-		return nil, cur.Err()
+		return nil, notFound(cur.Err())
 	}
 	// if has user then return
 	err := cur.Decode(&serviceResult)
@@ -235,8 +731,8 @@ func GetUser(db *mongo.Database, queryBson bson.M) (*models.User, error) {
 	return &serviceResult, nil
 }
 
-func GetUsers(db *mongo.Database) ([]models.User, error) {
-	collection := db.Collection("GUser")
+func (s *MongoStore) GetUsers() ([]models.User, error) {
+	collection := s.db.Collection("GUser")
 	var serviceResult = []models.User{}
 	cur, err := collection.Find(context.Background(), bson.M{})
 	if err != nil {
@@ -255,21 +751,21 @@ func GetUsers(db *mongo.Database) ([]models.User, error) {
 	return serviceResult, nil
 }
 
-func SaveUser(db *mongo.Database, user models.User) (*mongo.InsertOneResult, error) {
-	UserCollection := db.Collection("GUser")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) SaveUser(user models.User) (primitive.ObjectID, error) {
+	UserCollection := s.db.Collection("GUser")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	res, err := UserCollection.InsertOne(ctx, user)
 	if err != nil {
 		log.Println("Insert user Error", err)
-		return nil, err
+		return primitive.NilObjectID, err
 	}
 	log.Println("Insert user Success", user)
-	return res, nil
+	return insertedId(res), nil
 }
 
-func GetArticlesByProjectId(db *mongo.Database, projectId int) ([]models.Article, error) {
-	collection := db.Collection("Articles")
+func (s *MongoStore) GetArticlesByProjectId(projectId primitive.ObjectID) ([]models.Article, error) {
+	collection := s.db.Collection("Articles")
 	var articles = []models.Article{}
 	cur, err := collection.Find(context.Background(), bson.M{"projectId": projectId})
 	if err != nil {
@@ -289,22 +785,22 @@ func GetArticlesByProjectId(db *mongo.Database, projectId int) ([]models.Article
 }
 
 // return only one article
-func GetArticleByArticleId(db *mongo.Database, queryBson bson.M) (*models.Article, error) {
-	ArticleCollection := db.Collection("Articles")
+func (s *MongoStore) GetArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error) {
+	ArticleCollection := s.db.Collection("Articles")
 	var serviceResult models.Article
-	cur := ArticleCollection.FindOne(context.Background(), queryBson)
+	cur := ArticleCollection.FindOne(context.Background(), bson.M{"_id": articleId})
 	err := cur.Decode(&serviceResult)
 	if err != nil {
 		log.Println("Decode articles Error", err)
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &serviceResult, nil
 }
 
-func GetTasksByArticleId(db *mongo.Database, queryBson bson.M) ([]models.MRCTask, error) {
-	TaskCollection := db.Collection("MRCTask")
+func (s *MongoStore) GetTasksByArticleId(articleId string) ([]models.MRCTask, error) {
+	TaskCollection := s.db.Collection("MRCTask")
 	var tasks []models.MRCTask
-	cur, err := TaskCollection.Find(context.Background(), queryBson)
+	cur, err := TaskCollection.Find(context.Background(), bson.M{"articleId": articleId})
 	if err != nil {
 		log.Println("Find tasks Error", err)
 		return nil, err
@@ -321,8 +817,8 @@ func GetTasksByArticleId(db *mongo.Database, queryBson bson.M) ([]models.MRCTask
 	return tasks, nil
 }
 
-func GetAnswers(db *mongo.Database, task models.MRCAnswer) ([]*models.MRCAnswer, error) {
-	AnswerCollection := db.Collection("MRCAnswer")
+func (s *MongoStore) GetAnswers(task models.MRCAnswer) ([]*models.MRCAnswer, error) {
+	AnswerCollection := s.db.Collection("MRCAnswer")
 	var answers []*models.MRCAnswer
 	cur, err := AnswerCollection.Find(context.Background(), task.ToQueryBson())
 	if err != nil {
@@ -341,127 +837,399 @@ func GetAnswers(db *mongo.Database, task models.MRCAnswer) ([]*models.MRCAnswer,
 	return answers, nil
 }
 
-func GetTaskById(db *mongo.Database, taskModel models.MRCTask) (*models.MRCTask, error) {
-	TaskCollection := db.Collection("MRCTask")
+func (s *MongoStore) GetTaskById(taskModel models.MRCTask) (*models.MRCTask, error) {
+	TaskCollection := s.db.Collection("MRCTask")
 	var task models.MRCTask
 	result := TaskCollection.FindOne(context.Background(), taskModel.ToQueryBson())
 	err := result.Decode(&task)
 	if err != nil {
 		log.Println("Decode task Error", err)
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &task, nil
 }
 
-func SaveAnswer(db *mongo.Database, answer models.MRCAnswer) (*mongo.InsertOneResult, error) {
-	AnswerCollection := db.Collection("MRCAnswer")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	res, err := AnswerCollection.InsertOne(ctx, answer)
+func (s *MongoStore) AddMRCAnnotator(task models.MRCTask, userId string, required int) error {
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		return s.addMRCAnnotator(ctx, task, userId, required)
+	})
 	if err != nil {
-		log.Println("Insert answers Error", err)
-		return nil, err
+		log.Println("Add MRC annotator Error", err)
 	}
-	return res, nil
+	return err
 }
 
-func UpdateAnswer(db *mongo.Database, answer models.MRCValidation) (*mongo.UpdateResult, error) {
-	AnswerCollection := db.Collection("MRCAnswer")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	filter := bson.M{"_id": bson.M{"$eq": answer.OriginalId}}
-	update := bson.M{"$set": bson.M{"status": answer.Status}}
-	res, err := AnswerCollection.UpdateOne(ctx, filter, update)
-	log.Println("res type", reflect.TypeOf(res).Kind())
+// addMRCAnnotator is AddMRCAnnotator within a transaction.
+func (s *MongoStore) addMRCAnnotator(ctx mongo.SessionContext, task models.MRCTask, userId string, required int) error {
+	articleId, err := primitive.ObjectIDFromHex(task.ArticleId)
 	if err != nil {
-		log.Println("update answer error", err)
-		return nil, err
+		return err
+	}
+	filter := bson.M{"articleId": task.ArticleId, "taskId": task.TaskId, "taskType": "MRC", "annotators": bson.M{"$ne": userId}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"annotators": withAnnotator(userId)}}},
+		{{Key: "$set", Value: bson.M{"answered": bson.M{"$size": "$annotators"}}}},
+	}
+	var updated models.MRCTask
+	err = s.db.Collection("MRCTask").FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		// the user answered the task before
+		return nil
+	}
+	if err != nil || updated.Answered < required {
+		return err
+	}
+	// tasks written before projectId existed only know their article
+	var article models.Article
+	err = s.db.Collection("Articles").FindOne(ctx, bson.M{"_id": articleId}).Decode(&article)
+	if err != nil {
+		return notFound(err)
 	}
-	return res, nil
+	return s.refreshCompletion(ctx, article.ProjectId, articleId)
 }
 
-func UpdateValidationStatus(db *mongo.Database, status models.MRCValidation) error {
-	ValidationCollection := db.Collection("MRCValidation")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) GetOpenMRCTask(projectId primitive.ObjectID, userId string, required int) (*models.MRCTask, error) {
+	TaskCollection := s.db.Collection("MRCTask")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	filter := bson.M{"_id": bson.M{"$eq": status.OriginalId}}
-	update := bson.M{"$set": bson.M{"status": status.Status}}
-	res, err := ValidationCollection.UpdateOne(ctx, filter, update)
-	log.Println("res", res)
+	var task models.MRCTask
+	filter := bson.M{"projectId": projectId, "taskType": "MRC", "answered": bson.M{"$lt": required}, "annotators": bson.M{"$ne": userId}}
+	err := TaskCollection.FindOne(ctx, filter).Decode(&task)
 	if err != nil {
-		log.Println("update answer error", err)
-		return err
+		return nil, notFound(err)
 	}
-	return nil
+	return &task, nil
 }
 
-func SaveValidationStatus(db *mongo.Database, validationAnswer models.MRCValidation) (*mongo.InsertOneResult, error) {
-	log.Println("validation answer save:", validationAnswer)
-	ValidationCollection := db.Collection("MRCValidation")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	res, err := ValidationCollection.InsertOne(ctx, validationAnswer)
+func (s *MongoStore) SaveAnswer(answer models.MRCAnswer) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var err error
+		id, err = s.insertAnswer(ctx, answer)
+		return err
+	})
 	if err != nil {
 		log.Println("Insert answers Error", err)
-		return nil, err
+		return primitive.NilObjectID, err
 	}
-	return res, nil
+	return id, nil
 }
 
-func GetRandomValidationQuestion(db *mongo.Database, question models.MRCAnswer) (*models.MRCAnswer, error) {
-	AnswerCollection := db.Collection("MRCAnswer")
-	var questionPair models.MRCAnswer
-	res := AnswerCollection.FindOne(context.Background(), question.ToQueryBson())
-	err := res.Decode(&questionPair)
+// insertAnswer is SaveAnswer within a transaction.
+func (s *MongoStore) insertAnswer(ctx mongo.SessionContext, answer models.MRCAnswer) (primitive.ObjectID, error) {
+	res, err := s.db.Collection("MRCAnswer").InsertOne(ctx, answer)
 	if err != nil {
-		log.Println("Decode task Error", err)
-		return nil, err
+		return primitive.NilObjectID, err
 	}
-	return &questionPair, nil
+	id := insertedId(res)
+	_, err = s.revise(ctx, models.Revision{Kind: models.RevisionMRCAnswer, TargetId: id, Action: models.RevisionAnswered, Author: answer.UserId})
+	return id, err
 }
 
-func FindAnswerById(db *mongo.Database, id primitive.ObjectID) (*models.MRCAnswer, error) {
-	AnswerCollection := db.Collection("MRCAnswer")
-	var originalAnswerInfo models.MRCAnswer
-	log.Println("Find original", id)
-	res := AnswerCollection.FindOne(context.Background(), bson.M{"_id": id})
-	err := res.Decode(&originalAnswerInfo)
+func (s *MongoStore) AnswerMRCTask(project models.Project, task models.MRCTask, answer models.MRCAnswer, gold *models.GoldResult) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var err error
+		if gold != nil {
+			err = s.recordGoldResult(ctx, project, *gold)
+			if err != nil {
+				return err
+			}
+		}
+		id, err = s.insertAnswer(ctx, answer)
+		if err != nil || gold != nil {
+			return err
+		}
+		return s.addMRCAnnotator(ctx, task, answer.UserId, project.RequiredAnnotators())
+	})
 	if err != nil {
-		log.Println("Decode original info error", err)
+		if err != ErrAnnotated {
+			log.Println("Answer MRC task Error", err)
+		}
+		return primitive.NilObjectID, err
+	}
+	return id, nil
+}
+
+func (s *MongoStore) UpdateAnswer(answer models.MRCValidation, userId string) error {
+	AnswerCollection := s.db.Collection("MRCAnswer")
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		filter := bson.M{"_id": bson.M{"$eq": answer.OriginalId}}
+		update := bson.M{"$set": bson.M{"status": answer.Status}}
+		res, err := AnswerCollection.UpdateOne(ctx, filter, update)
+		log.Println("res", res)
+		if err != nil {
+			return err
+		}
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionMRCAnswer, TargetId: answer.OriginalId, Action: models.RevisionDecided, Author: userId})
+		return err
+	})
+	if err != nil {
+		log.Println("update answer error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) UpdateValidationStatus(status models.MRCValidation) error {
+	ValidationCollection := s.db.Collection("MRCValidation")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	filter := bson.M{"_id": bson.M{"$eq": status.OriginalId}}
+	update := bson.M{"$set": bson.M{"status": status.Status}}
+	res, err := ValidationCollection.UpdateOne(ctx, filter, update)
+	log.Println("res", res)
+	if err != nil {
+		log.Println("update answer error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) GetValidationsByProjectId(projectId primitive.ObjectID) ([]models.MRCValidation, error) {
+	ValidationCollection := s.db.Collection("MRCValidation")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var validations = []models.MRCValidation{}
+	cur, err := ValidationCollection.Find(ctx, bson.M{"projectId": projectId})
+	if err != nil {
+		log.Println("Find validations Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &validations)
+	if err != nil {
+		log.Println("Decode validations Error", err)
 		return nil, err
 	}
+	return validations, nil
+}
+
+func (s *MongoStore) SaveValidationStatus(validationAnswer models.MRCValidation) (primitive.ObjectID, error) {
+	log.Println("validation answer save:", validationAnswer)
+	ValidationCollection := s.db.Collection("MRCValidation")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	res, err := ValidationCollection.InsertOne(ctx, validationAnswer)
+	if err != nil {
+		log.Println("Insert answers Error", err)
+		return primitive.NilObjectID, err
+	}
+	return insertedId(res), nil
+}
+
+func (s *MongoStore) SaveValidation(answer models.MRCAnswer, validation models.MRCValidation) (primitive.ObjectID, error) {
+	var validationId primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		res, err := s.db.Collection("MRCAnswer").InsertOne(ctx, answer)
+		if err != nil {
+			return err
+		}
+		validation.ValidationId = insertedId(res)
+		res, err = s.db.Collection("MRCValidation").InsertOne(ctx, validation)
+		if err != nil {
+			return err
+		}
+		validationId = insertedId(res)
+		// only an unverified answer matches, so that two validators cannot
+		// both validate it; the inserts above roll back otherwise
+		filter := bson.M{"_id": validation.OriginalId, "status": "unverified"}
+		updated, err := s.db.Collection("MRCAnswer").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": validation.Status}})
+		if err != nil {
+			return err
+		}
+		if updated.MatchedCount == 0 {
+			return s.reviewedOrMissing(ctx, "MRCAnswer", validation.OriginalId)
+		}
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionMRCAnswer, TargetId: validation.OriginalId, Action: models.RevisionValidated, Author: validation.ValidationUserId})
+		return err
+	})
+	if err != nil {
+		log.Println("Save validation Error", err)
+		return primitive.NilObjectID, err
+	}
+	return validationId, nil
+}
+
+// reviewedOrMissing tells why an update of the unreviewed document id of
+// collection matched nothing: ErrDecided when it exists, ErrNotFound if not.
+func (s *MongoStore) reviewedOrMissing(ctx context.Context, collection string, id primitive.ObjectID) error {
+	count, err := s.db.Collection(collection).CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrDecided
+}
+
+func (s *MongoStore) GetRandomValidationQuestion(question models.MRCAnswer, exclude []primitive.ObjectID) (*models.MRCAnswer, error) {
+	AnswerCollection := s.db.Collection("MRCAnswer")
+	var questionPair models.MRCAnswer
+	// the validation queue, without the answers of the validator
+	question.TaskType = "MRCValidation"
+	res := AnswerCollection.FindOne(context.Background(), excluding(question.ToQueryBson(), exclude))
+	err := res.Decode(&questionPair)
+	if err != nil {
+		log.Println("Decode task Error", err)
+		return nil, notFound(err)
+	}
+	return &questionPair, nil
+}
+
+func (s *MongoStore) FindAnswerById(id primitive.ObjectID) (*models.MRCAnswer, error) {
+	AnswerCollection := s.db.Collection("MRCAnswer")
+	var originalAnswerInfo models.MRCAnswer
+	log.Println("Find original", id)
+	res := AnswerCollection.FindOne(context.Background(), bson.M{"_id": id})
+	err := res.Decode(&originalAnswerInfo)
+	if err != nil {
+		log.Println("Decode original info error", err)
+		return nil, notFound(err)
+	}
 	return &originalAnswerInfo, nil
 }
 
-func GetRandomDecisionInfo(db *mongo.Database, userId string) (*models.MRCValidation, error) {
-	ValidationCollection := db.Collection("MRCValidation")
+func (s *MongoStore) GetAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error) {
+	AnswerCollection := s.db.Collection("MRCAnswer")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var answers = []models.MRCAnswer{}
+	if len(articleIds) == 0 {
+		return answers, nil
+	}
+	cur, err := AnswerCollection.Find(ctx, bson.M{"articleId": bson.M{"$in": articleIds}, "taskType": "MRC"})
+	if err != nil {
+		log.Println("Find answers Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &answers)
+	if err != nil {
+		log.Println("Decode answers Error", err)
+		return nil, err
+	}
+	return answers, nil
+}
+
+func (s *MongoStore) GetValidationAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error) {
+	AnswerCollection := s.db.Collection("MRCAnswer")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var answers = []models.MRCAnswer{}
+	if len(articleIds) == 0 {
+		return answers, nil
+	}
+	cur, err := AnswerCollection.Find(ctx, bson.M{"articleId": bson.M{"$in": articleIds}, "taskType": "Validation"})
+	if err != nil {
+		log.Println("Find validation answers Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &answers)
+	if err != nil {
+		log.Println("Decode validation answers Error", err)
+		return nil, err
+	}
+	return answers, nil
+}
+
+func (s *MongoStore) GetRandomDecisionInfo(projectId primitive.ObjectID, userId string, exclude []primitive.ObjectID) (*models.MRCValidation, error) {
+	ValidationCollection := s.db.Collection("MRCValidation")
 	var decisionInfo models.MRCValidation
-	id, _ := primitive.ObjectIDFromHex(userId)
-	res := ValidationCollection.FindOne(context.Background(), bson.M{"status": "pending", "validationUserId": bson.M{"$ne": id}, "labelUserId": bson.M{"$ne": id}})
+	// user ids are stored as plain strings, so compare them as strings
+	filter := bson.M{"projectId": projectId, "status": "pending", "validationUserId": bson.M{"$ne": userId}, "labelUserId": bson.M{"$ne": userId}}
+	res := ValidationCollection.FindOne(context.Background(), excluding(filter, exclude))
 	resErr := res.Decode(&decisionInfo)
 	if resErr != nil {
 		log.Println("Decode decisionInfo error", resErr)
-		return nil, resErr
+		return nil, notFound(resErr)
 	}
 	return &decisionInfo, nil
 }
 
-func SaveDecision(db *mongo.Database, decisionResult models.MRCDecision) (*mongo.InsertOneResult, error) {
+func (s *MongoStore) SaveDecision(decisionResult models.MRCDecision) (primitive.ObjectID, error) {
 	log.Println("decision save:", decisionResult)
-	DecisionCollection := db.Collection("MRCDecision")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	DecisionCollection := s.db.Collection("MRCDecision")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 	res, err := DecisionCollection.InsertOne(ctx, decisionResult)
 	if err != nil {
 		log.Println("Insert answers Error", err)
+		return primitive.NilObjectID, err
+	}
+	return insertedId(res), nil
+}
+
+func (s *MongoStore) Decide(projectId primitive.ObjectID, decision models.MRCDecision, status string) (primitive.ObjectID, error) {
+	var decisionId primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var validation models.MRCValidation
+		filter := bson.M{"_id": decision.ValidationStatusId, "projectId": projectId, "originalId": decision.OriginalId}
+		err := s.db.Collection("MRCValidation").FindOne(ctx, filter).Decode(&validation)
+		if err != nil {
+			return notFound(err)
+		}
+		if !decision.ValidationId.IsZero() && decision.ValidationId != validation.ValidationId {
+			return ErrNotFound
+		}
+		decision.ValidationId = validation.ValidationId
+		if decision.UserId == validation.LabelUserId || decision.UserId == validation.ValidationUserId {
+			return ErrOwnWork
+		}
+		// only a pending validation matches, so that two adjudicators
+		// cannot both decide it
+		updated, err := s.db.Collection("MRCValidation").UpdateOne(ctx, bson.M{"_id": validation.Id, "status": "pending"}, bson.M{"$set": bson.M{"status": status}})
+		if err != nil {
+			return err
+		}
+		if updated.MatchedCount == 0 {
+			return ErrDecided
+		}
+		updated, err = s.db.Collection("MRCAnswer").UpdateOne(ctx, bson.M{"_id": decision.OriginalId}, bson.M{"$set": bson.M{"status": status}})
+		if err != nil {
+			return err
+		}
+		if updated.MatchedCount == 0 {
+			return ErrNotFound
+		}
+		res, err := s.db.Collection("MRCDecision").InsertOne(ctx, decision)
+		if err != nil {
+			return err
+		}
+		decisionId = insertedId(res)
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionMRCAnswer, TargetId: decision.OriginalId, Action: models.RevisionDecided, Author: decision.UserId})
+		return err
+	})
+	if err != nil {
+		log.Println("Save decision Error", err)
+		return primitive.NilObjectID, err
+	}
+	return decisionId, nil
+}
+
+func (s *MongoStore) GetDecisionsByOriginalIds(originalIds []primitive.ObjectID) ([]models.MRCDecision, error) {
+	DecisionCollection := s.db.Collection("MRCDecision")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var decisions = []models.MRCDecision{}
+	if len(originalIds) == 0 {
+		return decisions, nil
+	}
+	cur, err := DecisionCollection.Find(ctx, bson.M{"originalId": bson.M{"$in": originalIds}})
+	if err != nil {
+		log.Println("Find decisions Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &decisions)
+	if err != nil {
+		log.Println("Decode decisions Error", err)
 		return nil, err
 	}
-	return res, nil
+	return decisions, nil
 }
 
 //================================= sentiment API =================================
-func GetSentiArticles(db *mongo.Database, query models.Project) ([]models.Article, error) {
-	collection := db.Collection("SentiArticles")
+func (s *MongoStore) GetSentiArticles(query models.Project) ([]models.Article, error) {
+	collection := s.db.Collection("SentiArticles")
 	var articles = []models.Article{}
 	cur, err := collection.Find(context.Background(), bson.M{"projectId": query.ProjectId, "isAnswered": false})
 	if err != nil {
@@ -480,22 +1248,23 @@ func GetSentiArticles(db *mongo.Database, query models.Project) ([]models.Articl
 	return articles, nil
 }
 
-func GetSentiArticleByArticleId(db *mongo.Database, queryBson bson.M) (*models.Article, error) {
-	ArticleCollection := db.Collection("SentiArticles")
+func (s *MongoStore) GetSentiArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error) {
+	ArticleCollection := s.db.Collection("SentiArticles")
 	var serviceResult models.Article
-	cur := ArticleCollection.FindOne(context.Background(), queryBson)
+	cur := ArticleCollection.FindOne(context.Background(), bson.M{"_id": articleId})
 	err := cur.Decode(&serviceResult)
 	if err != nil {
 		log.Println("Decode articles Error", err)
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &serviceResult, nil
 }
 
-func GetSentiTasksByArticleId(db *mongo.Database, queryBson bson.M) ([]models.SentiTask, error) {
-	TaskCollection := db.Collection("SentiTask")
+func (s *MongoStore) GetSentiTasksByArticleId(articleId primitive.ObjectID, isAnswered bool) ([]models.SentiTask, error) {
+	TaskCollection := s.db.Collection("SentiTask")
 	var tasks []models.SentiTask
 	// cur, err := TaskCollection.Find(context.Background(), bson.D{{}})
+	queryBson := bson.M{"articleId": articleId, "isAnswered": isAnswered}
 	log.Println("queryBson", queryBson)
 	cur, err := TaskCollection.Find(context.Background(), queryBson)
 	if err != nil {
@@ -516,8 +1285,8 @@ func GetSentiTasksByArticleId(db *mongo.Database, queryBson bson.M) ([]models.Se
 	return tasks, nil
 }
 
-func GetAspectByTaskId(db *mongo.Database, task models.SentiAspect) ([]*models.SentiAspect, error) {
-	AnswerCollection := db.Collection("SentiAspect")
+func (s *MongoStore) GetAspectByTaskId(task models.SentiAspect) ([]*models.SentiAspect, error) {
+	AnswerCollection := s.db.Collection("SentiAspect")
 	var aspects []*models.SentiAspect
 	cur, err := AnswerCollection.Find(context.Background(), task.ToQueryBson())
 	if err != nil {
@@ -536,71 +1305,209 @@ func GetAspectByTaskId(db *mongo.Database, task models.SentiAspect) ([]*models.S
 	return aspects, nil
 }
 
-func GetSentiTaskById(db *mongo.Database, taskModel bson.M) (*models.SentiTask, error) {
-	TaskCollection := db.Collection("SentiTask")
+func (s *MongoStore) GetAspectsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiAspect, error) {
+	AspectCollection := s.db.Collection("SentiAspect")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var aspects = []models.SentiAspect{}
+	if len(taskIds) == 0 {
+		return aspects, nil
+	}
+	cur, err := AspectCollection.Find(ctx, bson.M{"taskId": bson.M{"$in": taskIds}})
+	if err != nil {
+		log.Println("Find aspects Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &aspects)
+	if err != nil {
+		log.Println("Decode aspects Error", err)
+		return nil, err
+	}
+	return aspects, nil
+}
+
+func (s *MongoStore) GetSentimentsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiSentiment, error) {
+	SentiCollection := s.db.Collection("SentiSentiment")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var sentiments = []models.SentiSentiment{}
+	if len(taskIds) == 0 {
+		return sentiments, nil
+	}
+	cur, err := SentiCollection.Find(ctx, bson.M{"taskId": bson.M{"$in": taskIds}})
+	if err != nil {
+		log.Println("Find sentiments Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &sentiments)
+	if err != nil {
+		log.Println("Decode sentiments Error", err)
+		return nil, err
+	}
+	return sentiments, nil
+}
+
+func (s *MongoStore) GetSentiTasksByProjectId(projectId primitive.ObjectID) ([]models.SentiTask, error) {
+	TaskCollection := s.db.Collection("SentiTask")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var tasks = []models.SentiTask{}
+	cur, err := TaskCollection.Find(ctx, bson.M{"projectId": projectId})
+	if err != nil {
+		log.Println("Find tasks Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &tasks)
+	if err != nil {
+		log.Println("Decode tasks Error", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *MongoStore) GetSentiTaskById(taskId primitive.ObjectID, taskType string) (*models.SentiTask, error) {
+	TaskCollection := s.db.Collection("SentiTask")
 	var task models.SentiTask
-	result := TaskCollection.FindOne(context.Background(), taskModel)
+	result := TaskCollection.FindOne(context.Background(), bson.M{"_id": taskId, "taskType": taskType})
 	err := result.Decode(&task)
 	if err != nil {
 		log.Println("Decode task Error", err)
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &task, nil
 }
 
-func GetRandomSentiTask(db *mongo.Database, ansQuery models.SentiTask) (*models.SentiTask, error) {
-	taskCollection := db.Collection("SentiTask")
+func (s *MongoStore) FindSentiTaskById(taskId primitive.ObjectID) (*models.SentiTask, error) {
+	TaskCollection := s.db.Collection("SentiTask")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var task models.SentiTask
+	err := TaskCollection.FindOne(ctx, bson.M{"_id": taskId}).Decode(&task)
+	if err != nil {
+		log.Println("Decode task Error", err)
+		return nil, notFound(err)
+	}
+	return &task, nil
+}
+
+func (s *MongoStore) GetRandomSentiTask(ansQuery models.SentiTask, userId string, exclude []primitive.ObjectID) (*models.SentiTask, error) {
+	taskCollection := s.db.Collection("SentiTask")
 	var task models.SentiTask
-	cur := taskCollection.FindOne(context.Background(), bson.M{"taskType": ansQuery.TaskType, "projectId": ansQuery.ProjectId, "isAnswered": true, "isValidate": false})
+	filter := bson.M{"taskType": ansQuery.TaskType, "projectId": ansQuery.ProjectId, "isAnswered": true, "isValidate": false, "annotators": bson.M{"$ne": userId}}
+	cur := taskCollection.FindOne(context.Background(), excluding(filter, exclude))
 	err := cur.Decode(&task)
 	if err != nil {
 		log.Println("get radon task Error", err)
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	return &task, nil
 }
 
-func SaveSentiAnswer(db *mongo.Database, answer models.SentiAnswer) (*mongo.InsertManyResult, error) {
-	AspectCollection := db.Collection("SentiAspect")
+func (s *MongoStore) GetOpenSentiTask(projectId primitive.ObjectID, userId string) (*models.SentiTask, error) {
+	TaskCollection := s.db.Collection("SentiTask")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var task models.SentiTask
+	filter := bson.M{"projectId": projectId, "isAnswered": false, "gold": bson.M{"$ne": true}, "annotators": bson.M{"$ne": userId}}
+	err := TaskCollection.FindOne(ctx, filter).Decode(&task)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &task, nil
+}
+
+func (s *MongoStore) SaveSentiAnswer(answer models.SentiAnswer, required int) error {
+	return s.AnswerSentiTask(models.Project{}, answer, required, nil)
+}
+
+func (s *MongoStore) AnswerSentiTask(project models.Project, answer models.SentiAnswer, required int, gold *models.GoldResult) error {
+	if len(answer.Aspect) == 0 || len(answer.Sentiment) == 0 {
+		return errEmptyInsert
+	}
 	aspectList := make([]interface{}, len(answer.Aspect))
 	for i := range answer.Aspect {
 		aspectList[i] = answer.Aspect[i]
 	}
-	SentiCollection := db.Collection("SentiSentiment")
 	sentiList := make([]interface{}, len(answer.Sentiment))
 	for i := range answer.Sentiment {
 		sentiList[i] = answer.Sentiment[i]
 	}
+	taskId, userId := answer.Aspect[0].TaskId, answer.Aspect[0].UserId
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	res, err := AspectCollection.InsertMany(ctx, aspectList)
-	if err != nil {
-		log.Println("Insert aspects Error", err)
-		return nil, err
-	}
-	res, err = SentiCollection.InsertMany(ctx, sentiList)
-	if err != nil {
-		log.Println("Insert sentiments Error", err)
-		return nil, err
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		TaskCollection := s.db.Collection("SentiTask")
+		filter := bson.M{"_id": taskId, "isAnswered": false, "annotators": bson.M{"$ne": userId}}
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"annotators": withAnnotator(userId)}}},
+			{{Key: "$set", Value: bson.M{"isAnswered": bson.M{"$gte": bson.A{bson.M{"$size": "$annotators"}, required}}}}},
+		}
+		if required <= 0 {
+			filter = bson.M{"_id": taskId, "annotators": bson.M{"$ne": userId}}
+			update = update[:1]
+		}
+		var task models.SentiTask
+		err := TaskCollection.FindOneAndUpdate(ctx, filter, update).Decode(&task)
+		if err == mongo.ErrNoDocuments {
+			err = TaskCollection.FindOne(ctx, bson.M{"_id": taskId}).Decode(&task)
+			if err != nil {
+				return notFound(err)
+			}
+			if containsString(task.Annotators, userId) {
+				return ErrAnnotated
+			}
+			return ErrTaskAnswered
+		}
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Collection("SentiAspect").InsertMany(ctx, aspectList)
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Collection("SentiSentiment").InsertMany(ctx, sentiList)
+		if err != nil {
+			return err
+		}
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionSentiTask, TargetId: taskId, Action: models.RevisionAnswered, Author: userId})
+		if err != nil {
+			return err
+		}
+		err = s.refreshCompletion(ctx, task.ProjectId, task.ArticleId)
+		if err != nil || gold == nil {
+			return err
+		}
+		err = s.recordGoldResult(ctx, project, *gold)
+		if err == ErrAnnotated {
+			// answered before a discard, the first result stays
+			return nil
+		}
+		return err
+	})
+	if err != nil && err != ErrAnnotated && err != ErrTaskAnswered {
+		log.Println("Save senti answer Error", err)
 	}
+	return err
+}
 
-	TaskCollection := db.Collection("SentiTask")
-	filter := bson.M{"_id": answer.Aspect[0].TaskId}
-	update := bson.M{"$set": bson.M{"isAnswered": true}}
-	_, err = TaskCollection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		log.Println("Insert comment Error", err)
-		return res, err
-	}
+// withAnnotator is the aggregation expression of the annotators of a task
+// with userId added, for tasks written before annotators were kept too.
+func withAnnotator(userId string) bson.M {
+	return bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$annotators", bson.A{}}}, bson.A{userId}}}
+}
 
-	return res, nil
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 // 這邊要等到 validation 的時候才會用到
-func GetSentiAnswer(db *mongo.Database, ansQuery models.SentiSentiment) ([]*models.SentiSentiment, error) {
-	SentiCollection := db.Collection("SentiSentiment")
+func (s *MongoStore) GetSentiAnswer(ansQuery models.SentiSentiment) ([]*models.SentiSentiment, error) {
+	SentiCollection := s.db.Collection("SentiSentiment")
 
 	var sentiList []*models.SentiSentiment
 	log.Println("ansQuery.ToQueryBson()", ansQuery.ToQueryBson())
@@ -622,194 +1529,1096 @@ func GetSentiAnswer(db *mongo.Database, ansQuery models.SentiSentiment) ([]*mode
 
 	return sentiList, nil
 }
-func GetRandomSentiAnswer(db *mongo.Database, ansQuery models.SentiTask) ([]*models.SentiSentiment, error) {
-	SentiCollection := db.Collection("SentiSentiment")
-	taskCollection := db.Collection("SentiTask")
-	var sentiList []*models.SentiSentiment
-	var task models.SentiTask
-	cur := taskCollection.FindOne(context.Background(), bson.M{"taskType": ansQuery.TaskType, "isAnswered": true, "isValidate": false})
-	err := cur.Decode(&task)
-	if err != nil {
-		log.Println("get radon task Error", err)
-		return nil, err
-	}
-
-	// log.Println("ansQuery.ToQueryBson()", ansQuery.ToQueryBson())
-
-	cur2, err := SentiCollection.Find(context.Background(), bson.M{"taskId": task.TaskId})
-	if err != nil {
-		log.Println("Find answers Error", err)
-		return nil, err
-	}
-
-	for cur2.Next(context.Background()) {
-		result := models.SentiSentiment{}
-		err := cur.Decode(&result)
+func (s *MongoStore) SaveFinalAnswer(answer models.SentiAnswer) (primitive.ObjectID, error) {
+	FinalCollection := s.db.Collection("SentiFinalAnswer")
+	TaskCollection := s.db.Collection("SentiTask")
+
+	var id primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		// a task is validated once
+		filter := bson.M{"_id": answer.Task.TaskId, "isValidate": false}
+		update := bson.M{"$set": bson.M{"isValidate": true}}
+		var task models.SentiTask
+		err := TaskCollection.FindOneAndUpdate(ctx, filter, update).Decode(&task)
+		if err == mongo.ErrNoDocuments {
+			return s.reviewedOrMissing(ctx, "SentiTask", answer.Task.TaskId)
+		}
 		if err != nil {
-			log.Println("Decode answer Error", err)
-			return nil, err
+			return err
 		}
-		sentiList = append(sentiList, &result)
+		res, err := FinalCollection.InsertOne(ctx, answer)
+		if err != nil {
+			return err
+		}
+		id = insertedId(res)
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionSentiTask, TargetId: task.TaskId, Action: models.RevisionValidated, Author: answer.UserId})
+		if err != nil {
+			return err
+		}
+		return s.refreshCompletion(ctx, task.ProjectId, task.ArticleId)
+	})
+	if err != nil {
+		log.Println("Save final answer Error", err)
+		return primitive.NilObjectID, err
 	}
-
-	return sentiList, nil
+	return id, nil
 }
 
-func SaveFinalAnswer(db *mongo.Database, answer models.SentiAnswer) (*mongo.InsertOneResult, error) {
-	FinalCollection := db.Collection("SentiFinalAnswer")
-	TaskCollection := db.Collection("SentiTask")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *MongoStore) GetFinalAnswersByProjectId(projectId primitive.ObjectID) ([]models.SentiAnswer, error) {
+	FinalCollection := s.db.Collection("SentiFinalAnswer")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	res, err := FinalCollection.InsertOne(ctx, answer)
+	var answers = []models.SentiAnswer{}
+	cur, err := FinalCollection.Find(ctx, bson.M{"projectId": projectId}, options.Find().SetSort(bson.M{"_id": 1}))
 	if err != nil {
-		log.Println("Insert aspects Error", err)
+		log.Println("Find final answers Error", err)
 		return nil, err
 	}
-
-	filter := bson.M{"_id": answer.Task.TaskId}
-	update := bson.M{"$set": bson.M{"isValidate": true}}
-	// log.Println("filter:", answer, filter)
-	_, err = TaskCollection.UpdateOne(ctx, filter, update)
+	err = cur.All(ctx, &answers)
 	if err != nil {
-		log.Println("Insert comment Error", err)
-		return res, err
+		log.Println("Decode final answers Error", err)
+		return nil, err
 	}
-	return res, nil
+	return answers, nil
 }
-func CheckIsAnswered(db *mongo.Database, query models.SentiTask) (bool, error) {
-	TaskCollection := db.Collection("SentiTask")
-	ArticleCollection := db.Collection("SentiArticles")
-	var isCompleted = true
-	cur, err := TaskCollection.Find(context.Background(), bson.M{"articleId": query.ArticleId})
+func (s *MongoStore) CheckIsAnswered(query models.SentiTask) (bool, error) {
+	article, err := s.checkSentiArticle(query.ArticleId)
 	if err != nil {
-		log.Println("Find tasks Error", err)
 		return false, err
 	}
-	for cur.Next(context.Background()) {
-		result := models.SentiTask{}
-		err := cur.Decode(&result)
-		if err != nil {
-			log.Println("Decode tasks Error when check isAnswer", err)
-			return false, err
-		}
-		if result.IsAnswered == false {
-			isCompleted = false
-		}
+	return article.IsAnswered, nil
+}
 
+func (s *MongoStore) CheckIsValidated(query models.SentiTask) (bool, error) {
+	article, err := s.checkSentiArticle(query.ArticleId)
+	if err != nil {
+		return false, err
 	}
-	log.Println("isCompleted : ", isCompleted)
-	if isCompleted == true {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	return article.IsValidated, nil
+}
 
-		filter := bson.M{"_id": query.ArticleId}
-		update := bson.M{"$set": bson.M{"isAnswered": true}}
-		_, err := ArticleCollection.UpdateOne(ctx, filter, update)
+// checkSentiArticle derives the completion of an article once more, for
+// articles written before the store kept it.
+func (s *MongoStore) checkSentiArticle(articleId primitive.ObjectID) (*models.SentiArticle, error) {
+	var article models.SentiArticle
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		ArticleCollection := s.db.Collection("SentiArticles")
+		err := ArticleCollection.FindOne(ctx, bson.M{"_id": articleId}).Decode(&article)
 		if err != nil {
-			log.Println("Insert comment Error", err)
-			return isCompleted, err
+			return notFound(err)
 		}
-	} else {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		filter := bson.M{"_id": query.ArticleId}
-		update := bson.M{"$set": bson.M{"isAnswered": false}}
-		_, err := ArticleCollection.UpdateOne(ctx, filter, update)
+		err = s.refreshCompletion(ctx, article.ProjectId, articleId)
 		if err != nil {
-			log.Println("Insert comment Error", err)
-			return isCompleted, err
+			return err
 		}
+		return ArticleCollection.FindOne(ctx, bson.M{"_id": articleId}).Decode(&article)
+	})
+	if err != nil {
+		log.Println("Check article Error", err)
+		return nil, err
 	}
-	return isCompleted, nil
+	return &article, nil
 }
-func CheckIsValidated(db *mongo.Database, query models.SentiTask) (bool, error) {
-	TaskCollection := db.Collection("SentiTask")
-	ArticleCollection := db.Collection("SentiArticles")
-	var isCompleted = true
-	cur, err := TaskCollection.Find(context.Background(), bson.M{"articleId": query.ArticleId})
+
+func (s *MongoStore) DiscardSentiAnswer(query models.SentiTask, userId string, reason string) (bool, error) {
+	TaskCollection := s.db.Collection("SentiTask")
+	AspectCollection := s.db.Collection("SentiAspect")
+	SentimentCollection := s.db.Collection("SentiSentiment")
+	result := models.SentiTask{}
+	cur := TaskCollection.FindOne(context.Background(), bson.M{"_id": query.TaskId})
+	err := cur.Decode(&result)
 	if err != nil {
 		log.Println("Find tasks Error", err)
 		return false, err
 	}
-	for cur.Next(context.Background()) {
-		result := models.SentiTask{}
-		err := cur.Decode(&result)
+	// the task, its article and its annotations go back together
+	err = s.withTransaction(func(ctx mongo.SessionContext) error {
+		filter := bson.M{"_id": query.TaskId}
+		update := bson.M{"$set": bson.M{"isAnswered": false, "isValidate": false, "annotators": bson.A{}}}
+		_, err := TaskCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
-			log.Println("Decode tasks Error", err)
-			return false, err
+			return err
 		}
-		if result.IsValidate == false {
-			isCompleted = false
+		err = s.refreshCompletion(ctx, result.ProjectId, result.ArticleId)
+		if err != nil {
+			return err
 		}
 
+		//刪除掉被deny的錯誤標注內容
+		// they live on in the revisions before this one
+		filter = bson.M{"taskId": query.TaskId}
+		_, err = AspectCollection.DeleteMany(ctx, filter)
+		if err != nil {
+			log.Println("Delete Aspect Error", err)
+			return err
+		}
+		_, err = SentimentCollection.DeleteMany(ctx, filter)
+		if err != nil {
+			log.Println("Delete Sentiment Error", err)
+			return err
+		}
+		_, err = s.revise(ctx, models.Revision{Kind: models.RevisionSentiTask, TargetId: query.TaskId, Action: models.RevisionDiscarded, Author: userId, Reason: reason})
+		return err
+	})
+	if err != nil {
+		log.Println("Discard senti answer Error", err)
+		return false, err
 	}
-	log.Println("isCompleted : ", isCompleted)
-	if isCompleted == true {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	return true, nil
+}
 
-		filter := bson.M{"_id": query.ArticleId}
-		update := bson.M{"$set": bson.M{"isValidated": true}}
-		_, err := ArticleCollection.UpdateOne(ctx, filter, update)
+// ================================= gold =================================
+
+func (s *MongoStore) SaveLabel(label models.Label) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var saved models.Label
+		err := s.db.Collection(label.TableName()).FindOneAndReplace(ctx, label.ToQueryBson(), label, options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)).Decode(&saved)
 		if err != nil {
-			log.Println("Insert comment Error", err)
-			return isCompleted, err
+			return err
+		}
+		id = saved.Id
+		taskId, ok := label.SentiTaskId()
+		if !ok {
+			return nil
 		}
+		var task models.SentiTask
+		err = s.db.Collection("SentiTask").FindOneAndUpdate(ctx, bson.M{"_id": taskId, "projectId": label.ProjectId}, bson.M{"$set": bson.M{"gold": true}}).Decode(&task)
+		if err != nil {
+			return notFound(err)
+		}
+		return s.refreshCompletion(ctx, task.ProjectId, task.ArticleId)
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Save label Error", err)
 	}
-	return isCompleted, nil
+	return id, err
 }
 
-func DiscardSentiAnswer(db *mongo.Database, query models.SentiTask) (bool, error) {
-	TaskCollection := db.Collection("SentiTask")
-	ArticleCollection := db.Collection("SentiArticles")
-	AspectCollection := db.Collection("SentiAspect")
-	SentimentCollection := db.Collection("SentiSentiment")
-	result := models.SentiTask{}
-	cur := TaskCollection.FindOne(context.Background(), bson.M{"_id": query.TaskId})
-	err := cur.Decode(&result)
+func (s *MongoStore) DeleteLabel(projectId primitive.ObjectID, labelId primitive.ObjectID) error {
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var label models.Label
+		err := s.db.Collection(label.TableName()).FindOneAndDelete(ctx, bson.M{"_id": labelId, "projectId": projectId}).Decode(&label)
+		if err != nil {
+			return notFound(err)
+		}
+		taskId, ok := label.SentiTaskId()
+		if !ok {
+			return nil
+		}
+		TaskCollection := s.db.Collection("SentiTask")
+		var task models.SentiTask
+		err = TaskCollection.FindOneAndUpdate(ctx, bson.M{"_id": taskId}, bson.M{"$unset": bson.M{"gold": ""}}).Decode(&task)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// the gold answers count as annotations, which may be all the
+		// task needs
+		var project models.Project
+		err = s.db.Collection(project.TableName()).FindOne(ctx, bson.M{"_id": task.ProjectId}).Decode(&project)
+		if err != nil {
+			return notFound(err)
+		}
+		filter := bson.M{"_id": taskId, "isValidate": false, "annotators": bson.M{"$exists": true}}
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"isAnswered": bson.M{"$gte": bson.A{bson.M{"$size": "$annotators"}, project.RequiredAnnotators()}}}}},
+		}
+		_, err = TaskCollection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		return s.refreshCompletion(ctx, task.ProjectId, task.ArticleId)
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Delete label Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) GetLabels(projectId primitive.ObjectID) ([]models.Label, error) {
+	LabelCollection := s.db.Collection("Label")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var labels = []models.Label{}
+	cur, err := LabelCollection.Find(ctx, bson.M{"projectId": projectId}, options.Find().SetSort(bson.M{"_id": 1}))
 	if err != nil {
-		log.Println("Find tasks Error", err)
-		return false, err
+		log.Println("Find labels Error", err)
+		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err = cur.All(ctx, &labels)
+	if err != nil {
+		log.Println("Decode labels Error", err)
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (s *MongoStore) SaveGoldResult(result models.GoldResult) error {
+	ResultCollection := s.db.Collection(result.TableName())
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
+	_, err := ResultCollection.InsertOne(ctx, result)
+	if isDuplicateKey(err) {
+		return ErrAnnotated
+	}
+	if err != nil {
+		log.Println("Insert gold result Error", err)
+	}
+	return err
+}
 
-	filter := bson.M{"_id": query.TaskId}
-	update := bson.M{"$set": bson.M{"isAnswered": false}}
-	_, err = TaskCollection.UpdateOne(ctx, filter, update)
+// recordGoldResult saves result within a transaction, and pauses the user
+// once their results fall below the MinAccuracy of project.
+func (s *MongoStore) recordGoldResult(ctx mongo.SessionContext, project models.Project, result models.GoldResult) error {
+	ResultCollection := s.db.Collection(result.TableName())
+	// looked up first, a duplicate key would abort the transaction
+	count, err := ResultCollection.CountDocuments(ctx, bson.M{"labelId": result.LabelId, "userId": result.UserId})
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return ErrAnnotated
+	}
+	_, err = ResultCollection.InsertOne(ctx, result)
+	if isDuplicateKey(err) {
+		return ErrAnnotated
+	}
+	if err != nil || project.Gold.MinAccuracy <= 0 {
+		return err
+	}
+	var results []models.GoldResult
+	cur, err := ResultCollection.Find(ctx, bson.M{"projectId": project.ProjectId, "userId": result.UserId}, options.Find().SetSort(bson.M{"answeredAt": 1}))
+	if err != nil {
+		return err
+	}
+	err = cur.All(ctx, &results)
+	if err != nil || !belowMinAccuracy(project, results) {
+		return err
+	}
+	// users answering without an annotator role have nothing to pause
+	filter := bson.M{"projectId": project.ProjectId, "userId": result.UserId, "role": models.RoleAnnotator}
+	_, err = s.db.Collection("Authentication").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"paused": true}})
+	return err
+}
 
+func (s *MongoStore) GetGoldResults(projectId primitive.ObjectID, userId string) ([]models.GoldResult, error) {
+	ResultCollection := s.db.Collection("GoldResult")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var results = []models.GoldResult{}
+	filter := bson.M{"projectId": projectId}
+	if userId != "" {
+		filter["userId"] = userId
+	}
+	cur, err := ResultCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"answeredAt": 1}))
 	if err != nil {
-		log.Println("Insert comment Error", err)
-		return false, err
+		log.Println("Find gold results Error", err)
+		return nil, err
 	}
+	err = cur.All(ctx, &results)
+	if err != nil {
+		log.Println("Decode gold results Error", err)
+		return nil, err
+	}
+	return results, nil
+}
 
-	filter = bson.M{"_id": result.ArticleId}
-	update = bson.M{"$set": bson.M{"isAnswered": false}}
-	_, err = ArticleCollection.UpdateOne(ctx, filter, update)
+// ================================= revisions =================================
+
+// revise records a new revision of the target of revision, as the writes
+// before it in the same transaction left it. Kind, TargetId, Action and
+// Author come from revision, the rest is filled in. Writing the target in
+// the transaction first keeps the versions in order: a concurrent change
+// conflicts with it and is retried.
+func (s *MongoStore) revise(ctx mongo.SessionContext, revision models.Revision) (*models.Revision, error) {
+	switch revision.Kind {
+	case models.RevisionSentiTask:
+		var task models.SentiTask
+		err := s.db.Collection("SentiTask").FindOne(ctx, bson.M{"_id": revision.TargetId}).Decode(&task)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		version := models.SentiTaskVersion{
+			Annotators: append([]string{}, task.Annotators...),
+			IsAnswered: task.IsAnswered,
+			IsValidate: task.IsValidate,
+			Aspects:    []models.SentiAspect{},
+			Sentiments: []models.SentiSentiment{},
+		}
+		cur, err := s.db.Collection("SentiAspect").Find(ctx, bson.M{"taskId": task.TaskId})
+		if err == nil {
+			err = cur.All(ctx, &version.Aspects)
+		}
+		if err != nil {
+			return nil, err
+		}
+		cur, err = s.db.Collection("SentiSentiment").Find(ctx, bson.M{"taskId": task.TaskId})
+		if err == nil {
+			err = cur.All(ctx, &version.Sentiments)
+		}
+		if err != nil {
+			return nil, err
+		}
+		revision.ProjectId = task.ProjectId
+		revision.SentiTask = &version
+	case models.RevisionMRCAnswer:
+		var answer models.MRCAnswer
+		err := s.db.Collection("MRCAnswer").FindOne(ctx, bson.M{"_id": revision.TargetId}).Decode(&answer)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		revision.ProjectId = answer.ProjectId
+		if revision.ProjectId.IsZero() {
+			// answers saved before projectId existed only know their article
+			articleId, _ := primitive.ObjectIDFromHex(answer.ArticleId)
+			var article models.Article
+			err = s.db.Collection("Articles").FindOne(ctx, bson.M{"_id": articleId}).Decode(&article)
+			if err != nil {
+				return nil, notFound(err)
+			}
+			revision.ProjectId = article.ProjectId
+		}
+		revision.MRCAnswer = &answer
+	default:
+		return nil, fmt.Errorf("cannot keep revisions of %q", revision.Kind)
+	}
+
+	RevisionCollection := s.db.Collection(revision.TableName())
+	count, err := RevisionCollection.CountDocuments(ctx, bson.M{"targetId": revision.TargetId})
 	if err != nil {
-		log.Println("Insert comment Error", err)
-		return false, err
+		return nil, err
 	}
+	revision.Id = primitive.NilObjectID
+	revision.Version = int(count) + 1
+	revision.CreatedAt = time.Now()
+	res, err := RevisionCollection.InsertOne(ctx, revision)
+	if err != nil {
+		return nil, err
+	}
+	revision.Id = insertedId(res)
+	return &revision, nil
+}
 
-	//刪除掉被deny的錯誤標注內容
-	filter = bson.M{"taskId": query.TaskId}
-	_, err = AspectCollection.DeleteMany(ctx, filter)
+func (s *MongoStore) GetRevisions(projectId primitive.ObjectID, targetId primitive.ObjectID) ([]models.Revision, error) {
+	RevisionCollection := s.db.Collection("Revision")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	filter := bson.M{"projectId": projectId}
+	if !targetId.IsZero() {
+		filter["targetId"] = targetId
+	}
+	var revisions = []models.Revision{}
+	cur, err := RevisionCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "version", Value: 1}}))
+	if err != nil {
+		log.Println("Find revisions Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &revisions)
+	if err != nil {
+		log.Println("Decode revisions Error", err)
+		return nil, err
+	}
+	return revisions, nil
+}
 
+func (s *MongoStore) GetRevision(projectId primitive.ObjectID, id primitive.ObjectID) (*models.Revision, error) {
+	RevisionCollection := s.db.Collection("Revision")
+	var revision models.Revision
+	err := RevisionCollection.FindOne(context.Background(), bson.M{"_id": id, "projectId": projectId}).Decode(&revision)
 	if err != nil {
-		log.Println("Delete Aspect Error", err)
-		return false, err
+		return nil, notFound(err)
+	}
+	return &revision, nil
+}
+
+func (s *MongoStore) RestoreRevision(projectId primitive.ObjectID, id primitive.ObjectID, userId string, reason string) (*models.Revision, error) {
+	var restored *models.Revision
+	err := s.withTransaction(func(ctx mongo.SessionContext) error {
+		var revision models.Revision
+		err := s.db.Collection(revision.TableName()).FindOne(ctx, bson.M{"_id": id, "projectId": projectId}).Decode(&revision)
+		if err != nil {
+			return notFound(err)
+		}
+		switch {
+		case revision.SentiTask != nil:
+			err = s.restoreSentiTask(ctx, revision.TargetId, revision.Version, *revision.SentiTask)
+		case revision.MRCAnswer != nil:
+			err = s.restoreMRCAnswer(ctx, revision.TargetId, *revision.MRCAnswer)
+		default:
+			err = fmt.Errorf("revision %s holds no annotation", id.Hex())
+		}
+		if err != nil {
+			return err
+		}
+		restored, err = s.revise(ctx, models.Revision{
+			Kind:         revision.Kind,
+			TargetId:     revision.TargetId,
+			Action:       models.RevisionRestored,
+			Author:       userId,
+			Reason:       reason,
+			RestoredFrom: revision.Version,
+		})
+		return err
+	})
+	if err != nil && err != ErrNotFound {
+		log.Println("Restore revision Error", err)
 	}
+	return restored, err
+}
 
-	filter = bson.M{"taskId": query.TaskId}
-	_, err = SentimentCollection.DeleteMany(ctx, filter)
+// restoreSentiTask must run inside withTransaction. The validation of a
+// task was made against its annotations, so a task validated since number,
+// the version restored, or validated then and not now or the other way
+// round, is not restored.
+func (s *MongoStore) restoreSentiTask(ctx mongo.SessionContext, taskId primitive.ObjectID, number int, version models.SentiTaskVersion) error {
+	TaskCollection := s.db.Collection("SentiTask")
+	var task models.SentiTask
+	err := TaskCollection.FindOne(ctx, bson.M{"_id": taskId}).Decode(&task)
+	if err != nil {
+		return notFound(err)
+	}
+	if task.IsValidate != version.IsValidate {
+		return ErrReviewed
+	}
+	validated, err := s.db.Collection("Revision").CountDocuments(ctx, bson.M{"targetId": taskId, "action": models.RevisionValidated, "version": bson.M{"$gt": number}})
+	if err != nil {
+		return err
+	}
+	if validated > 0 {
+		return ErrReviewed
+	}
+	annotators := version.Annotators
+	if annotators == nil {
+		annotators = []string{}
+	}
+	update := bson.M{"$set": bson.M{"annotators": annotators, "isAnswered": version.IsAnswered}}
+	_, err = TaskCollection.UpdateOne(ctx, bson.M{"_id": taskId}, update)
+	if err != nil {
+		return err
+	}
+	aspects := make([]interface{}, len(version.Aspects))
+	for i := range version.Aspects {
+		aspects[i] = version.Aspects[i]
+	}
+	sentiments := make([]interface{}, len(version.Sentiments))
+	for i := range version.Sentiments {
+		sentiments[i] = version.Sentiments[i]
+	}
+	for _, batch := range []struct {
+		collection string
+		docs       []interface{}
+	}{
+		{"SentiAspect", aspects},
+		{"SentiSentiment", sentiments},
+	} {
+		// the annotations being replaced live on in earlier revisions
+		_, err = s.db.Collection(batch.collection).DeleteMany(ctx, bson.M{"taskId": taskId})
+		if err != nil {
+			return err
+		}
+		err = s.insertMany(ctx, batch.collection, batch.docs)
+		if err != nil {
+			return err
+		}
+	}
+	return s.refreshCompletion(ctx, task.ProjectId, task.ArticleId)
+}
 
+// restoreMRCAnswer must run inside withTransaction. The status of an
+// answer follows its validations and decisions, so a decided answer, or
+// one whose status changed since the revision, is not restored.
+func (s *MongoStore) restoreMRCAnswer(ctx mongo.SessionContext, answerId primitive.ObjectID, answer models.MRCAnswer) error {
+	decisions, err := s.db.Collection("MRCDecision").CountDocuments(ctx, bson.M{"originalId": answerId})
 	if err != nil {
-		log.Println("Delete Sentiment Error", err)
-		return false, err
+		return err
 	}
-	// log.Println("isCompleted : ", isCompleted)
+	if decisions > 0 {
+		return ErrDecided
+	}
+	filter := bson.M{"_id": answerId}
+	var current models.MRCAnswer
+	err = s.db.Collection("MRCAnswer").FindOne(ctx, filter).Decode(&current)
+	if err != nil {
+		return notFound(err)
+	}
+	if current.Status != answer.Status {
+		return ErrReviewed
+	}
+	update := bson.M{"$set": bson.M{
+		"question":     answer.Question,
+		"answer":       answer.Answer,
+		"startIdx":     answer.StartIdx,
+		"isImpossible": answer.IsImpossible,
+	}}
+	_, err = s.db.Collection("MRCAnswer").UpdateOne(ctx, filter, update)
+	return err
+}
 
-	return true, nil
+// ================================= completion =================================
+
+// completedAt is the aggregation expression of the completion time of a
+// document: kept once it is set, now when it just completed, removed while
+// it is not complete.
+func completedAt(done bool, field string, now time.Time) interface{} {
+	if !done {
+		return "$$REMOVE"
+	}
+	return bson.M{"$ifNull": bson.A{"$" + field, now}}
+}
+
+// refreshCompletion derives whether articles of a project are answered and
+// validated from their tasks, then whether the whole project is. Without
+// articleIds every article of the project is refreshed. It must run inside
+// withTransaction, with the task changes it follows.
+func (s *MongoStore) refreshCompletion(ctx context.Context, projectId primitive.ObjectID, articleIds ...primitive.ObjectID) error {
+	now := time.Now()
+	filter := bson.M{"projectId": projectId}
+	if len(articleIds) != 0 {
+		filter["_id"] = bson.M{"$in": articleIds}
+	}
+	err := s.refreshSentiArticles(ctx, filter, now)
+	if err != nil {
+		return err
+	}
+	var project models.Project
+	err = s.db.Collection("Project").FindOne(ctx, bson.M{"_id": projectId}).Decode(&project)
+	if err != nil {
+		return notFound(err)
+	}
+	err = s.refreshMRCArticles(ctx, filter, project.RequiredAnnotators(), now)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int64)
+	for name, count := range map[string]struct {
+		collection string
+		filter     bson.M
+	}{
+		"articles":    {"Articles", bson.M{"projectId": projectId}},
+		"unanswered":  {"Articles", bson.M{"projectId": projectId, "isAnswered": bson.M{"$ne": true}}},
+		"senti":       {"SentiArticles", bson.M{"projectId": projectId}},
+		"sentiOpen":   {"SentiArticles", bson.M{"projectId": projectId, "isAnswered": bson.M{"$ne": true}}},
+		"unvalidated": {"SentiArticles", bson.M{"projectId": projectId, "isValidated": bson.M{"$ne": true}}},
+	} {
+		counts[name], err = s.db.Collection(count.collection).CountDocuments(ctx, count.filter)
+		if err != nil {
+			return err
+		}
+	}
+	answered := counts["articles"]+counts["senti"] > 0 && counts["unanswered"]+counts["sentiOpen"] == 0
+	validated := counts["senti"] > 0 && counts["unvalidated"] == 0
+	_, err = s.db.Collection("Project").UpdateOne(ctx, bson.M{"_id": projectId}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"answeredAt":  completedAt(answered, "answeredAt", now),
+			"validatedAt": completedAt(validated, "validatedAt", now),
+		}}},
+	})
+	return err
+}
+
+// refreshSentiArticles flags the sentiment articles matching filter by
+// their tasks, gold ones left out. Articles without such tasks are complete.
+func (s *MongoStore) refreshSentiArticles(ctx context.Context, filter bson.M, now time.Time) error {
+	// whether every task is answered and validated, and whether that is
+	// what the article says, in the aggregation: only the articles whose
+	// flags change come back
+	every := func(field string) bson.M {
+		return bson.M{"$allElementsTrue": bson.A{bson.M{"$map": bson.M{
+			"input": "$tasks",
+			"in":    bson.M{"$eq": bson.A{"$$this." + field, true}},
+		}}}}
+	}
+	stale := func(done, flag, at string) bson.M {
+		return bson.M{"$or": bson.A{
+			bson.M{"$ne": bson.A{done, bson.M{"$eq": bson.A{flag, true}}}},
+			bson.M{"$ne": bson.A{done, bson.M{"$ne": bson.A{bson.M{"$type": at}, "missing"}}}},
+		}}
+	}
+	var changes []struct {
+		ArticleId primitive.ObjectID `bson:"_id"`
+		Answered  bool               `bson:"answered"`
+		Validated bool               `bson:"validated"`
+	}
+	err := s.aggregate(ctx, "SentiArticles", mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{"from": "SentiTask", "localField": "_id", "foreignField": "articleId", "as": "tasks"}}},
+		{{Key: "$set", Value: bson.M{"tasks": bson.M{"$filter": bson.M{"input": "$tasks", "cond": bson.M{"$ne": bson.A{"$$this.gold", true}}}}}}},
+		{{Key: "$project", Value: bson.M{
+			"answered":    every("isAnswered"),
+			"validated":   every("isValidate"),
+			"isAnswered":  1,
+			"answeredAt":  1,
+			"isValidated": 1,
+			"validatedAt": 1,
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$or": bson.A{
+			stale("$answered", "$isAnswered", "$answeredAt"),
+			stale("$validated", "$isValidated", "$validatedAt"),
+		}}}}},
+		{{Key: "$project", Value: bson.M{"answered": 1, "validated": 1}}},
+	}, &changes)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+	ArticleCollection := s.db.Collection("SentiArticles")
+	for _, flag := range []struct {
+		name, at string
+		done     func(i int) bool
+	}{
+		{"isAnswered", "answeredAt", func(i int) bool { return changes[i].Answered }},
+		{"isValidated", "validatedAt", func(i int) bool { return changes[i].Validated }},
+	} {
+		ids := map[bool][]primitive.ObjectID{}
+		for i, change := range changes {
+			ids[flag.done(i)] = append(ids[flag.done(i)], change.ArticleId)
+		}
+		for done, articleIds := range ids {
+			_, err = ArticleCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": articleIds}}, mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{flag.name: done, flag.at: completedAt(done, flag.at, now)}}},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshMRCArticles counts the tasks of the MRC articles matching filter
+// that have required annotators. Articles are answered when all of them do.
+func (s *MongoStore) refreshMRCArticles(ctx context.Context, filter bson.M, required int, now time.Time) error {
+	var articles []models.Article
+	cur, err := s.db.Collection("Articles").Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	err = cur.All(ctx, &articles)
+	if err != nil || len(articles) == 0 {
+		return err
+	}
+	// MRC tasks keep their article id as a hex string
+	articleHexes := make([]string, len(articles))
+	for i, article := range articles {
+		articleHexes[i] = article.ArticleId.Hex()
+	}
+	var groups []struct {
+		ArticleId string `bson:"_id"`
+		Tasks     int    `bson:"tasks"`
+		Answered  int    `bson:"answered"`
+	}
+	err = s.aggregate(ctx, "MRCTask", mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"articleId": bson.M{"$in": articleHexes}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$articleId",
+			"tasks":    bson.M{"$sum": 1},
+			"answered": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$answered", required}}, 1, 0}}},
+		}}},
+	}, &groups)
+	if err != nil {
+		return err
+	}
+	tasks, answered := make(map[string]int), make(map[string]int)
+	for _, g := range groups {
+		tasks[g.ArticleId], answered[g.ArticleId] = g.Tasks, g.Answered
+	}
+	for _, article := range articles {
+		hex := article.ArticleId.Hex()
+		done := answered[hex] == tasks[hex]
+		_, err = s.db.Collection("Articles").UpdateOne(ctx, bson.M{"_id": article.ArticleId}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"answered": answered[hex], "isAnswered": done, "answeredAt": completedAt(done, "answeredAt", now)}}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ================================= progress =================================
+
+// progressGroup counts the items of an article in a state.
+type progressGroup struct {
+	Id struct {
+		ArticleId interface{} `bson:"articleId"`
+		State     string      `bson:"state"`
+	} `bson:"_id"`
+	Count int `bson:"count"`
+}
+
+// dayGroup counts the work done on a day.
+type dayGroup struct {
+	Date  string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// byState groups the items of a pipeline by article and state.
+func byState(state interface{}) []bson.D {
+	return []bson.D{
+		{{Key: "$project", Value: bson.M{"articleId": 1, "state": state}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"articleId": "$articleId", "state": "$state"}, "count": bson.M{"$sum": 1}}}},
+	}
+}
+
+// byDay groups the items of a pipeline by the UTC day of date.
+func byDay(date interface{}) bson.D {
+	return bson.D{{Key: "$group", Value: bson.M{
+		"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": date}},
+		"count": bson.M{"$sum": 1},
+	}}}
+}
+
+// stateSwitch is the state of the first case that holds, else otherwise.
+func stateSwitch(otherwise string, cases ...bson.M) bson.M {
+	return bson.M{"$switch": bson.M{"branches": cases, "default": otherwise}}
+}
+
+func (s *MongoStore) aggregate(ctx context.Context, collection string, pipeline mongo.Pipeline, results interface{}) error {
+	cur, err := s.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cur.All(ctx, results)
+}
+
+func (s *MongoStore) GetProgress(project models.Project) (*models.Progress, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	b := newProgressBuilder(project)
+	var err error
+	switch project.ProjectType {
+	case "Sentiment":
+		err = s.sentiProgress(ctx, project, b)
+	case "MRC":
+		err = s.mrcProgress(ctx, project, b)
+	default:
+		err = fmt.Errorf("cannot report the progress of %q projects", project.ProjectType)
+	}
+	if err != nil {
+		log.Println("Aggregate progress Error", err)
+		return nil, err
+	}
+	return b.build(), nil
+}
+
+func (s *MongoStore) sentiProgress(ctx context.Context, project models.Project, b *progressBuilder) error {
+	var articles []models.SentiArticle
+	cur, err := s.db.Collection("SentiArticles").Find(ctx, bson.M{"projectId": project.ProjectId})
+	if err != nil {
+		return err
+	}
+	err = cur.All(ctx, &articles)
+	if err != nil {
+		return err
+	}
+	for _, article := range articles {
+		b.article(article.ArticleId.Hex(), article.ArticleTitle)
+	}
+
+	taskFilter := bson.M{"projectId": project.ProjectId, "gold": bson.M{"$ne": true}}
+	var groups []progressGroup
+	err = s.aggregate(ctx, "SentiTask", append(mongo.Pipeline{
+		{{Key: "$match", Value: taskFilter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "Claim",
+			"let":  bson.M{"taskId": "$_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$itemId", "$$taskId"}},
+				bson.M{"$eq": bson.A{"$kind", models.ClaimSentiValidation}},
+				bson.M{"$gt": bson.A{"$expiresAt", time.Now()}},
+			}}}}},
+			"as": "claims",
+		}}},
+	}, byState(stateSwitch(models.ProgressUnassigned,
+		bson.M{"case": "$isValidate", "then": models.ProgressValidated},
+		bson.M{"case": bson.M{"$gt": bson.A{bson.M{"$size": "$claims"}, 0}}, "then": models.ProgressClaimed},
+		bson.M{"case": "$isAnswered", "then": models.ProgressAnswered},
+	))...), &groups)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		b.count(hexOf(g.Id.ArticleId), g.Id.State, g.Count)
+	}
+
+	// an annotation is every aspect a user saved on a task, answered when
+	// the first one was; aspects saved before answeredAt have their _id
+	var days []dayGroup
+	err = s.aggregate(ctx, "SentiTask", mongo.Pipeline{
+		{{Key: "$match", Value: taskFilter}},
+		{{Key: "$lookup", Value: bson.M{"from": "SentiAspect", "localField": "_id", "foreignField": "taskId", "as": "aspects"}}},
+		{{Key: "$unwind", Value: "$aspects"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"taskId": "$_id", "userId": "$aspects.userId"},
+			"at":  bson.M{"$min": bson.M{"$ifNull": bson.A{"$aspects.answeredAt", bson.M{"$toDate": "$aspects._id"}}}},
+		}}},
+		byDay("$at"),
+	}, &days)
+	if err != nil {
+		return err
+	}
+	for _, d := range days {
+		b.day(d.Date).Answered += d.Count
+	}
+	days = nil
+	// validations of tasks that are gold, or gone, are left out
+	err = s.aggregate(ctx, "SentiFinalAnswer", mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"projectId": project.ProjectId}}},
+		{{Key: "$lookup", Value: bson.M{"from": "SentiTask", "localField": "task._id", "foreignField": "_id", "as": "stored"}}},
+		{{Key: "$match", Value: bson.M{"stored.0": bson.M{"$exists": true}, "stored.gold": bson.M{"$ne": true}}}},
+		byDay(bson.M{"$ifNull": bson.A{"$validatedAt", bson.M{"$toDate": "$_id"}}}),
+	}, &days)
+	if err != nil {
+		return err
+	}
+	for _, d := range days {
+		b.day(d.Date).Validated += d.Count
+	}
+	return nil
+}
+
+func (s *MongoStore) mrcProgress(ctx context.Context, project models.Project, b *progressBuilder) error {
+	var articles []models.Article
+	cur, err := s.db.Collection("Articles").Find(ctx, bson.M{"projectId": project.ProjectId})
+	if err != nil {
+		return err
+	}
+	err = cur.All(ctx, &articles)
+	if err != nil {
+		return err
+	}
+	// MRC tasks and answers keep their article id as a hex string
+	articleHexes := make([]string, len(articles))
+	for i, article := range articles {
+		articleHexes[i] = article.ArticleId.Hex()
+		b.article(articleHexes[i], article.ArticleTitle)
+	}
+
+	var groups []progressGroup
+	err = s.aggregate(ctx, "MRCTask", append(mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"articleId": bson.M{"$in": articleHexes}, "answered": bson.M{"$not": bson.M{"$gte": project.RequiredAnnotators()}}}}},
+	}, byState(bson.M{"$literal": models.ProgressUnassigned})...), &groups)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		b.count(hexOf(g.Id.ArticleId), g.Id.State, g.Count)
+	}
+
+	// one pass over the answers for their states and for the days they
+	// were answered, validated and decided on
+	var facets []struct {
+		States    []progressGroup `bson:"states"`
+		Answered  []dayGroup      `bson:"answered"`
+		Validated []dayGroup      `bson:"validated"`
+		Decided   []dayGroup      `bson:"decided"`
+	}
+	states := bson.A{
+		bson.M{"$lookup": bson.M{
+			"from": "Claim",
+			"let":  bson.M{"answerId": "$_id", "validationIds": "$validations._id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$gt": bson.A{"$expiresAt", time.Now()}},
+				bson.M{"$or": bson.A{
+					bson.M{"$and": bson.A{bson.M{"$eq": bson.A{"$kind", models.ClaimMRCValidation}}, bson.M{"$eq": bson.A{"$itemId", "$$answerId"}}}},
+					bson.M{"$and": bson.A{bson.M{"$eq": bson.A{"$kind", models.ClaimMRCDecision}}, bson.M{"$in": bson.A{"$itemId", "$$validationIds"}}}},
+				}},
+			}}}}},
+			"as": "claims",
+		}},
+	}
+	// any status but these was set by an adjudicator
+	for _, stage := range byState(stateSwitch(models.ProgressFinal,
+		bson.M{"case": bson.M{"$gt": bson.A{bson.M{"$size": "$decisions"}, 0}}, "then": models.ProgressFinal},
+		bson.M{"case": bson.M{"$gt": bson.A{bson.M{"$size": "$claims"}, 0}}, "then": models.ProgressClaimed},
+		bson.M{"case": bson.M{"$eq": bson.A{"$status", "unverified"}}, "then": models.ProgressAnswered},
+		bson.M{"case": bson.M{"$eq": bson.A{"$status", "verified"}}, "then": models.ProgressValidated},
+		bson.M{"case": bson.M{"$eq": bson.A{"$status", "pending"}}, "then": models.ProgressPendingDecision},
+	)) {
+		states = append(states, stage)
+	}
+	err = s.aggregate(ctx, "MRCAnswer", mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"articleId": bson.M{"$in": articleHexes}, "taskType": "MRC", "status": bson.M{"$ne": models.AnswerStatusGold}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "MRCValidation", "localField": "_id", "foreignField": "originalId", "as": "validations"}}},
+		{{Key: "$lookup", Value: bson.M{"from": "MRCDecision", "localField": "_id", "foreignField": "originalId", "as": "decisions"}}},
+		{{Key: "$facet", Value: bson.M{
+			"states":    states,
+			"answered":  bson.A{byDay(bson.M{"$toDate": "$_id"})},
+			"validated": bson.A{bson.M{"$unwind": "$validations"}, byDay(bson.M{"$toDate": "$validations._id"})},
+			"decided":   bson.A{bson.M{"$unwind": "$decisions"}, byDay(bson.M{"$toDate": "$decisions._id"})},
+		}}},
+	}, &facets)
+	if err != nil {
+		return err
+	}
+	if len(facets) == 0 {
+		return nil
+	}
+	for _, g := range facets[0].States {
+		b.count(hexOf(g.Id.ArticleId), g.Id.State, g.Count)
+	}
+	for _, d := range facets[0].Answered {
+		b.day(d.Date).Answered += d.Count
+	}
+	for _, d := range facets[0].Validated {
+		b.day(d.Date).Validated += d.Count
+	}
+	for _, d := range facets[0].Decided {
+		b.day(d.Date).Decided += d.Count
+	}
+	return nil
+}
+
+// ================================= claims =================================
+
+// claimRetention keeps expired claims for a while before MongoDB purges
+// them, so a late heartbeat can still renew a claim nobody took over.
+const claimRetention = int32(time.Hour / time.Second)
+
+// EnsureIndexes creates the indexes the store relies on. The unique index
+// on the kind and item of a claim is what makes TakeClaim atomic, the one
+// on the label and user of a gold result keeps the first answer only, the
+// one on the target and version of a revision numbers versions once.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("GoldResult").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "labelId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "userId", Value: 1}, {Key: "answeredAt", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Collection("Revision").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "targetId", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "createdAt", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Collection("Claim").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "itemId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(claimRetention),
+		},
+		{
+			Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "kind", Value: 1}, {Key: "userId", Value: 1}},
+		},
+	})
+	return err
+}
+
+func (s *MongoStore) TakeClaim(claim models.Claim) error {
+	ClaimCollection := s.db.Collection(claim.TableName())
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	// the claim is ours if we hold it or it has expired; when another user
+	// holds it live, the upsert inserts a second claim on the item and the
+	// unique index refuses it
+	filter := bson.M{
+		"kind":   claim.Kind,
+		"itemId": claim.ItemId,
+		"$or":    bson.A{bson.M{"userId": claim.UserId}, bson.M{"expiresAt": bson.M{"$lte": claim.ClaimedAt}}},
+	}
+	update := bson.M{"$set": bson.M{
+		"projectId": claim.ProjectId,
+		"userId":    claim.UserId,
+		"claimedAt": claim.ClaimedAt,
+		"expiresAt": claim.ExpiresAt,
+	}}
+	_, err := ClaimCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if isDuplicateKey(err) {
+		return ErrClaimed
+	}
+	if err != nil {
+		log.Println("Take claim Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) RenewClaim(kind string, itemId primitive.ObjectID, userId string, expiresAt time.Time) (*models.Claim, error) {
+	ClaimCollection := s.db.Collection("Claim")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var claim models.Claim
+	filter := bson.M{"kind": kind, "itemId": itemId, "userId": userId}
+	update := bson.M{"$set": bson.M{"expiresAt": expiresAt}}
+	res := ClaimCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	err := res.Decode(&claim)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &claim, nil
+}
+
+func (s *MongoStore) ReleaseClaim(kind string, itemId primitive.ObjectID, userId string) error {
+	ClaimCollection := s.db.Collection("Claim")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := ClaimCollection.DeleteOne(ctx, bson.M{"kind": kind, "itemId": itemId, "userId": userId})
+	if err != nil {
+		log.Println("Release claim Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) ReleaseOtherClaims(projectId primitive.ObjectID, kind string, userId string, keep primitive.ObjectID) error {
+	ClaimCollection := s.db.Collection("Claim")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	filter := bson.M{"projectId": projectId, "kind": kind, "userId": userId, "itemId": bson.M{"$ne": keep}}
+	_, err := ClaimCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		log.Println("Release claims Error", err)
+	}
+	return err
+}
+
+func (s *MongoStore) GetClaim(kind string, itemId primitive.ObjectID) (*models.Claim, error) {
+	ClaimCollection := s.db.Collection("Claim")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var claim models.Claim
+	err := ClaimCollection.FindOne(ctx, bson.M{"kind": kind, "itemId": itemId}).Decode(&claim)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &claim, nil
+}
+
+func (s *MongoStore) GetLiveClaims(projectId primitive.ObjectID, kind string) ([]models.Claim, error) {
+	ClaimCollection := s.db.Collection("Claim")
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var claims = []models.Claim{}
+	filter := bson.M{"projectId": projectId, "expiresAt": bson.M{"$gt": time.Now()}}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	cur, err := ClaimCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"claimedAt": 1}))
+	if err != nil {
+		log.Println("Find claims Error", err)
+		return nil, err
+	}
+	err = cur.All(ctx, &claims)
+	if err != nil {
+		log.Println("Decode claims Error", err)
+		return nil, err
+	}
+	return claims, nil
 }
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Lynx/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotFound is returned by every store when the requested document does not exist.
+var ErrNotFound = errors.New("document not found")
+
+// ErrClaimed is returned when another user holds a live claim on an item.
+var ErrClaimed = errors.New("another user is working on this item")
+
+// ErrAnnotated is returned when a user answers a sentiment task twice.
+var ErrAnnotated = errors.New("you already annotated this task")
+
+// ErrTaskAnswered is returned when a sentiment task already has the
+// annotators its project asks for.
+var ErrTaskAnswered = errors.New("the task already has all the annotations it needs")
+
+// ErrOwnWork is returned when a user reviews, validates or decides on,
+// their own annotation.
+var ErrOwnWork = errors.New("you cannot review your own annotation")
+
+// ErrDecided is returned when an adjudicator decides a validation that is
+// no longer pending a decision, or a validator an answer that is no longer
+// unverified.
+var ErrDecided = errors.New("this was already validated or decided")
+
+// ErrReviewed is returned when restoring an MRC answer or sentiment task
+// would undo the validation or decision made since the revision.
+var ErrReviewed = errors.New("the answer was reviewed since this revision, a restore cannot undo the review")
+
+// UserStore reads and writes the GUser collection.
+type UserStore interface {
+	GetUser(user models.User) (*models.User, error)
+	GetUsers() ([]models.User, error)
+	GetUsersByIds(userIds []string) ([]models.User, error)
+	SaveUser(user models.User) (primitive.ObjectID, error)
+}
+
+// AuthStore reads and writes the Authentication collection.
+type AuthStore interface {
+	GetAuthByProjectId(auth models.Auth) ([]models.Auth, error)
+	GetUserProjectAuths(userId string, projectId primitive.ObjectID) ([]models.Auth, error)
+	GetAuthsByUserId(userId string) (models.Auths, error)
+	SaveAuth(auth models.Auth) (primitive.ObjectID, error)
+	SaveAuths(auths []models.Auth) error
+	// SetAnnotatorPaused pauses or resumes the annotator roles of a user in
+	// a project. ErrNotFound when the user is no annotator there.
+	SetAnnotatorPaused(projectId primitive.ObjectID, userId string, paused bool) error
+}
+
+// ProjectContent is everything created together with a project.
+type ProjectContent struct {
+	Auths         []models.Auth
+	Articles      []models.Article
+	MRCTasks      []models.MRCTask
+	MRCAnswers    []models.MRCAnswer
+	SentiArticles []models.SentiArticle
+	SentiTasks    []models.SentiTask
+	// SentiAspects and SentiSentiments are answers preloaded from a dataset.
+	SentiAspects    []models.SentiAspect
+	SentiSentiments []models.SentiSentiment
+}
+
+// articleIds lists the articles of the content by project.
+func (c ProjectContent) articleIds() map[primitive.ObjectID][]primitive.ObjectID {
+	ids := make(map[primitive.ObjectID][]primitive.ObjectID)
+	for _, a := range c.Articles {
+		ids[a.ProjectId] = append(ids[a.ProjectId], a.ArticleId)
+	}
+	for _, a := range c.SentiArticles {
+		ids[a.ProjectId] = append(ids[a.ProjectId], a.ArticleId)
+	}
+	return ids
+}
+
+// ProjectStore reads and writes the Project collection.
+type ProjectStore interface {
+	GetProjectByProjectId(project models.Project) (*models.Project, error)
+	GetProjectsByIds(projectIds []primitive.ObjectID) ([]models.Project, error)
+	GetProjectCount() (int64, error)
+	SaveProject(project models.Project) (primitive.ObjectID, error)
+	// CreateProject writes the project and its content all together or not at all.
+	CreateProject(project models.Project, content ProjectContent) error
+	// AddProjectContent adds content to an existing project, also all or nothing.
+	AddProjectContent(content ProjectContent) error
+	// UpdateProject changes the name, rule, type, redundancy, match rule and
+	// gold settings of a project. A new redundancy re-evaluates the tasks and
+	// articles as SetRedundancy does, in the same transaction.
+	UpdateProject(project models.Project) error
+	SetProjectArchived(projectId primitive.ObjectID, archived bool) error
+	// SetRedundancy changes how many annotators every task of a project
+	// needs, and re-evaluates which tasks and articles are answered.
+	SetRedundancy(projectId primitive.ObjectID, redundancy int) error
+	// CountProjectArticles counts MRC and sentiment articles alike.
+	CountProjectArticles(projectId primitive.ObjectID) (int64, error)
+	// DeleteProject removes the project with its auths, articles, tasks,
+	// answers, validations, decisions and gold labels, all together or not
+	// at all.
+	DeleteProject(projectId primitive.ObjectID) error
+}
+
+// ArticleStore reads and writes the Articles and SentiArticles collections.
+type ArticleStore interface {
+	GetArticlesByProjectId(projectId primitive.ObjectID) ([]models.Article, error)
+	GetArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error)
+	SaveArticles(articles []models.Article) error
+	GetSentiArticles(query models.Project) ([]models.Article, error)
+	GetSentiArticleByArticleId(articleId primitive.ObjectID) (*models.Article, error)
+}
+
+// MRCTaskStore reads and writes the MRCTask collection.
+type MRCTaskStore interface {
+	GetTasksByArticleId(articleId string) ([]models.MRCTask, error)
+	GetTaskById(task models.MRCTask) (*models.MRCTask, error)
+	SaveTasks(tasks []models.MRCTask) error
+	// AddMRCAnnotator counts userId among the annotators of a task, once.
+	// The article counts the task as answered when it reaches required, and
+	// is answered with the project once all their tasks are.
+	AddMRCAnnotator(task models.MRCTask, userId string, required int) error
+	// GetOpenMRCTask returns the first task of a project that has fewer
+	// than required annotators, userId not among them.
+	GetOpenMRCTask(projectId primitive.ObjectID, userId string, required int) (*models.MRCTask, error)
+}
+
+// MRCAnswerStore reads and writes the MRCAnswer collection.
+type MRCAnswerStore interface {
+	GetAnswers(query models.MRCAnswer) ([]*models.MRCAnswer, error)
+	FindAnswerById(id primitive.ObjectID) (*models.MRCAnswer, error)
+	// GetAnswersByArticleIds returns the MRC answers of the articles,
+	// leaving out the answers written by validators.
+	GetAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error)
+	// GetValidationAnswersByArticleIds returns the answers validators gave
+	// to the questions of the articles.
+	GetValidationAnswersByArticleIds(articleIds []string) ([]models.MRCAnswer, error)
+	// GetRandomValidationQuestion returns the first answer of
+	// question.ProjectId waiting for validation that is neither
+	// question.UserId's own nor in exclude; question.TaskType is ignored.
+	GetRandomValidationQuestion(question models.MRCAnswer, exclude []primitive.ObjectID) (*models.MRCAnswer, error)
+	SaveAnswer(answer models.MRCAnswer) (primitive.ObjectID, error)
+	// AnswerMRCTask saves the answer of an annotator to task in one
+	// transaction with what it counts for: gold, the result of a gold
+	// question, or without gold a new annotator of task, see
+	// AddMRCAnnotator. A second result of the user on the gold label is
+	// refused with ErrAnnotated, and the annotator is paused once they
+	// answered a full window of gold tasks with an accuracy below the
+	// MinAccuracy of project.
+	AnswerMRCTask(project models.Project, task models.MRCTask, answer models.MRCAnswer, gold *models.GoldResult) (primitive.ObjectID, error)
+	// UpdateAnswer sets the status an adjudicator, userId, decided on.
+	UpdateAnswer(answer models.MRCValidation, userId string) error
+}
+
+// MRCValidationStore reads and writes the MRCValidation collection.
+type MRCValidationStore interface {
+	GetRandomDecisionInfo(projectId primitive.ObjectID, userId string, exclude []primitive.ObjectID) (*models.MRCValidation, error)
+	SaveValidationStatus(validationAnswer models.MRCValidation) (primitive.ObjectID, error)
+	// SaveValidation saves the answer of a validator, the validation
+	// pointing to it and the status of the original answer together, and
+	// returns the id of the validation. It fails, saving nothing, with
+	// ErrNotFound when the original answer is gone and with ErrDecided
+	// when it is no longer unverified.
+	SaveValidation(answer models.MRCAnswer, validation models.MRCValidation) (primitive.ObjectID, error)
+	UpdateValidationStatus(status models.MRCValidation) error
+	// GetValidationsByProjectId returns the validations of a project, the
+	// status of decided ones being the adjudicator's.
+	GetValidationsByProjectId(projectId primitive.ObjectID) ([]models.MRCValidation, error)
+}
+
+// MRCDecisionStore writes the MRCDecision collection.
+type MRCDecisionStore interface {
+	SaveDecision(decisionResult models.MRCDecision) (primitive.ObjectID, error)
+	// Decide settles the pending validation decision.ValidationStatusId of
+	// the answer decision.OriginalId in a project: the answer and the
+	// validation take status and decision is saved, all or none of them.
+	// It fails with ErrNotFound when the validation is not of that answer
+	// and project, with ErrOwnWork when decision.UserId labelled or
+	// validated the answer, and with ErrDecided when it is not pending.
+	Decide(projectId primitive.ObjectID, decision models.MRCDecision, status string) (primitive.ObjectID, error)
+	GetDecisionsByOriginalIds(originalIds []primitive.ObjectID) ([]models.MRCDecision, error)
+}
+
+// SentiTaskStore reads and writes the SentiTask collection and the
+// article flags derived from it.
+type SentiTaskStore interface {
+	GetSentiTasksByArticleId(articleId primitive.ObjectID, isAnswered bool) ([]models.SentiTask, error)
+	GetSentiTaskById(taskId primitive.ObjectID, taskType string) (*models.SentiTask, error)
+	FindSentiTaskById(taskId primitive.ObjectID) (*models.SentiTask, error)
+	GetSentiTasksByProjectId(projectId primitive.ObjectID) ([]models.SentiTask, error)
+	// GetRandomSentiTask returns the first task of query.ProjectId and
+	// query.TaskType waiting for validation that userId did not annotate
+	// and that is not in exclude.
+	GetRandomSentiTask(query models.SentiTask, userId string, exclude []primitive.ObjectID) (*models.SentiTask, error)
+	// GetOpenSentiTask returns the first task of a project that is neither
+	// answered nor gold, and userId did not annotate.
+	GetOpenSentiTask(projectId primitive.ObjectID, userId string) (*models.SentiTask, error)
+	// CheckIsAnswered and CheckIsValidated derive the completion of the
+	// article of query once more and report it. Every write that changes a
+	// task keeps its article, and project, complete already.
+	CheckIsAnswered(query models.SentiTask) (bool, error)
+	CheckIsValidated(query models.SentiTask) (bool, error)
+	// DiscardSentiAnswer sends a task back to the annotators without their
+	// aspects and sentiments, which userId rejected for reason.
+	DiscardSentiAnswer(query models.SentiTask, userId string, reason string) (bool, error)
+}
+
+// SentiAspectStore reads the SentiAspect collection.
+type SentiAspectStore interface {
+	GetAspectByTaskId(query models.SentiAspect) ([]*models.SentiAspect, error)
+	GetAspectsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiAspect, error)
+}
+
+// SentiSentimentStore reads and writes the SentiSentiment and
+// SentiFinalAnswer collections.
+type SentiSentimentStore interface {
+	GetSentiAnswer(query models.SentiSentiment) ([]*models.SentiSentiment, error)
+	GetSentimentsByTaskIds(taskIds []primitive.ObjectID) ([]models.SentiSentiment, error)
+	// SaveSentiAnswer adds the aspects and sentiments of one annotator to
+	// their task, which is answered once required distinct annotators
+	// answered it. ErrAnnotated or ErrTaskAnswered refuse the answer. With
+	// required 0 the task takes every annotator and is never answered, as
+	// gold tasks are.
+	SaveSentiAnswer(answer models.SentiAnswer, required int) error
+	// AnswerSentiTask is SaveSentiAnswer with gold, the result of a gold
+	// task, recorded in the same transaction as AnswerMRCTask records it. A
+	// user who answered the label before keeps their first result.
+	AnswerSentiTask(project models.Project, answer models.SentiAnswer, required int, gold *models.GoldResult) error
+	// SaveFinalAnswer saves the validation of answer.Task and marks the task
+	// validated, or returns ErrDecided when it already is.
+	SaveFinalAnswer(answer models.SentiAnswer) (primitive.ObjectID, error)
+	// GetFinalAnswersByProjectId returns the validated answers of a project
+	// in the order they were saved.
+	GetFinalAnswersByProjectId(projectId primitive.ObjectID) ([]models.SentiAnswer, error)
+}
+
+// ClaimStore keeps the Claim collection, see models.Claim.
+type ClaimStore interface {
+	// TakeClaim gives the item to claim.UserId, unless another user holds a
+	// live claim on it; ErrClaimed then.
+	TakeClaim(claim models.Claim) error
+	// RenewClaim moves the expiry of the user's claim on an item to
+	// expiresAt, expired or not. ErrNotFound once someone else took it over.
+	RenewClaim(kind string, itemId primitive.ObjectID, userId string, expiresAt time.Time) (*models.Claim, error)
+	// ReleaseClaim drops the user's claim on an item, if any.
+	ReleaseClaim(kind string, itemId primitive.ObjectID, userId string) error
+	// ReleaseOtherClaims drops the user's claims in a queue of a project but the one on keep.
+	ReleaseOtherClaims(projectId primitive.ObjectID, kind string, userId string, keep primitive.ObjectID) error
+	GetClaim(kind string, itemId primitive.ObjectID) (*models.Claim, error)
+	// GetLiveClaims lists the claims of a project that have not expired,
+	// of every queue when kind is empty.
+	GetLiveClaims(projectId primitive.ObjectID, kind string) ([]models.Claim, error)
+}
+
+// GoldStore reads and writes the Label and GoldResult collections.
+type GoldStore interface {
+	// SaveLabel adds the gold label of a task, or replaces the label of the
+	// same MRC question or sentiment task. Sentiment tasks become gold.
+	SaveLabel(label models.Label) (primitive.ObjectID, error)
+	// DeleteLabel removes a label of a project; its sentiment task is no
+	// longer gold, and is answered once it has the annotators the project
+	// needs.
+	DeleteLabel(projectId primitive.ObjectID, labelId primitive.ObjectID) error
+	GetLabels(projectId primitive.ObjectID) ([]models.Label, error)
+	// SaveGoldResult refuses a second result of a user on a label with
+	// ErrAnnotated.
+	SaveGoldResult(result models.GoldResult) error
+	// GetGoldResults returns the results of a project in the order they
+	// were answered, of every user when userId is empty.
+	GetGoldResults(projectId primitive.ObjectID, userId string) ([]models.GoldResult, error)
+}
+
+// ProgressStore reports where the work of a project stands.
+type ProgressStore interface {
+	// GetProgress counts the items of a project in each state, over the
+	// project and per article, and the work done on each day. Gold tasks
+	// and answers are left out.
+	GetProgress(project models.Project) (*models.Progress, error)
+}
+
+// RevisionStore reads and restores the versions of annotations. The other
+// stores record a models.Revision in the same transaction as every change
+// to a sentiment task or MRC answer.
+type RevisionStore interface {
+	// GetRevisions returns the revisions of a project in the order they
+	// were made, only those of targetId unless it is zero.
+	GetRevisions(projectId primitive.ObjectID, targetId primitive.ObjectID) ([]models.Revision, error)
+	GetRevision(projectId primitive.ObjectID, id primitive.ObjectID) (*models.Revision, error)
+	// RestoreRevision brings the annotation of a revision back as it was
+	// then, recording that as a new revision by userId for reason, which
+	// it returns. Sentiment tasks get their aspects and sentiments back,
+	// unless the task was validated since the revision, or is no longer as
+	// validated as it was then: that fails with ErrReviewed. MRC answers
+	// keep their status: restoring one with a decision fails with
+	// ErrDecided, and one whose status changed since with ErrReviewed.
+	RestoreRevision(projectId primitive.ObjectID, id primitive.ObjectID, userId string, reason string) (*models.Revision, error)
+}
+
+// Pinger reports whether the backing database is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Store is everything the controllers need from the storage layer.
+type Store interface {
+	Pinger
+	UserStore
+	AuthStore
+	ProjectStore
+	ArticleStore
+	MRCTaskStore
+	MRCAnswerStore
+	MRCValidationStore
+	MRCDecisionStore
+	SentiTaskStore
+	SentiAspectStore
+	SentiSentimentStore
+	ClaimStore
+	GoldStore
+	ProgressStore
+	RevisionStore
+}
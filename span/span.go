@@ -0,0 +1,103 @@
+// Package span converts offsets into a text between the units clients count
+// in. Lynx stores every offset in runes, Unicode code points, which is also
+// what SQuAD and SemEval files use; JavaScript counts UTF-16 code units and
+// Go strings bytes, and in Chinese text all three disagree.
+package span
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header is the request header naming the unit of the offsets in a request
+// and its response.
+const Header = "X-Offset-Unit"
+
+type Unit string
+
+const (
+	Rune  Unit = "rune"
+	UTF16 Unit = "utf16"
+	Byte  Unit = "byte"
+)
+
+// ParseUnit reads the value of Header, Rune when it is empty.
+func ParseUnit(s string) (Unit, error) {
+	switch unit := Unit(strings.ToLower(strings.TrimSpace(s))); unit {
+	case "":
+		return Rune, nil
+	case Rune, UTF16, Byte:
+		return unit, nil
+	}
+	return "", fmt.Errorf("%s %q must be %s, %s or %s", Header, s, Rune, UTF16, Byte)
+}
+
+// width is how many units r takes.
+func width(r rune, unit Unit) int {
+	switch unit {
+	case UTF16:
+		if r >= 0x10000 {
+			return 2
+		}
+		return 1
+	case Byte:
+		return utf8.RuneLen(r)
+	}
+	return 1
+}
+
+// ToRunes converts an offset into text counted in unit into runes. Offsets
+// past the end of text or inside a character, the middle of a UTF-8
+// sequence or of a surrogate pair, are errors. Negative offsets mean no
+// position and are returned as they are.
+func ToRunes(text string, offset int, unit Unit) (int, error) {
+	if offset < 0 {
+		return offset, nil
+	}
+	at, runes := 0, 0
+	for _, r := range text {
+		if at >= offset {
+			break
+		}
+		at += width(r, unit)
+		runes++
+	}
+	if at != offset {
+		if at < offset {
+			return 0, fmt.Errorf("offset %d is past the end of the text", offset)
+		}
+		return 0, fmt.Errorf("offset %d splits a character when counted in %s", offset, unit)
+	}
+	return runes, nil
+}
+
+// FromRunes converts a rune offset into text into unit. Negative offsets
+// are returned as they are.
+func FromRunes(text string, offset int, unit Unit) (int, error) {
+	if offset < 0 {
+		return offset, nil
+	}
+	at, runes := 0, 0
+	for _, r := range text {
+		if runes == offset {
+			break
+		}
+		at += width(r, unit)
+		runes++
+	}
+	if runes != offset {
+		return 0, fmt.Errorf("offset %d is past the end of the text", offset)
+	}
+	return at, nil
+}
+
+// Check reports an error unless want is the text at rune offset.
+func Check(text string, offset int, want string) error {
+	runes := []rune(text)
+	end := offset + utf8.RuneCountInString(want)
+	if offset < 0 || end > len(runes) || string(runes[offset:end]) != want {
+		return fmt.Errorf("%q is not found at offset %d of the context", want, offset)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package span
+
+import "testing"
+
+// text mixes CJK characters, three UTF-8 bytes and one UTF-16 unit each,
+// an astral plane emoji, four bytes and a surrogate pair, and ASCII.
+//
+//	rune  0 臺 1 灣 2 🐈 3 a 4 b 5
+//	utf16 0    1    2    4   5   6
+//	byte  0    3    6   10  11  12
+const text = "臺灣🐈ab"
+
+var offsets = []struct {
+	runes, utf16, bytes int
+}{
+	{0, 0, 0},
+	{1, 1, 3},
+	{2, 2, 6},
+	{3, 4, 10},
+	{4, 5, 11},
+	{5, 6, 12},
+}
+
+func TestToRunes(t *testing.T) {
+	type test struct {
+		unit    Unit
+		offset  int
+		want    int
+		wantErr bool
+	}
+	var tests []test
+	for _, o := range offsets {
+		tests = append(tests,
+			test{unit: Rune, offset: o.runes, want: o.runes},
+			test{unit: UTF16, offset: o.utf16, want: o.runes},
+			test{unit: Byte, offset: o.bytes, want: o.runes},
+		)
+	}
+	tests = append(tests,
+		// no position
+		test{unit: Rune, offset: -1, want: -1},
+		test{unit: UTF16, offset: -1, want: -1},
+		test{unit: Byte, offset: -1, want: -1},
+		// inside a character
+		test{unit: UTF16, offset: 3, wantErr: true},
+		test{unit: Byte, offset: 1, wantErr: true},
+		test{unit: Byte, offset: 5, wantErr: true},
+		test{unit: Byte, offset: 7, wantErr: true},
+		test{unit: Byte, offset: 9, wantErr: true},
+		// past the end
+		test{unit: Rune, offset: 6, wantErr: true},
+		test{unit: UTF16, offset: 7, wantErr: true},
+		test{unit: Byte, offset: 13, wantErr: true},
+	)
+	for _, tt := range tests {
+		got, err := ToRunes(text, tt.offset, tt.unit)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ToRunes(%d, %s) = %d, %v, want %d, error %v", tt.offset, tt.unit, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFromRunes(t *testing.T) {
+	for _, o := range offsets {
+		for unit, want := range map[Unit]int{Rune: o.runes, UTF16: o.utf16, Byte: o.bytes} {
+			got, err := FromRunes(text, o.runes, unit)
+			if err != nil || got != want {
+				t.Errorf("FromRunes(%d, %s) = %d, %v, want %d", o.runes, unit, got, err, want)
+			}
+			back, err := ToRunes(text, got, unit)
+			if err != nil || back != o.runes {
+				t.Errorf("ToRunes(FromRunes(%d, %s)) = %d, %v", o.runes, unit, back, err)
+			}
+		}
+	}
+	for _, unit := range []Unit{Rune, UTF16, Byte} {
+		if got, err := FromRunes(text, -1, unit); err != nil || got != -1 {
+			t.Errorf("FromRunes(-1, %s) = %d, %v, want -1", unit, got, err)
+		}
+		if got, err := FromRunes(text, 6, unit); err == nil {
+			t.Errorf("FromRunes(6, %s) = %d, want an error past the end", unit, got)
+		}
+		if got, err := FromRunes("", 0, unit); err != nil || got != 0 {
+			t.Errorf("FromRunes of an empty text = %d, %v, want 0", got, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		offset int
+		want   string
+		ok     bool
+	}{
+		{0, "臺灣", true},
+		{2, "🐈", true},
+		{2, "🐈a", true},
+		{3, "ab", true},
+		{5, "", true},
+		{0, "灣", false},
+		// a byte or UTF-16 offset is not a rune offset
+		{6, "🐈", false},
+		{4, "ab", false},
+		{-1, "臺", false},
+		{6, "", false},
+		{10, "a", false},
+	}
+	for _, tt := range tests {
+		err := Check(text, tt.offset, tt.want)
+		if (err == nil) != tt.ok {
+			t.Errorf("Check(%d, %q) = %v, want ok %v", tt.offset, tt.want, err, tt.ok)
+		}
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    Unit
+		wantErr bool
+	}{
+		{"", Rune, false},
+		{"rune", Rune, false},
+		{" UTF16 ", UTF16, false},
+		{"byte", Byte, false},
+		{"utf-16", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnit(tt.header)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseUnit(%q) = %q, %v, want %q", tt.header, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package viewModels
+
+import (
+	"time"
+
+	"Lynx/models"
+)
+
+type LoginRequestModel struct {
+	Credential string `json:"credential"`
+}
+
+type LoginViewModel struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+	User      models.User `json:"user"`
+}
@@ -0,0 +1,42 @@
+package viewModels
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GoldRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	Id        primitive.ObjectID `json:"id"`
+}
+
+type ResumeAnnotatorRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	UserId    string             `json:"userId"`
+}
+
+// GoldStatsViewModel is how an annotator does on the gold tasks of a
+// project. WindowAccuracy is over the last Window answers, which is what
+// pauses annotators.
+type GoldStatsViewModel struct {
+	UserId         string   `json:"userId"`
+	Answered       int      `json:"answered"`
+	Correct        int      `json:"correct"`
+	Accuracy       *float64 `json:"accuracy"`
+	MeanScore      *float64 `json:"meanScore"`
+	WindowAccuracy *float64 `json:"windowAccuracy"`
+	Window         int      `json:"window"`
+	Paused         bool     `json:"paused"`
+}
+
+// NextTaskViewModel is the task /nextTask hands an annotator, gold or not.
+// Sentiment tasks have an AspectPool, MRC ones a Question when the
+// annotator is to answer rather than ask it.
+type NextTaskViewModel struct {
+	TaskType   string   `json:"taskType"`
+	ArticleId  string   `json:"articleId"`
+	TaskId     string   `json:"taskId"`
+	TaskTitle  string   `json:"taskTitle"`
+	Context    string   `json:"context"`
+	AspectPool []string `json:"aspectPool,omitempty"`
+	Question   string   `json:"question,omitempty"`
+}
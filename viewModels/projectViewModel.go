@@ -1,6 +1,9 @@
 package viewModels
 
 import (
+	"encoding/json"
+
+	"Lynx/importer"
 	"Lynx/models"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,20 +15,101 @@ type ProjectViewModel struct {
 	ProjectType string             `bson:"type" json:"type"`
 	Rule        string             `bson:"rule" json:"rule"`
 	ManagerId   string             `bson:"managerId" json:"managerId"`
+	Archived    bool               `bson:"archived" json:"archived"`
 	ArticleList []models.Article   `bson:"articleList" json:"articleList"`
 }
 
+type ProjectListRequestModel struct {
+	IncludeArchived bool `json:"includeArchived"`
+}
+
+// ProjectListViewModel is a project with the roles the user holds in it.
+type ProjectListViewModel struct {
+	models.Project
+	Roles []string `json:"roles"`
+}
+
+// UpdateProjectRequestModel leaves out what should not change: an empty
+// name or type, or no rule at all.
+type UpdateProjectRequestModel struct {
+	ProjectId   primitive.ObjectID   `json:"projectId"`
+	ProjectName string               `json:"name"`
+	ProjectType string               `json:"type"`
+	Rule        *string              `json:"rule"`
+	Redundancy  *int                 `json:"redundancy"`
+	MatchRule   *models.MatchRule    `json:"matchRule"`
+	Gold        *models.GoldSettings `json:"gold"`
+}
+
 type ProjectManageViewModel struct {
-	ProjectId  int    `bson:"projectId" json:"projectId"`
-	UserId     string `bson:"userId" json:"userId"`
-	CodeType   string `bson:"codeType" json:"codeType"`
-	StatusCode string `bson:"statusCode" json:"statusCode"`
-	Name       string `bson:"name" json:"name"`
-	Email      string `bson:"email" json:"email"`
-	ImageUrl   string `bson:"imageUrl" json:"imageUrl"`
+	ProjectId  primitive.ObjectID `bson:"projectId" json:"projectId"`
+	UserId     string             `bson:"userId" json:"userId"`
+	Role       string             `bson:"role" json:"role"`
+	CodeType   string             `bson:"codeType" json:"codeType"`
+	StatusCode string             `bson:"statusCode" json:"statusCode"`
+	Name       string             `bson:"name" json:"name"`
+	Email      string             `bson:"email" json:"email"`
+	ImageUrl   string             `bson:"imageUrl" json:"imageUrl"`
 }
 type AddProjectViewModel struct {
 	Project models.Project `bson:"project" json:"project"`
 	Members []models.Auth  `bson:"members" json:"members"`
 	CsvFile [][]string     `bson:"csvFile" json:"csvFile"`
+	// Squad replaces CsvFile with a SQuAD dataset for MRC projects.
+	Squad        json.RawMessage `bson:"squad" json:"squad"`
+	AnswerStatus string          `bson:"answerStatus" json:"answerStatus"`
+	// Absa replaces CsvFile with an ABSA dataset for sentiment projects.
+	Absa *AbsaImportModel `bson:"absa" json:"absa"`
+}
+
+type ImportSquadRequestModel struct {
+	ProjectId    primitive.ObjectID `json:"projectId"`
+	Squad        json.RawMessage    `json:"squad"`
+	AnswerStatus string             `json:"answerStatus"`
+}
+
+// AbsaImportModel is an ABSA dataset with how to read it, see importer.ParseABSA.
+type AbsaImportModel struct {
+	// Format is semeval or jsonl, guessed when empty.
+	Format     string   `json:"format"`
+	Data       string   `json:"data"`
+	AspectPool []string `json:"aspectPool"`
+	WithSeeds  bool     `json:"withSeeds"`
+}
+
+type ImportAbsaRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	AbsaImportModel
+}
+
+// ImportResultViewModel counts what an import added.
+type ImportResultViewModel struct {
+	Success  bool `json:"success"`
+	Articles int  `json:"articles"`
+	Tasks    int  `json:"tasks"`
+	Answers  int  `json:"answers"`
+}
+
+// ImportErrorViewModel lists every bad row of a rejected upload.
+type ImportErrorViewModel struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Errors  importer.RowErrors `json:"errors"`
+}
+
+// ExportSquadRequestModel selects answers by status or decision result,
+// verified answers when both are left out.
+type ExportSquadRequestModel struct {
+	ProjectId       primitive.ObjectID `json:"projectId"`
+	Version         string             `json:"version"`
+	Statuses        []string           `json:"statuses"`
+	DecisionResults []string           `json:"decisionResults"`
+}
+
+// ExportAbsaRequestModel picks the format, semeval by default, and whether
+// tasks whose validation did not all match are exported too.
+type ExportAbsaRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	Format    string             `json:"format"`
+	AllItems  bool               `json:"allItems"`
 }
@@ -0,0 +1,35 @@
+package viewModels
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DiscardRequestModel sends the sentiment task TaskId back to its
+// annotators; Reason is kept with the revision it makes.
+type DiscardRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	TaskId    primitive.ObjectID `json:"_id"`
+	Reason    string             `json:"reason"`
+}
+
+// HistoryRequestModel asks for the revisions of a project, of the sentiment
+// task or MRC answer TargetId only when it is set.
+type HistoryRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	TargetId  primitive.ObjectID `json:"targetId"`
+}
+
+// RevisionDiffRequestModel asks what changed from one revision of an
+// annotation to another.
+type RevisionDiffRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	From      primitive.ObjectID `json:"from"`
+	To        primitive.ObjectID `json:"to"`
+}
+
+// RestoreRequestModel brings back the annotation of RevisionId.
+type RestoreRequestModel struct {
+	ProjectId  primitive.ObjectID `json:"projectId"`
+	RevisionId primitive.ObjectID `json:"revisionId"`
+	Reason     string             `json:"reason"`
+}
@@ -0,0 +1,16 @@
+package viewModels
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LeaderboardRequestModel asks for the scores of the users of a project,
+// of the work done from Since up to Until when they are set.
+type LeaderboardRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	SortBy    string             `json:"sortBy"`
+	Since     *time.Time         `json:"since"`
+	Until     *time.Time         `json:"until"`
+}
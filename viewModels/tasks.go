@@ -1,6 +1,8 @@
 package viewModels
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -44,6 +46,8 @@ type ValidationQAPairModel struct {
 	Question    string `bson:"question" json:"question"`
 	TaskTitle   string `bson:"taskTitle" json:"taskTitle"`
 	TaskContext string `bson:"taskContext" json:"taskContext"`
+	// ClaimExpiresAt is when the item goes back to the queue without a /renewClaim.
+	ClaimExpiresAt time.Time `bson:"claimExpiresAt" json:"claimExpiresAt"`
 }
 
 type TaskViewModel struct {
@@ -64,6 +68,8 @@ type SentiTaskViewModel struct {
 	Context    string             `bson:"context" json:"context"`
 	AspectPool []string           `bson:"aspectPool" json:"aspectPool"`
 	IsAnswered bool               `bson:"isAnswered" json:"isAnswered"`
+	// ClaimExpiresAt is set by /getSentiValidation, which claims the task.
+	ClaimExpiresAt *time.Time `bson:"claimExpiresAt,omitempty" json:"claimExpiresAt,omitempty"`
 }
 
 type ValidationDataViewModel struct {
@@ -83,4 +89,23 @@ type DecisionDataViewModel struct {
 	ValidationAnswer    string             `bson:"validationAnswer" json:"validationAnswer"`
 	ValidationStartIdx  int                `bson:"validationStartIdx" json:"validationStartIdx"`
 	OriginalTaskContext string             `bson:"originalTaskContext" json:"originalTaskContext"`
+	ClaimExpiresAt      time.Time          `bson:"claimExpiresAt" json:"claimExpiresAt"`
+	// Score and Method tell how close the validation came to the original.
+	Score  float64 `bson:"score" json:"score"`
+	Method string  `bson:"method" json:"method"`
+}
+
+// SentiFinalAnswersRequestModel narrows the validations of a project down
+// to one task or state when they are set.
+type SentiFinalAnswersRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	TaskId    primitive.ObjectID `json:"taskId"`
+	State     string             `json:"state"`
+}
+
+// ClaimRequestModel names a claimed item, see models.Claim.
+type ClaimRequestModel struct {
+	ProjectId primitive.ObjectID `json:"projectId"`
+	Kind      string             `json:"kind"`
+	ItemId    primitive.ObjectID `json:"itemId"`
 }